@@ -0,0 +1,780 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/JSchrtke/structogen/codegen"
+	"github.com/JSchrtke/structogen/importer"
+	"github.com/JSchrtke/structogen/interp"
+	"github.com/JSchrtke/structogen/lsp"
+	"github.com/JSchrtke/structogen/nsd"
+	"github.com/JSchrtke/structogen/project"
+	"github.com/JSchrtke/structogen/render"
+	"github.com/JSchrtke/structogen/syntax"
+)
+
+// Exit codes of the command line interface.
+const (
+	exitOk    = 0
+	exitError = 1
+	exitUsage = 2
+)
+
+const usage = `usage: structogen <command> [flags] [file]
+
+Commands:
+    parse           print the parsed structogram as json
+    render          render the structogram as a diagram
+    check           check files for syntax errors
+    fmt             format files in the canonical formatting
+    gen             generate skeleton source code from the structogram
+    run             run the structogram and print its output
+    lsp             run a language server on stdin and stdout
+    import-python   translate a Python function into a structogram
+    import-go       translate a Go function into a structogram
+    import-nsd      translate a Structorizer .nsd file into a structogram
+    export-nsd      translate the structogram into a Structorizer .nsd file
+
+If no file is given, or the file is "-", the input is read from stdin.
+Run "structogen <command> -h" for the flags of a command.
+`
+
+// errUsage is returned by commands if they were called with invalid
+// arguments. The usage has already been printed in that case.
+var errUsage = errors.New("invalid usage")
+
+// errReported is returned by commands that failed and have already reported
+// the reason themselves.
+var errReported = errors.New("failure reported")
+
+// cli holds the streams a command operates on, so commands can be tested
+// without touching the real stdin, stdout and stderr.
+type cli struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// input is a structogram source, along with the name that is used to refer
+// to it in diagnostics and the path it was read from, which is "-" for
+// stdin.
+type input struct {
+	name   string
+	path   string
+	source string
+}
+
+func run(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	c := cli{stdin: stdin, stdout: stdout, stderr: stderr}
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return exitUsage
+	}
+
+	var err error
+	switch args[0] {
+	case "parse":
+		err = c.parse(args[1:])
+	case "render":
+		err = c.render(args[1:])
+	case "check":
+		err = c.check(args[1:])
+	case "fmt":
+		err = c.fmt(args[1:])
+	case "gen":
+		err = c.gen(args[1:])
+	case "run":
+		err = c.runStructogram(args[1:])
+	case "lsp":
+		err = c.lsp(args[1:])
+	case "import-python":
+		err = c.importFunction("import-python", importer.Python, args[1:])
+	case "import-go":
+		err = c.importFunction("import-go", importer.Go, args[1:])
+	case "import-nsd":
+		err = c.importNSD(args[1:])
+	case "export-nsd":
+		err = c.exportNSD(args[1:])
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stdout, usage)
+		return exitOk
+	default:
+		fmt.Fprintf(stderr, "structogen: unknown command %q\n\n", args[0])
+		fmt.Fprint(stderr, usage)
+		return exitUsage
+	}
+
+	if err == errUsage {
+		return exitUsage
+	}
+	if err == errReported {
+		return exitError
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "%s\n", err)
+		return exitError
+	}
+	return exitOk
+}
+
+func (c *cli) flagSet(name string, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(c.stderr, "usage: structogen %s [flags] %s\n", name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFlags parses the flags of a command and returns its inputs. Unless
+// multiple is set, at most one input is accepted. Otherwise, a directory
+// stands for all .str files below it.
+func (c *cli) parseFlags(
+	fs *flag.FlagSet, args []string, multiple bool,
+) ([]input, error) {
+	if err := fs.Parse(args); err != nil {
+		return nil, errUsage
+	}
+	paths := fs.Args()
+	if len(paths) == 0 {
+		paths = []string{"-"}
+	}
+	if len(paths) > 1 && !multiple {
+		fmt.Fprintf(c.stderr, "structogen %s: too many files\n", fs.Name())
+		fs.Usage()
+		return nil, errUsage
+	}
+
+	if multiple {
+		var err error
+		if paths, err = expandDirectories(paths); err != nil {
+			return nil, fmt.Errorf("structogen: %s", err)
+		}
+	}
+
+	var inputs []input
+	for _, path := range paths {
+		var source []byte
+		var err error
+		name := path
+		if path == "-" {
+			name = "<stdin>"
+			source, err = io.ReadAll(c.stdin)
+		} else {
+			source, err = os.ReadFile(path)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("structogen: %s", err)
+		}
+		inputs = append(
+			inputs, input{name: name, path: path, source: string(source)},
+		)
+	}
+	return inputs, nil
+}
+
+// expandDirectories replaces the directories among paths with the .str
+// files below them, in lexical order.
+func expandDirectories(paths []string) ([]string, error) {
+	var expanded []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if path == "-" || err != nil || !info.IsDir() {
+			// Errors are reported when the file is read.
+			expanded = append(expanded, path)
+			continue
+		}
+		err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && filepath.Ext(p) == ".str" {
+				expanded = append(expanded, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return expanded, nil
+}
+
+// parseInput parses the structograms of in. All syntax errors are reported,
+// one per line and prefixed with the input name.
+func parseInput(in input) ([]*syntax.Structogram, error) {
+	s, err := syntax.ParseAllTokens(syntax.Tokenize(in.source))
+	if diagnostics, ok := err.(syntax.Diagnostics); ok {
+		var lines []string
+		for _, d := range diagnostics {
+			lines = append(lines, fmt.Sprintf("%s:%s", in.name, d.Error()))
+		}
+		return s, errors.New(strings.Join(lines, "\n"))
+	}
+	return s, err
+}
+
+// selectStructogram returns the structogram of in with the given name, which
+// can also be the name it is called by, see project.CalledName. Without a
+// name, the input has to contain a single structogram.
+func selectStructogram(
+	in input, structograms []*syntax.Structogram, name string,
+) (*syntax.Structogram, error) {
+	if name == "" {
+		if len(structograms) == 1 {
+			return structograms[0], nil
+		}
+		return nil, fmt.Errorf(
+			"structogen: %s: contains %d structograms, select one with -name",
+			in.name, len(structograms),
+		)
+	}
+	for _, s := range structograms {
+		if s.Name == name || project.CalledName(s.Name) == name {
+			return s, nil
+		}
+	}
+	return nil, fmt.Errorf("structogen: %s: no structogram named %q", in.name, name)
+}
+
+// stringList is a flag that can be given several times, like -I.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// loadInputs loads the inputs along with the files they import, which are
+// looked up in searchPath if they are not found next to the importing file.
+func loadInputs(inputs []input, searchPath []string) *project.Loader {
+	loader := &project.Loader{SearchPath: searchPath}
+	for _, in := range inputs {
+		path := in.path
+		if path == "-" {
+			path = ""
+		}
+		loader.Load(in.name, path, in.source)
+	}
+	return loader
+}
+
+// writeOutput writes the output of a command to the given path, or to stdout
+// if the path is empty.
+func (c *cli) writeOutput(path string, output []byte) error {
+	if path == "" {
+		_, err := c.stdout.Write(output)
+		return err
+	}
+	if err := os.WriteFile(path, output, 0644); err != nil {
+		return fmt.Errorf("structogen: %s", err)
+	}
+	return nil
+}
+
+func (c *cli) parse(args []string) error {
+	fs := c.flagSet("parse", "[file]")
+	out := fs.String("o", "", "write the output to `file` instead of stdout")
+	inputs, err := c.parseFlags(fs, args, false)
+	if err != nil {
+		return err
+	}
+
+	structograms, err := parseInput(inputs[0])
+	if err != nil {
+		return err
+	}
+	// A file with several structograms is printed as an array of them.
+	var j []byte
+	if len(structograms) == 1 {
+		j, err = json.MarshalIndent(structograms[0], "", "    ")
+	} else {
+		j, err = json.MarshalIndent(structograms, "", "    ")
+	}
+	if err != nil {
+		return err
+	}
+	return c.writeOutput(*out, append(j, '\n'))
+}
+
+func (c *cli) render(args []string) error {
+	fs := c.flagSet("render", "[file ...]")
+	out := fs.String("o", "", "write the output to `file` instead of stdout")
+	format := fs.String(
+		"format", "svg",
+		"output `format`, svg, pdf, png, html, tex, tikz or text",
+	)
+	standalone := fs.Bool(
+		"standalone", false,
+		"write a complete LaTeX document for the tex and tikz formats",
+	)
+	width := fs.Int(
+		"width", 40, "wrap text at `columns` characters for the text format",
+	)
+	ascii := fs.Bool(
+		"ascii", false, "draw only with ascii characters for the text format",
+	)
+	dpi := fs.Float64(
+		"dpi", 96, "rasterize with `dpi` pixels per inch for the png format",
+	)
+	scale := fs.Float64("scale", 1, "scale the png format by `factor`")
+	transparent := fs.Bool(
+		"transparent", false, "leave the background of the png format transparent",
+	)
+	name := fs.String(
+		"name", "", "render the structogram called `name`, if there are several",
+	)
+	link := fs.String(
+		"link", "{name}.svg",
+		"link the calls of the svg format to `url`, where {name} is the name "+
+			"of the called structogram, or not at all if it is empty",
+	)
+	var searchPath stringList
+	fs.Var(
+		&searchPath, "I",
+		"look up imports in `dir` as well, for the html and svg formats, "+
+			"can be repeated",
+	)
+	// Only html bundles several files, the other formats draw a single one.
+	inputs, err := c.parseFlags(fs, args, true)
+	if err != nil {
+		return err
+	}
+	if *format == "html" {
+		return c.renderHTML(inputs, searchPath, *out)
+	}
+	if len(inputs) > 1 {
+		fmt.Fprintf(c.stderr, "structogen render: too many files\n")
+		fs.Usage()
+		return errUsage
+	}
+
+	var renderer func(io.Writer, *syntax.Structogram) error
+	switch *format {
+	case "svg":
+		renderer = func(w io.Writer, s *syntax.Structogram) error {
+			return render.LinkedSVG(w, s, callLinks(inputs, searchPath, *link))
+		}
+	case "pdf":
+		renderer = render.PDF
+	case "png":
+		if *dpi <= 0 || *scale <= 0 {
+			fmt.Fprintf(
+				c.stderr, "structogen render: dpi and scale have to be positive\n",
+			)
+			fs.Usage()
+			return errUsage
+		}
+		renderer = func(w io.Writer, s *syntax.Structogram) error {
+			return render.PNG(w, s, *dpi, *scale, *transparent)
+		}
+	case "tex":
+		renderer = func(w io.Writer, s *syntax.Structogram) error {
+			return render.Struktex(w, s, *standalone)
+		}
+	case "tikz":
+		renderer = func(w io.Writer, s *syntax.Structogram) error {
+			return render.TikZ(w, s, *standalone)
+		}
+	case "text":
+		renderer = func(w io.Writer, s *syntax.Structogram) error {
+			return render.Text(w, s, *width, *ascii)
+		}
+	default:
+		fmt.Fprintf(c.stderr, "structogen render: unknown format %q\n", *format)
+		fs.Usage()
+		return errUsage
+	}
+
+	structograms, err := parseInput(inputs[0])
+	if err != nil {
+		return err
+	}
+	s, err := selectStructogram(inputs[0], structograms, *name)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := renderer(&buf, s); err != nil {
+		return importError(inputs[0], err)
+	}
+	return c.writeOutput(*out, buf.Bytes())
+}
+
+// callLinks returns a function that links calls to the structogram they
+// refer to among the inputs and the files they import, by replacing {name}
+// in the template. Calls that do not refer to exactly one structogram are
+// not linked, and neither is anything if the template is empty.
+func callLinks(
+	inputs []input, searchPath []string, template string,
+) func(call string) string {
+	if template == "" {
+		return nil
+	}
+	// Imports that can not be loaded only cost the links into them.
+	p := project.New(loadInputs(inputs, searchPath).Files())
+	return func(call string) string {
+		definitions := p.Resolve(call)
+		if len(definitions) != 1 {
+			return ""
+		}
+		name := project.CalledName(definitions[0].Structogram.Name)
+		return strings.ReplaceAll(template, "{name}", url.PathEscape(name))
+	}
+}
+
+// renderHTML writes the structograms of all inputs and the files they
+// import into a single html page. All files are loaded first, so that the
+// errors of every file are reported.
+func (c *cli) renderHTML(inputs []input, searchPath []string, out string) error {
+	loader := loadInputs(inputs, searchPath)
+	for _, problem := range loader.Problems() {
+		fmt.Fprintf(c.stderr, "%s\n", problem)
+	}
+	if len(loader.Problems()) > 0 {
+		return errReported
+	}
+	var files []render.HTMLFile
+	for _, f := range loader.Files() {
+		files = append(files, render.HTMLFile{
+			Name: f.Name, Source: f.Source, Structograms: f.Structograms,
+		})
+	}
+	var buf bytes.Buffer
+	if err := render.HTML(&buf, files); err != nil {
+		return err
+	}
+	return c.writeOutput(out, buf.Bytes())
+}
+
+func (c *cli) gen(args []string) error {
+	fs := c.flagSet("gen", "[file]")
+	out := fs.String("o", "", "write the output to `file` instead of stdout")
+	lang := fs.String(
+		"lang", "pseudo",
+		"generate code in `language`, "+strings.Join(codegen.Languages(), ", "),
+	)
+	inputs, err := c.parseFlags(fs, args, false)
+	if err != nil {
+		return err
+	}
+	if !contains(codegen.Languages(), *lang) {
+		fmt.Fprintf(c.stderr, "structogen gen: unknown language %q\n", *lang)
+		fs.Usage()
+		return errUsage
+	}
+
+	structograms, err := parseInput(inputs[0])
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := codegen.GenerateAll(&buf, structograms, *lang); err != nil {
+		return importError(inputs[0], err)
+	}
+	return c.writeOutput(*out, buf.Bytes())
+}
+
+// runStructogram runs a structogram, which can call the other structograms
+// of its file and of the files it imports.
+func (c *cli) runStructogram(args []string) error {
+	fs := c.flagSet("run", "[file]")
+	name := fs.String(
+		"name", "", "run the structogram called `name`, if there are several",
+	)
+	arguments := fs.String(
+		"args", "", "pass the comma separated `values` to the parameters",
+	)
+	step := fs.Bool(
+		"step", false,
+		"report every step along with the variables on stderr",
+	)
+	maxSteps := fs.Int(
+		"max-steps", 1000000, "stop after `n` steps, or never if it is 0",
+	)
+	var searchPath stringList
+	fs.Var(&searchPath, "I", "look up imports in `dir` as well, can be repeated")
+	inputs, err := c.parseFlags(fs, args, false)
+	if err != nil {
+		return err
+	}
+	values, err := interp.Arguments(*arguments)
+	if err != nil {
+		fmt.Fprintf(c.stderr, "structogen run: invalid arguments: %s\n", err)
+		fs.Usage()
+		return errUsage
+	}
+
+	loader := loadInputs(inputs, searchPath)
+	for _, problem := range loader.Problems() {
+		fmt.Fprintf(c.stderr, "%s\n", problem)
+	}
+	if len(loader.Problems()) > 0 {
+		return errReported
+	}
+	s, err := selectStructogram(
+		inputs[0], loader.Files()[0].Structograms, *name,
+	)
+	if err != nil {
+		return err
+	}
+
+	// Steps and errors are reported with the name of the file that
+	// contains the structogram.
+	files := map[*syntax.Structogram]*project.File{}
+	var structograms []*syntax.Structogram
+	for _, f := range loader.Files() {
+		for _, s := range f.Structograms {
+			files[s] = f
+			structograms = append(structograms, s)
+		}
+	}
+	m := &interp.Machine{
+		Output: c.stdout, Structograms: structograms, MaxSteps: *maxSteps,
+	}
+	if *step {
+		m.Step = func(s interp.Step) error {
+			_, err := fmt.Fprintf(
+				c.stderr, "%s:%s\n", files[s.Structogram].Name, s,
+			)
+			return err
+		}
+	}
+	result, err := m.Run(s, values...)
+	if e, ok := err.(*interp.Error); ok {
+		return fmt.Errorf("%s:%s", files[e.Structogram].Name, e.Error())
+	}
+	if err != nil {
+		return err
+	}
+	if *step && result != nil {
+		fmt.Fprintf(c.stderr, "returned %s\n", interp.Quote(result))
+	}
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *cli) check(args []string) error {
+	fs := c.flagSet("check", "[file ...]")
+	calls := fs.Bool(
+		"calls", false,
+		"report calls that refer to no structogram or to several of them, "+
+			"the default if several files are checked or -I is given",
+	)
+	var searchPath stringList
+	fs.Var(&searchPath, "I", "look up imports in `dir` as well, can be repeated")
+	inputs, err := c.parseFlags(fs, args, true)
+	if err != nil {
+		return err
+	}
+
+	// The syntax errors of imported files are reported as well, along with
+	// the imports they were loaded through.
+	loader := loadInputs(inputs, searchPath)
+	failed := false
+	for _, problem := range loader.Problems() {
+		fmt.Fprintf(c.stderr, "%s\n", problem)
+		failed = true
+	}
+	// A single file usually calls functions that are not structograms, so
+	// its calls are only checked on request.
+	checkCalls := len(loader.Files()) > 1 || len(searchPath) > 0
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "calls" {
+			checkCalls = *calls
+		}
+	})
+	if checkCalls {
+		for _, problem := range project.New(loader.Files()).Check() {
+			fmt.Fprintf(c.stderr, "%s\n", problem)
+			failed = true
+		}
+	}
+	if failed {
+		return errReported
+	}
+	return nil
+}
+
+func (c *cli) fmt(args []string) error {
+	fs := c.flagSet("fmt", "[file ...]")
+	out := fs.String("o", "", "write the output to `file` instead of stdout")
+	list := fs.Bool("l", false, "list the files whose formatting differs")
+	write := fs.Bool("w", false, "write the result to the files themselves")
+	showDiff := fs.Bool("d", false, "print the diffs to the formatted files")
+	inputs, err := c.parseFlags(fs, args, true)
+	if err != nil {
+		return err
+	}
+	printSource := !*list && !*write && !*showDiff
+	if *out != "" && (!printSource || len(inputs) > 1) {
+		fmt.Fprintf(
+			c.stderr, "structogen fmt: -o only works for printing a single file\n",
+		)
+		fs.Usage()
+		return errUsage
+	}
+
+	failed := false
+	for _, in := range inputs {
+		s, err := parseInput(in)
+		if err != nil {
+			fmt.Fprintf(c.stderr, "%s\n", err)
+			failed = true
+			continue
+		}
+		formatted := syntax.FormatAll(s)
+		if printSource {
+			if err := c.writeOutput(*out, []byte(formatted)); err != nil {
+				return err
+			}
+			continue
+		}
+		if formatted == in.source {
+			continue
+		}
+		if *list {
+			fmt.Fprintln(c.stdout, in.name)
+		}
+		if *write {
+			if err := overwrite(in.path, formatted); err != nil {
+				fmt.Fprintf(c.stderr, "structogen fmt: %s\n", err)
+				failed = true
+			}
+		}
+		if *showDiff {
+			fmt.Fprint(c.stdout, diff(in.name, in.source, formatted))
+		}
+	}
+	if failed {
+		return errReported
+	}
+	return nil
+}
+
+// overwrite replaces the content of the file at path, keeping its
+// permissions.
+func overwrite(path string, content string) error {
+	if path == "-" {
+		return errors.New("can not write the result back to stdin")
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(content), info.Mode().Perm())
+}
+
+func (c *cli) lsp(args []string) error {
+	fs := c.flagSet("lsp", "")
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(c.stderr, "structogen lsp: unexpected arguments\n")
+		fs.Usage()
+		return errUsage
+	}
+	if err := lsp.Serve(c.stdin, c.stdout); err != nil {
+		return fmt.Errorf("structogen lsp: %s", err)
+	}
+	return nil
+}
+
+// importFunction runs the import command with the given name, which
+// translates a function with translate.
+func (c *cli) importFunction(
+	name string,
+	translate func(source string, name string) (*syntax.Structogram, error),
+	args []string,
+) error {
+	fs := c.flagSet(name, "[file]")
+	out := fs.String("o", "", "write the output to `file` instead of stdout")
+	function := fs.String(
+		"func", "", "translate the function with the given `name`, "+
+			"which is optional if there is only one",
+	)
+	inputs, err := c.parseFlags(fs, args, false)
+	if err != nil {
+		return err
+	}
+
+	s, err := translate(inputs[0].source, *function)
+	if err != nil {
+		return importError(inputs[0], err)
+	}
+	return c.writeOutput(*out, []byte(syntax.Format(s)))
+}
+
+func (c *cli) importNSD(args []string) error {
+	fs := c.flagSet("import-nsd", "[file]")
+	out := fs.String("o", "", "write the output to `file` instead of stdout")
+	inputs, err := c.parseFlags(fs, args, false)
+	if err != nil {
+		return err
+	}
+
+	s, err := nsd.Read(strings.NewReader(inputs[0].source))
+	if err != nil {
+		return importError(inputs[0], err)
+	}
+	return c.writeOutput(*out, []byte(syntax.Format(s)))
+}
+
+func (c *cli) exportNSD(args []string) error {
+	fs := c.flagSet("export-nsd", "[file]")
+	out := fs.String("o", "", "write the output to `file` instead of stdout")
+	name := fs.String(
+		"name", "", "export the structogram called `name`, if there are several",
+	)
+	inputs, err := c.parseFlags(fs, args, false)
+	if err != nil {
+		return err
+	}
+
+	structograms, err := parseInput(inputs[0])
+	if err != nil {
+		return err
+	}
+	s, err := selectStructogram(inputs[0], structograms, *name)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := nsd.Write(&buf, s); err != nil {
+		return importError(inputs[0], err)
+	}
+	return c.writeOutput(*out, buf.Bytes())
+}
+
+// importError prefixes errors of importers and exporters with the input
+// name, like syntax errors.
+func importError(in input, err error) error {
+	if d, ok := err.(syntax.Diagnostic); ok {
+		return fmt.Errorf("%s:%s", in.name, d.Error())
+	}
+	return fmt.Errorf("structogen: %s: %s", in.name, err)
+}
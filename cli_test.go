@@ -0,0 +1,543 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/JSchrtke/structogen/syntax"
+)
+
+func runForTest(
+	t *testing.T, stdin string, args ...string,
+) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := run(args, strings.NewReader(stdin), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func checkOk(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Errorf("Did not expect any errors, but got %s", err.Error())
+	}
+}
+
+func checkContains(t *testing.T, s string, substr string) {
+	t.Helper()
+	if !strings.Contains(s, substr) {
+		t.Errorf("Expected output to contain %s", substr)
+	}
+}
+
+func checkExitCode(t *testing.T, actual int, expected int) {
+	t.Helper()
+	if actual != expected {
+		t.Errorf("Expected exit code %d, but got %d", expected, actual)
+	}
+}
+
+func writeTempFile(t *testing.T, name string, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestCliWithoutCommandPrintsUsage(t *testing.T) {
+	code, _, stderr := runForTest(t, "")
+	checkExitCode(t, code, exitUsage)
+	checkContains(t, stderr, "usage: structogen <command>")
+}
+
+func TestCliUnknownCommandIsUsageError(t *testing.T) {
+	code, _, stderr := runForTest(t, "", "unknown")
+	checkExitCode(t, code, exitUsage)
+	checkContains(t, stderr, `unknown command "unknown"`)
+}
+
+func TestCliParseReadsStdin(t *testing.T) {
+	code, stdout, _ := runForTest(t, `name("a") instruction("b")`, "parse")
+	checkExitCode(t, code, exitOk)
+	checkContains(t, stdout, `"Name": "a"`)
+	checkContains(t, stdout, `"NodeType": "instruction"`)
+}
+
+func TestCliParseReadsFile(t *testing.T) {
+	path := writeTempFile(t, "a.str", `name("from file")`)
+	code, stdout, _ := runForTest(t, "", "parse", path)
+	checkExitCode(t, code, exitOk)
+	checkContains(t, stdout, `"Name": "from file"`)
+}
+
+func TestCliReportsSyntaxErrorsWithFileName(t *testing.T) {
+	path := writeTempFile(t, "broken.str", `name("a") if("b")`)
+	code, stdout, stderr := runForTest(t, "", "parse", path)
+	checkExitCode(t, code, exitError)
+	if stdout != "" {
+		t.Errorf("Expected no output, but got %s", stdout)
+	}
+	checkContains(
+		t, stderr, path+":1:18, expected 'openBrace', but got 'EOF'",
+	)
+}
+
+func TestCliReportsMissingFiles(t *testing.T) {
+	code, _, stderr := runForTest(t, "", "check", "does-not-exist.str")
+	checkExitCode(t, code, exitError)
+	checkContains(t, stderr, "does-not-exist.str")
+}
+
+func TestCliRenderWritesOutputFile(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out.pdf")
+	code, stdout, _ := runForTest(
+		t, `name("a") instruction("b")`, "render", "-format", "pdf", "-o", out,
+	)
+	checkExitCode(t, code, exitOk)
+	if stdout != "" {
+		t.Errorf("Expected no output on stdout, but got %s", stdout)
+	}
+	written, err := os.ReadFile(out)
+	checkOk(t, err)
+	if !bytes.HasPrefix(written, []byte("%PDF-")) {
+		t.Errorf("Expected a pdf file to be written")
+	}
+}
+
+func TestCliRenderDefaultsToSvg(t *testing.T) {
+	code, stdout, _ := runForTest(t, `name("a") instruction("b")`, "render")
+	checkExitCode(t, code, exitOk)
+	checkContains(t, stdout, "<svg")
+}
+
+func TestCliRenderRejectsUnknownFormats(t *testing.T) {
+	code, _, stderr := runForTest(t, `name("a")`, "render", "-format", "gif")
+	checkExitCode(t, code, exitUsage)
+	checkContains(t, stderr, `unknown format "gif"`)
+}
+
+func TestCliCheckReportsEveryBrokenFile(t *testing.T) {
+	ok := writeTempFile(t, "ok.str", `name("a")`)
+	first := writeTempFile(t, "first.str", `name()`)
+	second := writeTempFile(t, "second.str", `instruction("a")`)
+	code, _, stderr := runForTest(t, "", "check", ok, first, second)
+	checkExitCode(t, code, exitError)
+	checkContains(t, stderr, first+":1:6")
+	checkContains(t, stderr, second+":1:1")
+	if strings.Contains(stderr, ok) {
+		t.Errorf("Did not expect a diagnostic for %s", ok)
+	}
+}
+
+func TestCliFmtPrintsCanonicalSource(t *testing.T) {
+	code, stdout, _ := runForTest(
+		t, `name('a')if("b"){call("c")}else{instruction("d")}`, "fmt",
+	)
+	checkExitCode(t, code, exitOk)
+	expected := `name("a")
+
+if ("b") {
+    call("c")
+} else {
+    instruction("d")
+}
+`
+	if stdout != expected {
+		t.Errorf("Expected:\n%s\nbut got:\n%s", expected, stdout)
+	}
+}
+
+func TestCliOnlyAcceptsOneInputForParse(t *testing.T) {
+	code, _, stderr := runForTest(t, "", "parse", "a.str", "b.str")
+	checkExitCode(t, code, exitUsage)
+	checkContains(t, stderr, "too many files")
+}
+
+func TestCliReportsAllSyntaxErrors(t *testing.T) {
+	path := writeTempFile(
+		t, "broken.str", "name(\"a\")\ninstruction()\ncall(\"b\"\nif(\"c\")",
+	)
+	code, _, stderr := runForTest(t, "", "check", path)
+	checkExitCode(t, code, exitError)
+	checkContains(
+		t, stderr, path+":2:13, expected 'string', but got 'closeParentheses'\n",
+	)
+	checkContains(
+		t, stderr, path+":4:1, expected 'closeParentheses', but got 'if'\n",
+	)
+}
+
+func TestCliLspServesStdin(t *testing.T) {
+	var stdin strings.Builder
+	for _, m := range []string{
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"shutdown"}`,
+		`{"jsonrpc":"2.0","method":"exit"}`,
+	} {
+		fmt.Fprintf(&stdin, "Content-Length: %d\r\n\r\n%s", len(m), m)
+	}
+	code, stdout, _ := runForTest(t, stdin.String(), "lsp")
+	checkExitCode(t, code, exitOk)
+	checkContains(t, stdout, `"semanticTokensProvider"`)
+	checkContains(t, stdout, `{"jsonrpc":"2.0","id":2,"result":null}`)
+}
+
+func TestCliFmtListsUnformattedFiles(t *testing.T) {
+	dir := t.TempDir()
+	formatted := filepath.Join(dir, "formatted.str")
+	unformatted := filepath.Join(dir, "sub", "unformatted.str")
+	checkOk(t, os.MkdirAll(filepath.Dir(unformatted), 0755))
+	checkOk(t, os.WriteFile(formatted, []byte("name(\"a\")\n"), 0644))
+	checkOk(t, os.WriteFile(unformatted, []byte("name('a')"), 0644))
+
+	code, stdout, _ := runForTest(t, "", "fmt", "-l", dir)
+	checkExitCode(t, code, exitOk)
+	if stdout != unformatted+"\n" {
+		t.Errorf("Expected only %s to be listed, but got %s", unformatted, stdout)
+	}
+}
+
+func TestCliFmtWritesFiles(t *testing.T) {
+	path := writeTempFile(t, "a.str", "name('a') instruction('b')")
+	code, stdout, _ := runForTest(t, "", "fmt", "-w", path)
+	checkExitCode(t, code, exitOk)
+	if stdout != "" {
+		t.Errorf("Expected no output, but got %s", stdout)
+	}
+	written, err := os.ReadFile(path)
+	checkOk(t, err)
+	expected := "name(\"a\")\n\ninstruction(\"b\")\n"
+	if string(written) != expected {
+		t.Errorf("Expected:\n%s\nbut got:\n%s", expected, written)
+	}
+}
+
+func TestCliFmtPrintsDiffs(t *testing.T) {
+	path := writeTempFile(t, "a.str", "name(\"a\")\n\ncall('b')\n")
+	code, stdout, _ := runForTest(t, "", "fmt", "-d", path)
+	checkExitCode(t, code, exitOk)
+	checkContains(t, stdout, "--- "+path+".orig\n+++ "+path+"\n")
+	checkContains(t, stdout, "-call('b')\n+call(\"b\")\n")
+}
+
+func TestCliFmtCanNotWriteToStdin(t *testing.T) {
+	code, _, stderr := runForTest(t, "name('a')", "fmt", "-w")
+	checkExitCode(t, code, exitError)
+	checkContains(t, stderr, "stdin")
+}
+
+func TestCliFmtRejectsOutputFileForMultipleFiles(t *testing.T) {
+	a := writeTempFile(t, "a.str", "name(\"a\")\n")
+	b := writeTempFile(t, "b.str", "name(\"b\")\n")
+	code, _, _ := runForTest(t, "", "fmt", "-o", "out.str", a, b)
+	checkExitCode(t, code, exitUsage)
+}
+
+func TestCliImportsPython(t *testing.T) {
+	path := writeTempFile(t, "a.py", "def f(x):\n    if x:\n        g()\n")
+	code, stdout, _ := runForTest(t, "", "import-python", "-func", "f", path)
+	checkExitCode(t, code, exitOk)
+	checkContains(t, stdout, "name(\"f(x)\")\n\nif (\"x\") {\n    call(\"g()\")\n}\n")
+}
+
+func TestCliReportsPythonErrorsWithFileName(t *testing.T) {
+	path := writeTempFile(t, "a.py", "def f():\n    with x:\n        pass\n")
+	code, _, stderr := runForTest(t, "", "import-python", path)
+	checkExitCode(t, code, exitError)
+	checkContains(t, stderr, path+":2:5, unsupported statement 'with'")
+
+	code, _, stderr = runForTest(t, "", "import-python", "-func", "g", path)
+	checkExitCode(t, code, exitError)
+	checkContains(t, stderr, "no function named g")
+}
+
+func TestCliImportsGo(t *testing.T) {
+	path := writeTempFile(
+		t, "a.go", "package a\n\nfunc f(x bool) {\n\tif x {\n\t\tg()\n\t}\n}\n",
+	)
+	code, stdout, _ := runForTest(t, "", "import-go", path)
+	checkExitCode(t, code, exitOk)
+	checkContains(t, stdout, "name(\"f(x bool)\")\n\nif (\"x\") {\n    call(\"g()\")\n}\n")
+
+	code, _, stderr := runForTest(t, "", "import-go", "-func", "h", path)
+	checkExitCode(t, code, exitError)
+	checkContains(t, stderr, "no function named h")
+}
+
+func TestCliGenWritesCode(t *testing.T) {
+	code, stdout, _ := runForTest(
+		t, `name("a") call("b")`, "gen", "-lang", "python",
+	)
+	checkExitCode(t, code, exitOk)
+	if stdout != "def a():\n    b()\n" {
+		t.Errorf("Wrong output, got %s", stdout)
+	}
+
+	code, _, stderr := runForTest(t, `name("a")`, "gen", "-lang", "cobol")
+	checkExitCode(t, code, exitUsage)
+	checkContains(t, stderr, `unknown language "cobol"`)
+}
+
+func TestCliTranslatesBetweenStrAndNsd(t *testing.T) {
+	code, exported, _ := runForTest(
+		t, `name("a") while("b") {call("c")}`, "export-nsd",
+	)
+	checkExitCode(t, code, exitOk)
+	checkContains(t, exported, `<while text="&#34;while b&#34;"`)
+
+	code, imported, _ := runForTest(t, exported, "import-nsd")
+	checkExitCode(t, code, exitOk)
+	checkContains(t, imported, "while (\"b\") {\n    call(\"c\")\n}\n")
+
+	code, _, stderr := runForTest(t, "<root><children><foo/></children></root>", "import-nsd")
+	checkExitCode(t, code, exitError)
+	checkContains(t, stderr, "structogen: <stdin>: unsupported element <foo>")
+}
+
+func TestCliRenderWritesStruktex(t *testing.T) {
+	code, stdout, _ := runForTest(
+		t, `name("a") instruction("b")`, "render", "-format", "tex", "-standalone",
+	)
+	checkExitCode(t, code, exitOk)
+	checkContains(t, stdout, "\\usepackage{struktex}\n")
+	checkContains(t, stdout, "\\assign{b}\n")
+}
+
+func TestCliRenderWritesTikZ(t *testing.T) {
+	code, stdout, _ := runForTest(
+		t, `name("a") instruction("b")`, "render", "-format", "tikz",
+	)
+	checkExitCode(t, code, exitOk)
+	checkContains(t, stdout, "\\begin{tikzpicture}")
+	checkContains(t, stdout, "{b}};\n")
+}
+
+func TestCliRenderDrawsText(t *testing.T) {
+	code, stdout, _ := runForTest(
+		t, `name("a") instruction("b c")`,
+		"render", "-format", "text", "-width", "1", "-ascii",
+	)
+	checkExitCode(t, code, exitOk)
+	checkContains(t, stdout, "+--+\n|b |\n|c |\n+--+\n")
+}
+
+func TestCliRenderWritesPNG(t *testing.T) {
+	code, stdout, _ := runForTest(
+		t, `name("a") instruction("b")`,
+		"render", "-format", "png", "-dpi", "144", "-transparent",
+	)
+	checkExitCode(t, code, exitOk)
+	if !strings.HasPrefix(stdout, "\x89PNG\r\n") {
+		t.Errorf("Expected a png file")
+	}
+}
+
+func TestCliRenderRejectsNonPositiveScale(t *testing.T) {
+	code, _, stderr := runForTest(
+		t, `name("a")`, "render", "-format", "png", "-scale", "0",
+	)
+	checkExitCode(t, code, exitUsage)
+	checkContains(t, stderr, "dpi and scale have to be positive")
+}
+
+func TestCliRenderBundlesFilesIntoHTML(t *testing.T) {
+	main := writeTempFile(t, "main.str", `name("main") call("helper()")`)
+	helper := writeTempFile(t, "helper.str", `name("helper") instruction("b")`)
+	code, stdout, _ := runForTest(t, "", "render", "-format", "html", main, helper)
+	checkExitCode(t, code, exitOk)
+	checkContains(t, stdout, `<h2>`+html.EscapeString(helper)+`</h2>`)
+	checkContains(t, stdout, `data-target="structogram-1"`)
+}
+
+func TestCliRenderReportsErrorsOfEveryBundledFile(t *testing.T) {
+	first := writeTempFile(t, "first.str", `name()`)
+	second := writeTempFile(t, "second.str", `name("a") if`)
+	code, _, stderr := runForTest(
+		t, "", "render", "-format", "html", first, second,
+	)
+	checkExitCode(t, code, exitError)
+	checkContains(t, stderr, first+":1:")
+	checkContains(t, stderr, second+":1:")
+}
+
+func TestCliRenderDrawsOnlyHTMLFromSeveralFiles(t *testing.T) {
+	first := writeTempFile(t, "first.str", `name("a")`)
+	second := writeTempFile(t, "second.str", `name("b")`)
+	code, _, stderr := runForTest(t, "", "render", first, second)
+	checkExitCode(t, code, exitUsage)
+	checkContains(t, stderr, "too many files")
+}
+
+const twoStructograms = `name("main")
+
+call("total = sum(list)")
+
+name("sum(list)")
+
+instruction("total = 0")
+`
+
+func TestCliParsePrintsSeveralStructogramsAsArray(t *testing.T) {
+	code, stdout, _ := runForTest(t, twoStructograms, "parse")
+	checkExitCode(t, code, exitOk)
+	var structograms []syntax.Structogram
+	checkOk(t, json.Unmarshal([]byte(stdout), &structograms))
+	if len(structograms) != 2 || structograms[1].Name != "sum(list)" {
+		t.Errorf("Expected main and sum, but got %v", structograms)
+	}
+}
+
+func TestCliRenderSelectsStructogramByName(t *testing.T) {
+	code, stdout, _ := runForTest(
+		t, twoStructograms, "render", "-format", "text", "-name", "sum",
+	)
+	checkExitCode(t, code, exitOk)
+	checkContains(t, stdout, "total = 0")
+
+	code, _, stderr := runForTest(t, twoStructograms, "render")
+	checkExitCode(t, code, exitError)
+	checkContains(t, stderr, "<stdin>: contains 2 structograms, select one with -name")
+
+	code, _, stderr = runForTest(t, twoStructograms, "render", "-name", "max")
+	checkExitCode(t, code, exitError)
+	checkContains(t, stderr, `<stdin>: no structogram named "max"`)
+}
+
+func TestCliCheckReportsUnresolvedCalls(t *testing.T) {
+	main := writeTempFile(t, "main.str", `name("main")
+call("sum(list)")
+call("print(total)")`)
+	lib := writeTempFile(t, "lib.str", `name("sum(list)") instruction("a")`)
+
+	code, _, _ := runForTest(t, "", "check", main)
+	checkExitCode(t, code, exitOk)
+	code, _, _ = runForTest(t, "", "check", "-calls=false", main, lib)
+	checkExitCode(t, code, exitOk)
+
+	code, _, stderr := runForTest(t, "", "check", main, lib)
+	checkExitCode(t, code, exitError)
+	checkContains(t, stderr, main+`:3:1, call of unknown structogram "print"`)
+	if strings.Contains(stderr, "sum") {
+		t.Errorf("Expected the call of sum to be resolved, but got %s", stderr)
+	}
+
+	code, _, stderr = runForTest(t, "", "check", "-calls", main)
+	checkExitCode(t, code, exitError)
+	checkContains(t, stderr, main+`:2:1, call of unknown structogram "sum"`)
+}
+
+func TestCliRenderLinksCallsInSvg(t *testing.T) {
+	main := writeTempFile(t, "main.str", `import("lib.str")
+name("main")
+call("total = sum(list)")
+call("print(total)")`)
+	lib := filepath.Join(filepath.Dir(main), "lib.str")
+	checkOk(t, os.WriteFile(lib, []byte(`name("sum(list)") instruction("a")`), 0644))
+
+	code, stdout, _ := runForTest(t, "", "render", main)
+	checkExitCode(t, code, exitOk)
+	checkContains(t, stdout, `<a href="sum.svg">`)
+	if strings.Count(stdout, "<a ") != 1 {
+		t.Errorf("Expected only the call of sum to be linked, but got %s", stdout)
+	}
+
+	code, stdout, _ = runForTest(
+		t, "", "render", "-link", "https://example.com/{name}.html", main,
+	)
+	checkExitCode(t, code, exitOk)
+	checkContains(t, stdout, `<a href="https://example.com/sum.html">`)
+
+	code, stdout, _ = runForTest(t, "", "render", "-link", "", main)
+	checkExitCode(t, code, exitOk)
+	if strings.Contains(stdout, "<a ") {
+		t.Errorf("Did not expect links, but got %s", stdout)
+	}
+}
+
+func TestCliCheckFollowsImports(t *testing.T) {
+	dir := t.TempDir()
+	lib := t.TempDir()
+	for path, content := range map[string]string{
+		filepath.Join(dir, "main.str"): `import("common/sorting.str")
+name("main") call("sort(list)")`,
+		filepath.Join(dir, "common", "sorting.str"): `import("swap.str")
+name("sort(list)") call("swap(a, b)")`,
+		filepath.Join(lib, "swap.str"): `name("swap(a, b)") if`,
+	} {
+		checkOk(t, os.MkdirAll(filepath.Dir(path), 0755))
+		checkOk(t, os.WriteFile(path, []byte(content), 0644))
+	}
+	main := filepath.Join(dir, "main.str")
+
+	code, _, stderr := runForTest(t, "", "check", main)
+	checkExitCode(t, code, exitError)
+	checkContains(t, stderr, `can not find import "swap.str"`)
+
+	code, _, stderr = runForTest(t, "", "check", "-calls", "-I", lib, main)
+	checkExitCode(t, code, exitError)
+	checkContains(
+		t, stderr,
+		filepath.Join(lib, "swap.str")+":1:22, expected 'openParentheses', "+
+			"but got 'EOF'\n\timported from "+
+			filepath.Join(dir, "common", "sorting.str")+":1:1\n\timported from "+
+			main+":1:1",
+	)
+	if strings.Contains(stderr, "unknown structogram") {
+		t.Errorf("Expected the calls to be resolved, but got %s", stderr)
+	}
+}
+
+func TestCliRunPrintsTheOutput(t *testing.T) {
+	source := `name("main")
+for ("i = 0; i < 3; i++") {
+    instruction("print i * i")
+}`
+	code, stdout, stderr := runForTest(t, source, "run", "-step")
+	checkExitCode(t, code, exitOk)
+	if stdout != "0\n1\n4\n" {
+		t.Errorf("Expected the squares, but got %q", stdout)
+	}
+	checkContains(t, stderr, `<stdin>:3:5, instruction "print i * i" | i = 2`)
+}
+
+func TestCliRunReportsErrorsWithTheFile(t *testing.T) {
+	lib := writeTempFile(t, "lib.str", `name("half(n)") return("n / 0")`)
+	main := writeTempFile(t, "main.str", `import("`+filepath.Base(lib)+`")
+name("main")
+call("half(4)")`)
+
+	code, _, stderr := runForTest(
+		t, "", "run", "-I", filepath.Dir(lib), main,
+	)
+	checkExitCode(t, code, exitError)
+	checkContains(t, stderr, lib+":1:17, division by zero")
+
+	code, _, stderr = runForTest(
+		t, "", "run", "-args", "1 +", "-I", filepath.Dir(lib), main,
+	)
+	checkExitCode(t, code, exitUsage)
+	checkContains(t, stderr, "invalid arguments: unexpected end")
+}
+
+func TestCliGenGeneratesAFunctionPerStructogram(t *testing.T) {
+	code, stdout, _ := runForTest(t, twoStructograms, "gen", "-lang", "python")
+	checkExitCode(t, code, exitOk)
+	checkContains(t, stdout, "def main():\n")
+	checkContains(t, stdout, "\n\ndef sum(list):\n")
+}
+
+func TestCliFmtKeepsEveryStructogram(t *testing.T) {
+	code, stdout, _ := runForTest(t, twoStructograms, "fmt")
+	checkExitCode(t, code, exitOk)
+	if stdout != twoStructograms {
+		t.Errorf("Expected:\n%s\nbut got:\n%s", twoStructograms, stdout)
+	}
+}
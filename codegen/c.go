@@ -0,0 +1,140 @@
+package codegen
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/JSchrtke/structogen/syntax"
+)
+
+func init() {
+	register("c", c{})
+}
+
+// c writes C. Cases of a switch end with a break, as they do not fall
+// through in structograms, and a for loop without semicolons in its header
+// becomes a while loop. C has neither parallel blocks nor exceptions, so the
+// branches of a parallel run one after another, and catch bodies are written
+// into blocks that never run.
+type c struct{}
+
+func (c) indentation() string {
+	return "    "
+}
+
+func (c) emptyBody() string {
+	return ""
+}
+
+func (c) function(g *generator, name string, body []syntax.Node) {
+	identifier, params := signature(name, camelCase)
+	g.line("void " + identifier + params + " {")
+	g.body(body)
+	g.line("}")
+}
+
+func (c) statement(g *generator, n syntax.Node, merged []syntax.Node) {
+	switch n.NodeType {
+	case "parallel":
+		for i, b := range n.Nodes {
+			g.line("/* parallel branch " + strconv.Itoa(i+1) + " */")
+			g.statements(b.Nodes)
+		}
+	case "try":
+		g.line("/* try */")
+		g.statements(n.Nodes)
+		catches, finally := handlers(merged)
+		for _, c := range catches {
+			g.line("/* catch " + c.Value + " */")
+			g.line("if (0) {")
+			g.body(c.Nodes)
+			g.line("}")
+		}
+		if finally != nil {
+			g.line("/* finally */")
+			g.statements(finally.Nodes)
+		}
+	case "exit":
+		g.line("exit(" + orDefault(n.Value, "0") + ");")
+	default:
+		braces(g, n, merged, "")
+	}
+}
+
+// braces writes the statements that C and the languages with a syntax
+// derived from it have in common. A for header containing one of the
+// separators is kept, other ones become the condition of a while loop.
+func braces(g *generator, n syntax.Node, merged []syntax.Node, separators string) {
+	switch n.NodeType {
+	case "instruction":
+		g.lines(n.Value, terminate)
+	case "call":
+		g.line(callExpression(n.Value) + ";")
+	case "if":
+		g.line("if (" + n.Value + ") {")
+		g.body(n.Nodes)
+		if e := elseBody(merged); e != nil {
+			g.line("} else {")
+			g.body(e.Nodes)
+		}
+		g.line("}")
+	case "while":
+		g.line("while (" + n.Value + ") {")
+		g.body(n.Nodes)
+		g.line("}")
+	case "for":
+		if strings.ContainsAny(n.Value, ";"+separators) {
+			g.line("for (" + n.Value + ") {")
+		} else {
+			g.line("while (" + n.Value + ") {")
+		}
+		g.body(n.Nodes)
+		g.line("}")
+	case "dowhile":
+		g.line("do {")
+		g.body(n.Nodes)
+		g.line("} while (" + n.Value + ");")
+	case "switch":
+		g.line("switch (" + n.Value + ") {")
+		for _, c := range n.Nodes {
+			if c.NodeType == "default" {
+				g.line("default:")
+			} else {
+				g.line("case " + c.Value + ":")
+			}
+			g.body(c.Nodes)
+			if !endsWithJump(c.Nodes) {
+				g.depth++
+				g.line("break;")
+				g.depth--
+			}
+		}
+		g.line("}")
+	case "break", "continue", "return":
+		g.line(jump(n.NodeType, n.Value) + ";")
+	}
+}
+
+// terminate ends a statement with a semicolon, unless it already ends with
+// one or with a brace.
+func terminate(s string) string {
+	trimmed := strings.TrimSpace(s)
+	if strings.HasSuffix(trimmed, ";") || strings.HasSuffix(trimmed, "{") ||
+		strings.HasSuffix(trimmed, "}") {
+		return s
+	}
+	return s + ";"
+}
+
+// endsWithJump reports whether the last of nodes is a jump, after which
+// no break is needed.
+func endsWithJump(nodes []syntax.Node) bool {
+	if len(nodes) == 0 {
+		return false
+	}
+	switch nodes[len(nodes)-1].NodeType {
+	case "break", "continue", "return", "exit":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,277 @@
+// Package codegen generates skeleton source code from structograms.
+//
+// Every language is implemented in a file of its own, which registers it
+// with register. The values of the statements are copied verbatim, so they
+// should already be written in the language that is generated.
+package codegen
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/JSchrtke/structogen/syntax"
+)
+
+// language writes the statements of structograms in a programming language.
+type language interface {
+	// function writes the function with the given name, which contains body.
+	function(g *generator, name string, body []syntax.Node)
+	// statement writes n along with the nodes that are merged into it: the
+	// else of an if, and the catch and finally nodes of a try.
+	statement(g *generator, n syntax.Node, merged []syntax.Node)
+	// indentation returns the text that a level of indentation consists of.
+	indentation() string
+	// emptyBody returns the statement that is written into a body without
+	// any statements, or an empty string if bodies can be empty.
+	emptyBody() string
+}
+
+var languages = map[string]language{}
+
+func register(name string, l language) {
+	languages[name] = l
+}
+
+// Languages returns the names of the languages that code can be generated
+// for, in alphabetical order.
+func Languages() []string {
+	var names []string
+	for name := range languages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// importWriter is implemented by languages whose code has to import the
+// packages it uses.
+type importWriter interface {
+	// imports writes the declaration that imports packages, which are
+	// sorted.
+	imports(g *generator, packages []string)
+}
+
+// Generate writes s as a function in the language with the given name to w.
+func Generate(w io.Writer, s *syntax.Structogram, lang string) error {
+	return GenerateAll(w, []*syntax.Structogram{s}, lang)
+}
+
+// GenerateAll writes each of structograms as a function in the language
+// with the given name to w, separated by blank lines and preceded by the
+// imports that all of them need together. Statements that can not be
+// translated are reported as a syntax.Diagnostic.
+func GenerateAll(w io.Writer, structograms []*syntax.Structogram, lang string) error {
+	l, ok := languages[lang]
+	if !ok {
+		return fmt.Errorf("unknown language %q", lang)
+	}
+	g := &generator{lang: l, packages: map[string]bool{}}
+	for i, s := range structograms {
+		if i > 0 {
+			g.line("")
+		}
+		l.function(g, s.Name, s.Nodes)
+	}
+	if g.err != nil {
+		return g.err
+	}
+
+	functions := g.b.String()
+	g.b.Reset()
+	if iw, ok := l.(importWriter); ok && len(g.packages) > 0 {
+		var packages []string
+		for p := range g.packages {
+			packages = append(packages, p)
+		}
+		sort.Strings(packages)
+		iw.imports(g, packages)
+		g.line("")
+	}
+	_, err := io.WriteString(w, g.b.String()+functions)
+	return err
+}
+
+// generator collects the generated lines and keeps track of their
+// indentation.
+type generator struct {
+	b     strings.Builder
+	lang  language
+	depth int
+	// written counts the lines that were written, which tells whether a body
+	// is empty.
+	written int
+	// inCase is set while the innermost statement that a break leaves is a
+	// case, for languages where break does not leave cases.
+	inCase bool
+	// continueJump writes a continue of the innermost loop, for languages
+	// that emulate a loop in a way that a plain continue would break. It is
+	// nil if a plain continue works.
+	continueJump func()
+	// packages are the packages that the generated code uses.
+	packages map[string]bool
+	// err is the first statement that could not be translated.
+	err error
+}
+
+// fail reports that n can not be translated, unless an earlier statement
+// could not be translated either.
+func (g *generator) fail(n syntax.Node, message string) {
+	if g.err == nil {
+		g.err = syntax.Diagnostic{Start: n.Start, End: n.End, Message: message}
+	}
+}
+
+// line writes text with the current indentation. Each line of a text with
+// line breaks is indented.
+func (g *generator) line(text string) {
+	for _, l := range strings.Split(text, "\n") {
+		if strings.TrimSpace(l) == "" {
+			g.b.WriteString("\n")
+		} else {
+			indent := strings.Repeat(g.lang.indentation(), g.depth)
+			g.b.WriteString(indent + l + "\n")
+		}
+		g.written++
+	}
+}
+
+// lines writes the lines of a text that consists of multiple statements,
+// like a multi-line instruction. Empty texts are left out, and each line is
+// passed through end, which can terminate it.
+func (g *generator) lines(text string, end func(string) string) {
+	if strings.TrimSpace(text) == "" {
+		return
+	}
+	for _, l := range strings.Split(text, "\n") {
+		if strings.TrimSpace(l) == "" {
+			g.line("")
+			continue
+		}
+		g.line(end(l))
+	}
+}
+
+// body writes nodes with one more level of indentation.
+func (g *generator) body(nodes []syntax.Node) {
+	g.depth++
+	written := g.written
+	g.statements(nodes)
+	if g.written == written && g.lang.emptyBody() != "" {
+		g.line(g.lang.emptyBody())
+	}
+	g.depth--
+}
+
+// statements writes nodes, merging the else nodes into their if and the
+// catch and finally nodes into their try.
+func (g *generator) statements(nodes []syntax.Node) {
+	for i := 0; i < len(nodes); i++ {
+		n := nodes[i]
+		end := i + 1
+		switch n.NodeType {
+		case "if":
+			if end < len(nodes) && nodes[end].NodeType == "else" {
+				end++
+			}
+		case "try":
+			for end < len(nodes) && (nodes[end].NodeType == "catch" ||
+				nodes[end].NodeType == "finally") {
+				end++
+			}
+		}
+		g.lang.statement(g, n, nodes[i+1:end])
+		i = end - 1
+	}
+}
+
+// elseBody returns the else node among the nodes merged into an if, or nil
+// if there is none.
+func elseBody(merged []syntax.Node) *syntax.Node {
+	if len(merged) > 0 {
+		return &merged[0]
+	}
+	return nil
+}
+
+// handlers splits the nodes merged into a try into its catch nodes and its
+// finally node, which is nil if there is none.
+func handlers(merged []syntax.Node) ([]syntax.Node, *syntax.Node) {
+	var catches []syntax.Node
+	var finally *syntax.Node
+	for i, n := range merged {
+		if n.NodeType == "finally" {
+			finally = &merged[i]
+		} else {
+			catches = append(catches, n)
+		}
+	}
+	return catches, finally
+}
+
+// callExpression returns the value of a call node as a call expression,
+// adding parentheses if it does not end with any.
+func callExpression(value string) string {
+	value = strings.TrimSpace(value)
+	if strings.HasSuffix(value, ")") {
+		return value
+	}
+	return value + "()"
+}
+
+// orDefault returns value, or fallback if value is empty.
+func orDefault(value string, fallback string) string {
+	if strings.TrimSpace(value) == "" {
+		return fallback
+	}
+	return value
+}
+
+// signature turns the name of a structogram into a function name and its
+// parameter list. A name like "sum(a, b)" is split at its parenthesis, other
+// names are turned into an identifier by joining their words with join, and
+// get an empty parameter list.
+func signature(name string, join func(words []string) string) (string, string) {
+	params := "()"
+	if i := strings.Index(name, "("); i != -1 {
+		name, params = name[:i], name[i:]
+	}
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+	if len(words) == 0 {
+		words = []string{"structogram"}
+	}
+	identifier := join(words)
+	if unicode.IsDigit([]rune(identifier)[0]) {
+		identifier = "_" + identifier
+	}
+	return identifier, params
+}
+
+// camelCase joins words in camel case, starting with a lower case letter.
+func camelCase(words []string) string {
+	var b strings.Builder
+	for i, w := range words {
+		r := []rune(w)
+		if i == 0 {
+			r[0] = unicode.ToLower(r[0])
+		} else {
+			r[0] = unicode.ToUpper(r[0])
+		}
+		b.WriteString(string(r))
+	}
+	return b.String()
+}
+
+// snakeCase joins words in lower case with underscores.
+func snakeCase(words []string) string {
+	return strings.ToLower(strings.Join(words, "_"))
+}
+
+// keep returns s unchanged, for languages that do not terminate statements.
+func keep(s string) string {
+	return s
+}
@@ -0,0 +1,307 @@
+package codegen
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/JSchrtke/structogen/syntax"
+)
+
+func checkOk(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("Did not expect any errors, but got %s", err.Error())
+	}
+}
+
+func generateForTest(t *testing.T, source string, lang string) string {
+	t.Helper()
+	s, err := syntax.Parse(strings.NewReader(source))
+	checkOk(t, err)
+	var b strings.Builder
+	checkOk(t, Generate(&b, s, lang))
+	return b.String()
+}
+
+func checkGenerated(t *testing.T, actual string, expected string) {
+	t.Helper()
+	if actual != expected {
+		t.Errorf("Expected:\n%s\nbut got:\n%s", expected, actual)
+	}
+}
+
+const loops = `name("count up(n)")
+if ("n > 0") {
+    for ("i = 0; i < n; i++") {
+        call("show(i)")
+    }
+} else {
+    instruction("")
+}
+dowhile ("n < 10") {
+    instruction("n += 1")
+}
+return("n")
+`
+
+func TestGenerateWritesPseudocode(t *testing.T) {
+	checkGenerated(t, generateForTest(t, loops, "pseudo"), `function count up(n)
+    if n > 0 then
+        for i = 0; i < n; i++ do
+            call show(i)
+        end for
+    else
+    end if
+    repeat
+        n += 1
+    while n < 10
+    return n
+end function
+`)
+}
+
+func TestGenerateWritesPython(t *testing.T) {
+	checkGenerated(t, generateForTest(t, loops, "python"), `def count_up(n):
+    if n > 0:
+        i = 0
+        while i < n:
+            show(i)
+            i++
+    else:
+        pass
+    while True:
+        n += 1
+        if not (n < 10):
+            break
+    return n
+`)
+}
+
+func TestGenerateKeepsContinueInEmulatedLoops(t *testing.T) {
+	source := `name("a")
+for ("i = 0; i < 3; i += 1") {
+    if ("i == 1") { continue("") }
+    call("show(i)")
+}
+dowhile ("n < 3") {
+    instruction("n += 1")
+    switch ("n") { case ("1") { continue("") } default { call("show(n)") } }
+}`
+	checkGenerated(t, generateForTest(t, source, "python"), `def a():
+    i = 0
+    while i < 3:
+        if i == 1:
+            i += 1
+            continue
+        show(i)
+        i += 1
+    while True:
+        n += 1
+        match n:
+            case 1:
+                if n < 3:
+                    continue
+                break
+            case _:
+                show(n)
+        if not (n < 3):
+            break
+`)
+	checkGenerated(t, generateForTest(t, source, "go"), `func a() {
+	for i = 0; i < 3; i += 1 {
+		if i == 1 {
+			continue
+		}
+		show(i)
+	}
+	for again := true; again; again = n < 3 {
+		n += 1
+		switch n {
+		case 1:
+			continue
+		default:
+			show(n)
+		}
+	}
+}
+`)
+}
+
+func TestGenerateWritesC(t *testing.T) {
+	checkGenerated(t, generateForTest(t, loops, "c"), `void countUp(n) {
+    if (n > 0) {
+        for (i = 0; i < n; i++) {
+            show(i);
+        }
+    } else {
+    }
+    do {
+        n += 1;
+    } while (n < 10);
+    return n;
+}
+`)
+}
+
+func TestGenerateWritesJavaExceptions(t *testing.T) {
+	checkGenerated(t, generateForTest(t, `name("read")
+try {
+    call("open")
+} catch ("IOException e") {
+    exit("")
+} finally {
+    call("close()")
+}
+for ("String line : lines") {
+    instruction("count++;")
+}
+`, "java"), `static void read() {
+    try {
+        open();
+    } catch (IOException e) {
+        System.exit(0);
+    } finally {
+        close();
+    }
+    for (String line : lines) {
+        count++;
+    }
+}
+`)
+}
+
+func TestGenerateWritesSwitchesWithoutFallThrough(t *testing.T) {
+	source := `name("a")
+while ("true") {
+    switch ("x") {
+        case ("1") {
+            break("")
+        }
+        default {
+            call("f")
+        }
+    }
+}
+`
+	checkGenerated(t, generateForTest(t, source, "c"), `void a() {
+    while (true) {
+        switch (x) {
+        case 1:
+            break;
+        default:
+            f();
+            break;
+        }
+    }
+}
+`)
+	checkGenerated(t, generateForTest(t, source, "python"), `def a():
+    while true:
+        match x:
+            case 1:
+                pass
+            case _:
+                f()
+`)
+}
+
+func TestGenerateWritesGoThatParses(t *testing.T) {
+	source, err := os.ReadFile("../template.str")
+	checkOk(t, err)
+	// The template uses a few statements that are not Go.
+	replacer := strings.NewReplacer(
+		`print \"start\"`, `println(\"start\")`,
+		"print counter", "println(counter)",
+		"print e", "println(e)",
+	)
+	generated := generateForTest(t, replacer.Replace(string(source)), "go")
+
+	_, err = parser.ParseFile(
+		token.NewFileSet(), "", "package main\n\n"+generated, 0,
+	)
+	if err != nil {
+		t.Errorf("Expected valid Go, but got %s in:\n%s", err, generated)
+	}
+	if !strings.Contains(generated, "\n\nfunc templateName() {\n\tcounter = 0\n") {
+		t.Errorf("Expected a function named templateName, but got:\n%s", generated)
+	}
+}
+
+func TestGenerateWritesGoThatCompiles(t *testing.T) {
+	structograms, err := syntax.ParseAll(strings.NewReader(`name("work(n int)")
+parallel {
+    branch { call("println(n)") }
+    branch { for ("i := 0; i < n; i++") { if ("i > 2") { break("") } } }
+}
+parallel {
+    branch { call("println(n)") }
+    branch { switch ("n") { case ("1") { break("") } default { call("f()") } } }
+}
+exit("2")
+
+name("f")`))
+	checkOk(t, err)
+	var b strings.Builder
+	checkOk(t, GenerateAll(&b, structograms, "go"))
+	generated := b.String()
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", "package main\n\n"+generated, 0)
+	checkOk(t, err)
+	config := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	if _, err := config.Check("main", fset, []*ast.File{file}, nil); err != nil {
+		t.Errorf("Expected Go that compiles, but got %s in:\n%s", err, generated)
+	}
+}
+
+func TestGenerateRejectsJumpsOutOfGoroutines(t *testing.T) {
+	s, err := syntax.Parse(strings.NewReader(`name("a")
+parallel {
+    branch { call("b()") }
+    branch { if ("c") { return("") } }
+}`))
+	checkOk(t, err)
+	err = Generate(&strings.Builder{}, s, "go")
+	expected := "4:25, go can not return out of a parallel branch"
+	if err == nil || err.Error() != expected {
+		t.Errorf("Expected error %s, but got %v", expected, err)
+	}
+}
+
+func TestGenerateRejectsJumpsOutOfTry(t *testing.T) {
+	for _, test := range []struct{ source, expected string }{
+		{`name("a")
+while ("b") { try { break("") } finally { call("c()") } }`,
+			"2:21, go can not break out of a try"},
+		{`name("a")
+while ("b") { try { call("c()") } catch ("e") { continue("") } }`,
+			"2:49, go can not continue out of a try"},
+		{`name("a")
+try { call("c()") } finally { return("x") }`,
+			"2:31, go can not return out of a try"},
+	} {
+		s, err := syntax.Parse(strings.NewReader(test.source))
+		checkOk(t, err)
+		err = Generate(&strings.Builder{}, s, "go")
+		if err == nil || err.Error() != test.expected {
+			t.Errorf("Expected error %s, but got %v", test.expected, err)
+		}
+	}
+}
+
+func TestGenerateRejectsUnknownLanguages(t *testing.T) {
+	err := Generate(&strings.Builder{}, &syntax.Structogram{}, "cobol")
+	if err == nil || err.Error() != `unknown language "cobol"` {
+		t.Errorf("Expected an error for the unknown language, but got %v", err)
+	}
+	languages := strings.Join(Languages(), ",")
+	if languages != "c,go,java,pseudo,python" {
+		t.Errorf("Wrong languages, got %s", languages)
+	}
+}
@@ -0,0 +1,184 @@
+package codegen
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/JSchrtke/structogen/syntax"
+)
+
+func init() {
+	register("go", golang{})
+}
+
+// golang writes Go. A dowhile becomes a for loop whose post statement checks
+// its condition, so that a continue checks it as well, the branches of a
+// parallel run in goroutines, and a try becomes a function literal that
+// recovers from panics. Since the branches and the sections of
+// a try are function literals, jumps out of them are reported as errors.
+type golang struct{}
+
+func (golang) imports(g *generator, packages []string) {
+	g.line("import (")
+	g.depth++
+	for _, p := range packages {
+		g.line(strconv.Quote(p))
+	}
+	g.depth--
+	g.line(")")
+}
+
+func (golang) indentation() string {
+	return "\t"
+}
+
+func (golang) emptyBody() string {
+	return ""
+}
+
+func (golang) function(g *generator, name string, body []syntax.Node) {
+	identifier, params := signature(name, camelCase)
+	g.line("func " + identifier + params + " {")
+	g.body(body)
+	g.line("}")
+}
+
+func (golang) statement(g *generator, n syntax.Node, merged []syntax.Node) {
+	switch n.NodeType {
+	case "instruction":
+		g.lines(n.Value, keep)
+	case "call":
+		g.line(callExpression(n.Value))
+	case "if":
+		g.line("if " + n.Value + " {")
+		g.body(n.Nodes)
+		if e := elseBody(merged); e != nil {
+			g.line("} else {")
+			g.body(e.Nodes)
+		}
+		g.line("}")
+	case "while", "for":
+		g.line("for " + n.Value + " {")
+		g.body(n.Nodes)
+		g.line("}")
+	case "dowhile":
+		g.line("for again := true; again; again = " + n.Value + " {")
+		g.body(n.Nodes)
+		g.line("}")
+	case "switch":
+		g.line("switch " + n.Value + " {")
+		for _, c := range n.Nodes {
+			if c.NodeType == "default" {
+				g.line("default:")
+			} else {
+				g.line("case " + c.Value + ":")
+			}
+			g.body(c.Nodes)
+		}
+		g.line("}")
+	case "parallel":
+		// The block gives every wait group a scope of its own.
+		g.packages["sync"] = true
+		g.line("{")
+		g.depth++
+		g.line("var wg sync.WaitGroup")
+		g.line("wg.Add(" + strconv.Itoa(len(n.Nodes)) + ")")
+		for _, b := range n.Nodes {
+			if j := leavingJump(b.Nodes, false, false); j != nil {
+				g.fail(*j, fmt.Sprintf(
+					"go can not %s out of a parallel branch", j.NodeType,
+				))
+			}
+			g.line("go func() {")
+			g.depth++
+			g.line("defer wg.Done()")
+			g.statements(b.Nodes)
+			g.depth--
+			g.line("}()")
+		}
+		g.line("wg.Wait()")
+		g.depth--
+		g.line("}")
+	case "try":
+		catches, finally := handlers(merged)
+		sections := append([]syntax.Node{n}, catches...)
+		if finally != nil {
+			sections = append(sections, *finally)
+		}
+		for _, section := range sections {
+			if j := leavingJump(section.Nodes, false, false); j != nil {
+				g.fail(*j, fmt.Sprintf("go can not %s out of a try", j.NodeType))
+			}
+		}
+		// Deferred functions run in reverse order, so the finally body is
+		// deferred first to run after the catch bodies.
+		g.line("func() {")
+		g.depth++
+		if finally != nil {
+			g.line("defer func() {")
+			g.body(finally.Nodes)
+			g.line("}()")
+		}
+		if len(catches) > 0 {
+			g.line("defer func() {")
+			g.depth++
+			g.line("if r := recover(); r != nil {")
+			g.depth++
+			for _, c := range catches {
+				g.line("// catch " + c.Value)
+				g.statements(c.Nodes)
+			}
+			g.depth--
+			g.line("}")
+			g.depth--
+			g.line("}()")
+		}
+		g.statements(n.Nodes)
+		g.depth--
+		g.line("}()")
+	case "exit":
+		g.packages["os"] = true
+		g.line("os.Exit(" + orDefault(n.Value, "0") + ")")
+	case "break", "continue", "return":
+		g.line(jump(n.NodeType, n.Value))
+	}
+}
+
+// leavingJump returns the first jump among nodes that would leave the
+// function literal they are written into, or nil if there is none. inLoop
+// and inCase tell whether the nodes are in a loop or a case inside of the
+// function literal.
+func leavingJump(nodes []syntax.Node, inLoop bool, inCase bool) *syntax.Node {
+	for i := range nodes {
+		n := &nodes[i]
+		switch n.NodeType {
+		case "return":
+			return n
+		case "break":
+			if !inLoop && !inCase {
+				return n
+			}
+		case "continue":
+			if !inLoop {
+				return n
+			}
+		case "while", "dowhile", "for":
+			if j := leavingJump(n.Nodes, true, inCase); j != nil {
+				return j
+			}
+			continue
+		case "case", "default":
+			if j := leavingJump(n.Nodes, inLoop, true); j != nil {
+				return j
+			}
+			continue
+		case "parallel":
+			// The branches of a nested parallel are checked on their own.
+			continue
+		}
+		if j := leavingJump(n.Nodes, inLoop, inCase); j != nil {
+			return j
+		}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package codegen
+
+import (
+	"strconv"
+
+	"github.com/JSchrtke/structogen/syntax"
+)
+
+func init() {
+	register("java", java{})
+}
+
+// java writes a static Java method. Apart from exceptions and enhanced for
+// loops, which are kept, Java is written like C.
+type java struct{}
+
+func (java) indentation() string {
+	return "    "
+}
+
+func (java) emptyBody() string {
+	return ""
+}
+
+func (java) function(g *generator, name string, body []syntax.Node) {
+	identifier, params := signature(name, camelCase)
+	g.line("static void " + identifier + params + " {")
+	g.body(body)
+	g.line("}")
+}
+
+func (java) statement(g *generator, n syntax.Node, merged []syntax.Node) {
+	switch n.NodeType {
+	case "parallel":
+		for i, b := range n.Nodes {
+			g.line("// parallel branch " + strconv.Itoa(i+1))
+			g.statements(b.Nodes)
+		}
+	case "try":
+		g.line("try {")
+		g.body(n.Nodes)
+		catches, finally := handlers(merged)
+		for _, c := range catches {
+			g.line("} catch (" + orDefault(c.Value, "Exception e") + ") {")
+			g.body(c.Nodes)
+		}
+		if finally != nil {
+			g.line("} finally {")
+			g.body(finally.Nodes)
+		}
+		g.line("}")
+	case "exit":
+		g.line("System.exit(" + orDefault(n.Value, "0") + ");")
+	default:
+		braces(g, n, merged, ":")
+	}
+}
@@ -0,0 +1,99 @@
+package codegen
+
+import "github.com/JSchrtke/structogen/syntax"
+
+func init() {
+	register("pseudo", pseudo{})
+}
+
+// pseudo writes pseudocode, where every compound statement is closed by an
+// end line that names it.
+type pseudo struct{}
+
+func (pseudo) indentation() string {
+	return "    "
+}
+
+func (pseudo) emptyBody() string {
+	return ""
+}
+
+func (pseudo) function(g *generator, name string, body []syntax.Node) {
+	g.line("function " + name)
+	g.body(body)
+	g.line("end function")
+}
+
+func (pseudo) statement(g *generator, n syntax.Node, merged []syntax.Node) {
+	switch n.NodeType {
+	case "instruction":
+		g.lines(n.Value, keep)
+	case "call":
+		g.line("call " + callExpression(n.Value))
+	case "if":
+		g.line("if " + n.Value + " then")
+		g.body(n.Nodes)
+		if e := elseBody(merged); e != nil {
+			g.line("else")
+			g.body(e.Nodes)
+		}
+		g.line("end if")
+	case "while":
+		g.line("while " + n.Value + " do")
+		g.body(n.Nodes)
+		g.line("end while")
+	case "for":
+		g.line("for " + n.Value + " do")
+		g.body(n.Nodes)
+		g.line("end for")
+	case "dowhile":
+		g.line("repeat")
+		g.body(n.Nodes)
+		g.line("while " + n.Value)
+	case "switch":
+		g.line("switch " + n.Value)
+		g.depth++
+		for _, c := range n.Nodes {
+			if c.NodeType == "default" {
+				g.line("default:")
+			} else {
+				g.line("case " + c.Value + ":")
+			}
+			g.body(c.Nodes)
+		}
+		g.depth--
+		g.line("end switch")
+	case "parallel":
+		g.line("parallel")
+		g.depth++
+		for _, b := range n.Nodes {
+			g.line("branch")
+			g.body(b.Nodes)
+		}
+		g.depth--
+		g.line("end parallel")
+	case "try":
+		g.line("try")
+		g.body(n.Nodes)
+		catches, finally := handlers(merged)
+		for _, c := range catches {
+			g.line("catch " + c.Value)
+			g.body(c.Nodes)
+		}
+		if finally != nil {
+			g.line("finally")
+			g.body(finally.Nodes)
+		}
+		g.line("end try")
+	case "break", "continue", "return", "exit":
+		g.line(jump(n.NodeType, n.Value))
+	}
+}
+
+// jump returns the keyword of a jump, followed by its value if it has one.
+func jump(keyword string, value string) string {
+	if value == "" {
+		return keyword
+	}
+	return keyword + " " + value
+}
@@ -0,0 +1,166 @@
+package codegen
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/JSchrtke/structogen/syntax"
+)
+
+func init() {
+	register("python", python{})
+}
+
+// python writes Python. As Python has no do-while loop, a dowhile becomes a
+// while loop that breaks at its end, and a for loop without an in becomes a
+// while loop. A continue in them checks the condition of the dowhile or runs
+// the step of the for loop first. The branches of a parallel run one after
+// another. A break in a case becomes a pass, because it would leave the
+// surrounding loop instead.
+type python struct{}
+
+func (python) indentation() string {
+	return "    "
+}
+
+func (python) emptyBody() string {
+	return "pass"
+}
+
+func (python) function(g *generator, name string, body []syntax.Node) {
+	identifier, params := signature(name, snakeCase)
+	g.line("def " + identifier + params + ":")
+	g.body(body)
+}
+
+func (python) statement(g *generator, n syntax.Node, merged []syntax.Node) {
+	switch n.NodeType {
+	case "instruction":
+		g.lines(n.Value, keep)
+	case "call":
+		g.line(callExpression(n.Value))
+	case "if":
+		g.line("if " + n.Value + ":")
+		g.body(n.Nodes)
+		if e := elseBody(merged); e != nil {
+			g.line("else:")
+			g.body(e.Nodes)
+		}
+	case "while":
+		g.line("while " + n.Value + ":")
+		pythonLoopBody(g, n.Nodes, nil)
+	case "for":
+		// A for loop with three clauses, like "i = 0; i < 10; i += 1", is
+		// written as a while loop with its clauses around it.
+		clauses := strings.Split(n.Value, ";")
+		if len(clauses) == 3 {
+			step := strings.TrimSpace(clauses[2])
+			g.lines(strings.TrimSpace(clauses[0]), keep)
+			condition := orDefault(strings.TrimSpace(clauses[1]), "True")
+			g.line("while " + condition + ":")
+			pythonLoopBody(g, n.Nodes, func() {
+				g.lines(step, keep)
+				g.line("continue")
+			})
+			g.depth++
+			g.lines(step, keep)
+			g.depth--
+		} else if strings.Contains(n.Value, " in ") {
+			g.line("for " + n.Value + ":")
+			pythonLoopBody(g, n.Nodes, nil)
+		} else {
+			g.line("while " + n.Value + ":")
+			pythonLoopBody(g, n.Nodes, nil)
+		}
+	case "dowhile":
+		g.line("while True:")
+		// A continue leaves the loop unless the condition holds.
+		pythonLoopBody(g, n.Nodes, func() {
+			g.line("if " + n.Value + ":")
+			g.depth++
+			g.line("continue")
+			g.depth--
+			g.line("break")
+		})
+		g.depth++
+		g.line("if not (" + n.Value + "):")
+		g.depth++
+		g.line("break")
+		g.depth -= 2
+	case "switch":
+		g.line("match " + n.Value + ":")
+		g.depth++
+		for _, c := range n.Nodes {
+			if c.NodeType == "default" {
+				g.line("case _:")
+			} else {
+				g.line("case " + c.Value + ":")
+			}
+			inCase := g.inCase
+			g.inCase = true
+			g.body(c.Nodes)
+			g.inCase = inCase
+		}
+		g.depth--
+	case "parallel":
+		for i, b := range n.Nodes {
+			// Comments do not count as statements of a body.
+			g.line("# parallel branch " + strconv.Itoa(i+1))
+			written := g.written
+			g.statements(b.Nodes)
+			if g.written == written {
+				g.line("pass")
+			}
+		}
+	case "try":
+		g.line("try:")
+		g.body(n.Nodes)
+		catches, finally := handlers(merged)
+		for _, c := range catches {
+			g.line("except " + pythonException(c.Value) + ":")
+			g.body(c.Nodes)
+		}
+		if finally != nil {
+			g.line("finally:")
+			g.body(finally.Nodes)
+		}
+	case "exit":
+		g.line("raise SystemExit(" + n.Value + ")")
+	case "break":
+		if g.inCase {
+			g.line("pass")
+		} else {
+			g.line(jump(n.NodeType, n.Value))
+		}
+	case "continue":
+		if g.continueJump != nil {
+			g.continueJump()
+		} else {
+			g.line(jump(n.NodeType, n.Value))
+		}
+	case "return":
+		g.line(jump(n.NodeType, n.Value))
+	}
+}
+
+// pythonLoopBody writes the body of a loop, which is what a break inside of
+// it leaves. continueJump writes a continue of the loop, see generator.
+func pythonLoopBody(g *generator, nodes []syntax.Node, continueJump func()) {
+	inCase, outerJump := g.inCase, g.continueJump
+	g.inCase, g.continueJump = false, continueJump
+	g.body(nodes)
+	g.inCase, g.continueJump = inCase, outerJump
+}
+
+// pythonException turns the value of a catch, like "ValueError e", into the
+// exception of an except clause, like "ValueError as e".
+func pythonException(value string) string {
+	fields := strings.Fields(value)
+	switch {
+	case len(fields) == 0:
+		return "Exception"
+	case len(fields) == 2 && fields[1] != "as":
+		return fields[0] + " as " + fields[1]
+	}
+	return value
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around each change.
+const diffContext = 3
+
+// edit is a single line of a diff. Op is ' ' for unchanged lines, '-' for
+// removed and '+' for added ones, a and b are the indices of the line in the
+// old and the new text.
+type edit struct {
+	op   byte
+	line string
+	a    int
+	b    int
+}
+
+// diff returns the changes from old to new in unified format, or an empty
+// string if there are none. The old text is labeled name.orig.
+func diff(name string, old string, new string) string {
+	if old == new {
+		return ""
+	}
+	edits := diffLines(strings.SplitAfter(old, "\n"), strings.SplitAfter(new, "\n"))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s.orig\n+++ %s\n", name, name)
+	for i := 0; i < len(edits); {
+		if edits[i].op == ' ' {
+			i++
+			continue
+		}
+		// A hunk extends to the last change that is close enough for the
+		// context lines of both to overlap.
+		last := i
+		for j := i + 1; j < len(edits) && j-last <= 2*diffContext; j++ {
+			if edits[j].op != ' ' {
+				last = j
+			}
+		}
+		start := i - diffContext
+		if start < 0 {
+			start = 0
+		}
+		end := last + diffContext + 1
+		if end > len(edits) {
+			end = len(edits)
+		}
+		writeHunk(&b, edits[start:end])
+		i = end
+	}
+	return b.String()
+}
+
+// diffLines returns the shortest edit script from old to new, which is
+// derived from their longest common subsequence of lines.
+func diffLines(old []string, new []string) []edit {
+	old, new = trimEmpty(old), trimEmpty(new)
+	// lcs[i][j] is the length of the longest common subsequence of old[i:]
+	// and new[j:].
+	lcs := make([][]int, len(old)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(new)+1)
+	}
+	for i := len(old) - 1; i >= 0; i-- {
+		for j := len(new) - 1; j >= 0; j-- {
+			if old[i] == new[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] > lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var edits []edit
+	i, j := 0, 0
+	for i < len(old) || j < len(new) {
+		switch {
+		case i < len(old) && j < len(new) && old[i] == new[j]:
+			edits = append(edits, edit{op: ' ', line: old[i], a: i, b: j})
+			i++
+			j++
+		case i < len(old) && (j == len(new) || lcs[i+1][j] >= lcs[i][j+1]):
+			edits = append(edits, edit{op: '-', line: old[i], a: i, b: j})
+			i++
+		default:
+			edits = append(edits, edit{op: '+', line: new[j], a: i, b: j})
+			j++
+		}
+	}
+	return edits
+}
+
+// trimEmpty removes the empty string strings.SplitAfter returns after a
+// final line break.
+func trimEmpty(lines []string) []string {
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		return lines[:len(lines)-1]
+	}
+	return lines
+}
+
+func writeHunk(b *strings.Builder, edits []edit) {
+	var oldLines, newLines int
+	for _, e := range edits {
+		if e.op != '+' {
+			oldLines++
+		}
+		if e.op != '-' {
+			newLines++
+		}
+	}
+	// Line numbers are 1-based, but an empty range is given by the line
+	// before it.
+	oldStart, newStart := edits[0].a+1, edits[0].b+1
+	if oldLines == 0 {
+		oldStart--
+	}
+	if newLines == 0 {
+		newStart--
+	}
+	fmt.Fprintf(
+		b, "@@ -%s +%s @@\n",
+		hunkRange(oldStart, oldLines), hunkRange(newStart, newLines),
+	)
+	for _, e := range edits {
+		b.WriteByte(e.op)
+		b.WriteString(e.line)
+		if !strings.HasSuffix(e.line, "\n") {
+			b.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+// hunkRange formats a range of lines, leaving out the length if it is 1.
+func hunkRange(start int, lines int) string {
+	if lines == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, lines)
+}
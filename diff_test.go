@@ -0,0 +1,50 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestDiffOfEqualTextsIsEmpty(t *testing.T) {
+	if d := diff("a", "x\ny\n", "x\ny\n"); d != "" {
+		t.Errorf("Expected no diff, but got:\n%s", d)
+	}
+}
+
+func TestDiffIsUnified(t *testing.T) {
+	old := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n14\n15\n"
+	new := "1\n2\nthree\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n15\nsixteen\n"
+	expected := `--- a.orig
++++ a
+@@ -1,6 +1,6 @@
+ 1
+ 2
+-3
++three
+ 4
+ 5
+ 6
+@@ -11,5 +11,5 @@
+ 11
+ 12
+ 13
+-14
+ 15
++sixteen
+`
+	if d := diff("a", old, new); d != expected {
+		t.Errorf("Expected:\n%s\nbut got:\n%s", expected, d)
+	}
+}
+
+func TestDiffMarksMissingNewlineAtEndOfFile(t *testing.T) {
+	expected := `--- a.orig
++++ a
+@@ -1 +1 @@
+-x
+\ No newline at end of file
++x
+`
+	if d := diff("a", "x", "x\n"); d != expected {
+		t.Errorf("Expected:\n%s\nbut got:\n%s", expected, d)
+	}
+}
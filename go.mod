@@ -1,3 +1,5 @@
 module github.com/JSchrtke/structogen
 
 go 1.16
+
+require golang.org/x/image v0.0.0-20220413100746-70e8d0d3baa9
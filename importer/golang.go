@@ -0,0 +1,293 @@
+package importer
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/scanner"
+	"go/token"
+	"strings"
+
+	"github.com/JSchrtke/structogen/syntax"
+)
+
+// Go translates the function with the given name in the Go source into a
+// structogram. Methods can be chosen by their name or by their receiver type
+// and name, like Stack.Push. If name is empty, the source has to contain
+// exactly one function.
+//
+// An if with else if becomes an if nested inside of the else, a for with
+// only a condition becomes a while, and a type switch becomes a switch.
+// Statements that only call a function become calls, statements without a
+// counterpart in structograms become instructions, printed as Go source.
+func Go(source string, name string) (*syntax.Structogram, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", source, 0)
+	if err != nil {
+		if list, ok := err.(scanner.ErrorList); ok && len(list) > 0 {
+			e := list[0]
+			return nil, sourceError(e.Pos.Line, e.Pos.Column, e.Msg)
+		}
+		return nil, err
+	}
+
+	var functions []*ast.FuncDecl
+	var names []string
+	for _, decl := range file.Decls {
+		f, ok := decl.(*ast.FuncDecl)
+		if !ok || f.Body == nil {
+			continue
+		}
+		functions = append(functions, f)
+		names = append(names, goQualifiedName(f))
+	}
+	i, err := chooseFunction(names, goResolveMethod(names, name))
+	if err != nil {
+		return nil, err
+	}
+	f := functions[i]
+
+	c := goConverter{fset: fset}
+	// The parameters are printed as a function type, which starts with func.
+	params := c.print(&ast.FuncType{Params: f.Type.Params})
+	return &syntax.Structogram{
+		Name:  f.Name.Name + strings.TrimPrefix(params, "func"),
+		Nodes: c.body(f.Body.List),
+	}, nil
+}
+
+// goQualifiedName returns the name of f, which is prefixed with the name of
+// its receiver type for methods.
+func goQualifiedName(f *ast.FuncDecl) string {
+	if f.Recv == nil || len(f.Recv.List) == 0 {
+		return f.Name.Name
+	}
+	recv := f.Recv.List[0].Type
+	for {
+		switch t := recv.(type) {
+		case *ast.StarExpr:
+			recv = t.X
+			continue
+		case *ast.ParenExpr:
+			recv = t.X
+			continue
+		case *ast.Ident:
+			return t.Name + "." + f.Name.Name
+		}
+		return f.Name.Name
+	}
+}
+
+// goResolveMethod returns the qualified name of the only method called name,
+// so that methods can be chosen without their receiver type as well.
+func goResolveMethod(names []string, name string) string {
+	var found []string
+	for _, n := range names {
+		if n == name {
+			return name
+		}
+		if strings.HasSuffix(n, "."+name) {
+			found = append(found, n)
+		}
+	}
+	if len(found) == 1 {
+		return found[0]
+	}
+	return name
+}
+
+type goConverter struct {
+	fset *token.FileSet
+}
+
+// print returns node as Go source, indented with spaces.
+func (c *goConverter) print(node interface{}) string {
+	var buf bytes.Buffer
+	config := printer.Config{Mode: printer.UseSpaces, Tabwidth: 4}
+	if err := config.Fprint(&buf, c.fset, node); err != nil {
+		return ""
+	}
+	return buf.String()
+}
+
+// printList prints the nodes separated by commas.
+func (c *goConverter) printList(nodes []ast.Expr) string {
+	var printed []string
+	for _, n := range nodes {
+		printed = append(printed, c.print(n))
+	}
+	return strings.Join(printed, ", ")
+}
+
+// body translates the statements of a block. An empty block gets an empty
+// instruction, as bodies in structograms can not be empty.
+func (c *goConverter) body(statements []ast.Stmt) []syntax.Node {
+	nodes := c.statements(statements)
+	if len(nodes) == 0 {
+		nodes = []syntax.Node{{NodeType: "instruction"}}
+	}
+	return nodes
+}
+
+func (c *goConverter) statements(statements []ast.Stmt) []syntax.Node {
+	var nodes []syntax.Node
+	for _, s := range statements {
+		nodes = append(nodes, c.statement(s)...)
+	}
+	return nodes
+}
+
+func (c *goConverter) statement(s ast.Stmt) []syntax.Node {
+	switch s := s.(type) {
+	case *ast.EmptyStmt:
+		return nil
+	case *ast.BlockStmt:
+		return c.statements(s.List)
+	case *ast.LabeledStmt:
+		return c.statement(s.Stmt)
+	case *ast.ExprStmt:
+		if _, ok := s.X.(*ast.CallExpr); ok {
+			return []syntax.Node{{NodeType: "call", Value: c.print(s.X)}}
+		}
+	case *ast.ReturnStmt:
+		return []syntax.Node{{NodeType: "return", Value: c.printList(s.Results)}}
+	case *ast.BranchStmt:
+		switch s.Tok {
+		case token.BREAK, token.CONTINUE:
+			var label string
+			if s.Label != nil {
+				label = s.Label.Name
+			}
+			return []syntax.Node{{NodeType: s.Tok.String(), Value: label}}
+		}
+	case *ast.IfStmt:
+		return c.ifStatement(s)
+	case *ast.ForStmt:
+		return c.forStatement(s)
+	case *ast.RangeStmt:
+		return c.rangeStatement(s)
+	case *ast.SwitchStmt:
+		value := "true"
+		if s.Tag != nil {
+			value = c.print(s.Tag)
+		}
+		return c.switchStatement(s.Init, value, s.Body)
+	case *ast.TypeSwitchStmt:
+		return c.switchStatement(s.Init, c.print(s.Assign), s.Body)
+	}
+	return []syntax.Node{{NodeType: "instruction", Value: c.print(s)}}
+}
+
+// init translates the init statement of an if, for or switch, which
+// becomes a statement of its own in front of it.
+func (c *goConverter) init(s ast.Stmt) []syntax.Node {
+	if s == nil {
+		return nil
+	}
+	return c.statement(s)
+}
+
+func (c *goConverter) ifStatement(s *ast.IfStmt) []syntax.Node {
+	nodes := c.init(s.Init)
+	nodes = append(nodes, syntax.Node{
+		NodeType: "if",
+		Value:    c.print(s.Cond),
+		Nodes:    c.body(s.Body.List),
+	})
+	if s.Else == nil {
+		return nodes
+	}
+
+	// An else if becomes an if nested inside of the else.
+	var elseBody []syntax.Node
+	switch e := s.Else.(type) {
+	case *ast.IfStmt:
+		elseBody = c.ifStatement(e)
+	case *ast.BlockStmt:
+		elseBody = c.body(e.List)
+	}
+	return append(nodes, syntax.Node{NodeType: "else", Nodes: elseBody})
+}
+
+// forStatement translates a for with only a condition, or none at all, into
+// a while and a for with three clauses into a for.
+func (c *goConverter) forStatement(s *ast.ForStmt) []syntax.Node {
+	body := c.body(s.Body.List)
+	if s.Init == nil && s.Post == nil {
+		cond := "true"
+		if s.Cond != nil {
+			cond = c.print(s.Cond)
+		}
+		return []syntax.Node{{NodeType: "while", Value: cond, Nodes: body}}
+	}
+
+	var clauses [3]string
+	if s.Init != nil {
+		clauses[0] = c.print(s.Init)
+	}
+	if s.Cond != nil {
+		clauses[1] = c.print(s.Cond)
+	}
+	if s.Post != nil {
+		clauses[2] = c.print(s.Post)
+	}
+	return []syntax.Node{{
+		NodeType: "for",
+		Value:    strings.Join(clauses[:], "; "),
+		Nodes:    body,
+	}}
+}
+
+func (c *goConverter) rangeStatement(s *ast.RangeStmt) []syntax.Node {
+	var header string
+	if s.Key != nil {
+		header = c.print(s.Key)
+		if s.Value != nil {
+			header += ", " + c.print(s.Value)
+		}
+		header += " " + s.Tok.String() + " "
+	}
+	return []syntax.Node{{
+		NodeType: "for",
+		Value:    header + "range " + c.print(s.X),
+		Nodes:    c.body(s.Body.List),
+	}}
+}
+
+// switchStatement translates the clauses of a switch. The default case
+// comes last, as in .str files. A switch without one gets an empty default,
+// which every switch needs.
+func (c *goConverter) switchStatement(
+	init ast.Stmt, value string, body *ast.BlockStmt,
+) []syntax.Node {
+	var cases []syntax.Node
+	var defaultNode *syntax.Node
+	for _, s := range body.List {
+		clause, ok := s.(*ast.CaseClause)
+		if !ok {
+			continue
+		}
+		nodes := c.body(clause.Body)
+		if clause.List == nil {
+			defaultNode = &syntax.Node{NodeType: "default", Nodes: nodes}
+			continue
+		}
+		cases = append(cases, syntax.Node{
+			NodeType: "case",
+			Value:    c.printList(clause.List),
+			Nodes:    nodes,
+		})
+	}
+	if defaultNode == nil {
+		defaultNode = &syntax.Node{NodeType: "default", Nodes: c.body(nil)}
+	}
+	cases = append(cases, *defaultNode)
+
+	nodes := c.init(init)
+	return append(nodes, syntax.Node{
+		NodeType: "switch",
+		Value:    value,
+		Nodes:    cases,
+	})
+}
@@ -0,0 +1,175 @@
+package importer
+
+import (
+	"testing"
+
+	"github.com/JSchrtke/structogen/syntax"
+)
+
+func TestGoTranslatesSimpleStatements(t *testing.T) {
+	s, err := Go(`package main
+
+func greet(name string, times int) (string, error) {
+	count++
+	fmt.Println("hello", name)
+	message := format(name,
+		"!")
+	defer log.Flush()
+	return message, nil
+}
+`, "greet")
+	checkOk(t, err)
+	checkImport(t, s, `name("greet(name string, times int)")
+
+instruction("count++")
+call("fmt.Println(\"hello\", name)")
+instruction("""
+    message := format(name,
+        "!")
+""")
+instruction("defer log.Flush()")
+return("message, nil")
+`)
+}
+
+func TestGoTranslatesElseIfIntoNestedIf(t *testing.T) {
+	s, err := Go(`package main
+
+func sign(x int) int {
+	if x > 0 {
+		return 1
+	} else if y := -x; y > 0 {
+		return -1
+	} else {
+	}
+	return 0
+}
+`, "")
+	checkOk(t, err)
+	checkImport(t, s, `name("sign(x int)")
+
+if ("x > 0") {
+    return("1")
+} else {
+    instruction("y := -x")
+    if ("y > 0") {
+        return("-1")
+    } else {
+        instruction("")
+    }
+}
+return("0")
+`)
+}
+
+func TestGoTranslatesLoops(t *testing.T) {
+	s, err := Go(`package main
+
+func loops(items []int) {
+	for i := 0; i < 10; i++ {
+		continue
+	}
+	for len(items) > 0 {
+		items = items[1:]
+	}
+	for {
+		break
+	}
+	for _, item := range items {
+		use(item)
+	}
+}
+`, "loops")
+	checkOk(t, err)
+	checkImport(t, s, `name("loops(items []int)")
+
+for ("i := 0; i < 10; i++") {
+    continue("")
+}
+while ("len(items) > 0") {
+    instruction("items = items[1:]")
+}
+while ("true") {
+    break("")
+}
+for ("_, item := range items") {
+    call("use(item)")
+}
+`)
+}
+
+func TestGoTranslatesSwitch(t *testing.T) {
+	s, err := Go(`package main
+
+func describe(x interface{}) {
+	switch v := x.(type) {
+	default:
+		fmt.Println("other")
+	case int, uint:
+		fmt.Println("integer", v)
+	}
+	switch {
+	case x == nil:
+	}
+}
+`, "describe")
+	checkOk(t, err)
+	checkImport(t, s, `name("describe(x interface{})")
+
+switch ("v := x.(type)") {
+    case ("int, uint") {
+        call("fmt.Println(\"integer\", v)")
+    }
+    default {
+        call("fmt.Println(\"other\")")
+    }
+}
+switch ("true") {
+    case ("x == nil") {
+        instruction("")
+    }
+    default {
+        instruction("")
+    }
+}
+`)
+}
+
+func TestGoFindsMethods(t *testing.T) {
+	source := `package main
+
+func (s *Stack) Push(v int) {
+	s.items = append(s.items, v)
+}
+
+func (q Queue) Push(v int) {
+	q.items = append(q.items, v)
+}
+
+func Pop() {}
+`
+	s, err := Go(source, "Stack.Push")
+	checkOk(t, err)
+	checkImport(t, s, `name("Push(v int)")
+
+instruction("s.items = append(s.items, v)")
+`)
+
+	s, err = Go(source, "Pop")
+	checkOk(t, err)
+	checkImport(t, s, "name(\"Pop()\")\n\ninstruction(\"\")\n")
+
+	_, err = Go(source, "Push")
+	checkErrorMsg(
+		t, err,
+		"no function named Push, the source contains: Stack.Push, Queue.Push, Pop",
+	)
+}
+
+func TestGoReportsSyntaxErrors(t *testing.T) {
+	_, err := Go("package main\n\nfunc f() {\n\tif {\n}\n", "")
+	if _, ok := err.(syntax.Diagnostic); !ok {
+		t.Fatalf("Expected a syntax.Diagnostic, but got %v", err)
+	}
+	checkErrorMsg(t, err, "4:5, missing condition in if statement")
+}
@@ -0,0 +1,37 @@
+// Package importer translates functions written in other programming
+// languages into structograms.
+package importer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/JSchrtke/structogen/syntax"
+)
+
+// chooseFunction returns the index of the function with the given name
+// among names, or of the only function if name is empty.
+func chooseFunction(names []string, name string) (int, error) {
+	for i, n := range names {
+		if name == "" && len(names) == 1 || n == name {
+			return i, nil
+		}
+	}
+	if name == "" {
+		return 0, fmt.Errorf(
+			"the source contains %d functions, choose one of them: %s",
+			len(names), strings.Join(names, ", "),
+		)
+	}
+	return 0, fmt.Errorf(
+		"no function named %s, the source contains: %s",
+		name, strings.Join(names, ", "),
+	)
+}
+
+// sourceError returns an error at the given position of the source that is
+// imported.
+func sourceError(line int, column int, message string) syntax.Diagnostic {
+	start := syntax.Position{Line: line, Column: column}
+	return syntax.Diagnostic{Start: start, End: start, Message: message}
+}
@@ -0,0 +1,347 @@
+package importer
+
+import (
+	"strings"
+	"unicode"
+)
+
+// pyLine is a logical line of Python source. Comments are removed and lines
+// that are continued, by a backslash or by open brackets, are joined.
+type pyLine struct {
+	line   int
+	indent int
+	text   string
+}
+
+// pyStatement is a statement of Python source. Compound statements have a
+// keyword, the rest of their header up to the colon, and a body. The header
+// of a simple statement is the whole statement.
+type pyStatement struct {
+	line    int
+	column  int
+	keyword string
+	header  string
+	body    []pyStatement
+}
+
+// pyCompoundKeywords are the keywords that start a compound statement. The
+// soft keywords match and case are only keywords if a header follows them.
+var pyCompoundKeywords = map[string]bool{
+	"if": true, "elif": true, "else": true, "while": true, "for": true,
+	"try": true, "except": true, "finally": true, "with": true, "def": true,
+	"class": true, "match": true, "case": true, "async": true,
+}
+
+// splitPyLines splits source into logical lines.
+func splitPyLines(source string) ([]pyLine, error) {
+	rs := []rune(strings.ReplaceAll(source, "\r\n", "\n"))
+	var lines []pyLine
+	var current *pyLine
+	var text []rune
+	var quote string
+	depth := 0
+	lineNumber := 1
+	// joined is set after a line break inside of brackets, which becomes a
+	// space, unless it directly follows an opening bracket or precedes a
+	// closing one.
+	joined := false
+	add := func(r rune) {
+		if joined && len(text) > 0 &&
+			!strings.ContainsRune(" ([{", text[len(text)-1]) &&
+			!strings.ContainsRune(")]}", r) {
+			text = append(text, ' ')
+		}
+		joined = false
+		text = append(text, r)
+	}
+
+	for i := 0; i < len(rs); {
+		if current == nil {
+			indent, j := pyIndentation(rs, i)
+			if j == len(rs) {
+				break
+			}
+			switch rs[j] {
+			case '\n':
+				lineNumber++
+				i = j + 1
+				continue
+			case '#':
+				for j < len(rs) && rs[j] != '\n' {
+					j++
+				}
+				i = j
+				continue
+			}
+			current = &pyLine{line: lineNumber, indent: indent}
+			i = j
+		}
+
+		r := rs[i]
+		switch {
+		case quote != "":
+			if r == '\\' && i+1 < len(rs) {
+				if rs[i+1] == '\n' {
+					lineNumber++
+				}
+				text = append(text, r, rs[i+1])
+				i += 2
+				continue
+			}
+			if hasPrefixAt(rs, i, quote) {
+				text = append(text, []rune(quote)...)
+				i += len(quote)
+				quote = ""
+				continue
+			}
+			if r == '\n' {
+				if len(quote) == 1 {
+					return nil, sourceError(lineNumber, 1, "unterminated string")
+				}
+				lineNumber++
+			}
+			text = append(text, r)
+			i++
+		case r == '#':
+			for i < len(rs) && rs[i] != '\n' {
+				i++
+			}
+		case r == '"' || r == '\'':
+			quote = string(r)
+			if hasPrefixAt(rs, i, strings.Repeat(quote, 3)) {
+				quote = strings.Repeat(quote, 3)
+			}
+			for _, q := range quote {
+				add(q)
+			}
+			i += len(quote)
+		case r == '\\' && i+1 < len(rs) && rs[i+1] == '\n':
+			lineNumber++
+			i = skipBlanks(rs, i+2)
+			joined = true
+		case r == '\n':
+			lineNumber++
+			i++
+			if depth > 0 {
+				i = skipBlanks(rs, i)
+				joined = true
+				continue
+			}
+			current.text = strings.TrimSpace(string(text))
+			lines = append(lines, *current)
+			current, text, joined = nil, nil, false
+		case r == ' ' || r == '\t':
+			if !joined {
+				text = append(text, r)
+			}
+			i++
+		default:
+			if strings.ContainsRune("([{", r) {
+				depth++
+			} else if strings.ContainsRune(")]}", r) && depth > 0 {
+				depth--
+			}
+			add(r)
+			i++
+		}
+	}
+	if quote != "" {
+		return nil, sourceError(lineNumber, 1, "unterminated string")
+	}
+	if current != nil {
+		current.text = strings.TrimSpace(string(text))
+		lines = append(lines, *current)
+	}
+	return lines, nil
+}
+
+// pyIndentation measures the indentation of the line starting at i, with
+// tabs advancing to the next multiple of eight, and returns it along with
+// the index of the first rune after it.
+func pyIndentation(rs []rune, i int) (int, int) {
+	indent := 0
+	for ; i < len(rs); i++ {
+		switch rs[i] {
+		case ' ':
+			indent++
+		case '\t':
+			indent = indent/8*8 + 8
+		case '\f':
+		default:
+			return indent, i
+		}
+	}
+	return indent, i
+}
+
+func skipBlanks(rs []rune, i int) int {
+	for i < len(rs) && (rs[i] == ' ' || rs[i] == '\t') {
+		i++
+	}
+	return i
+}
+
+func hasPrefixAt(rs []rune, i int, prefix string) bool {
+	return strings.HasPrefix(string(rs[i:]), prefix)
+}
+
+// parsePyBlock parses the statements of lines, starting at i, that have the
+// given indentation. It returns them along with the index of the first line
+// after the block.
+func parsePyBlock(lines []pyLine, i int, indent int) ([]pyStatement, int, error) {
+	var statements []pyStatement
+	for i < len(lines) {
+		l := lines[i]
+		if l.indent < indent {
+			break
+		}
+		if l.indent > indent {
+			return nil, i, sourceError(l.line, l.indent+1, "unexpected indentation")
+		}
+		i++
+
+		keyword := pyKeyword(l.text)
+		if keyword == "" {
+			statements = append(statements, pySimpleStatements(l, l.text)...)
+			continue
+		}
+		colon := findTopLevel(l.text, func(text string, j int) bool {
+			return text[j] == ':' && !strings.HasPrefix(text[j:], ":=")
+		})
+		if colon == -1 {
+			return nil, i, sourceError(l.line, l.indent+1, "expected ':'")
+		}
+		s := pyStatement{
+			line:    l.line,
+			column:  l.indent + 1,
+			keyword: keyword,
+			header:  strings.TrimSpace(l.text[len(keyword):colon]),
+		}
+		if rest := strings.TrimSpace(l.text[colon+1:]); rest != "" {
+			s.body = pySimpleStatements(l, rest)
+		} else {
+			if i == len(lines) || lines[i].indent <= indent {
+				return nil, i, sourceError(
+					l.line, l.indent+1, "expected an indented block",
+				)
+			}
+			var err error
+			s.body, i, err = parsePyBlock(lines, i, lines[i].indent)
+			if err != nil {
+				return nil, i, err
+			}
+		}
+		statements = append(statements, s)
+	}
+	return statements, i, nil
+}
+
+// pyKeyword returns the keyword of the compound statement that text starts
+// with, or an empty string if it is a simple statement. An async keyword is
+// returned along with the keyword that follows it.
+func pyKeyword(text string) string {
+	word := leadingWord(text)
+	if !pyCompoundKeywords[word] {
+		return ""
+	}
+	rest := strings.TrimLeftFunc(text[len(word):], unicode.IsSpace)
+	switch word {
+	case "async":
+		next := leadingWord(rest)
+		if next != "def" && next != "for" && next != "with" {
+			return ""
+		}
+		return text[:len(text)-len(rest)] + next
+	case "match", "case":
+		// As soft keywords, they have to be followed by a header, e.g.
+		// "match = 1" or "match: int" are simple statements.
+		if rest == "" || strings.ContainsRune(":=.,)]}", rune(rest[0])) {
+			return ""
+		}
+	}
+	return word
+}
+
+func leadingWord(text string) string {
+	end := strings.IndexFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+	if end == -1 {
+		return text
+	}
+	return text[:end]
+}
+
+// pySimpleStatements splits text, which belongs to the line l, into the
+// simple statements separated by semicolons.
+func pySimpleStatements(l pyLine, text string) []pyStatement {
+	var statements []pyStatement
+	for {
+		semicolon := findTopLevel(text, func(text string, j int) bool {
+			return text[j] == ';'
+		})
+		part := text
+		if semicolon != -1 {
+			part = text[:semicolon]
+		}
+		if part = strings.TrimSpace(part); part != "" {
+			statements = append(statements, pyStatement{
+				line:   l.line,
+				column: l.indent + 1,
+				header: part,
+			})
+		}
+		if semicolon == -1 {
+			return statements
+		}
+		text = text[semicolon+1:]
+	}
+}
+
+// findTopLevel returns the byte index of the first position in text that is
+// neither a bracket, nor inside of brackets or a string, and for which match
+// returns true, or -1 if there is none.
+func findTopLevel(text string, match func(text string, i int) bool) int {
+	return scanPy(text, func(i int, depth int) bool {
+		return depth == 0 && !strings.ContainsRune("()[]{}", rune(text[i])) &&
+			match(text, i)
+	})
+}
+
+// scanPy calls visit for every byte of text outside of strings, along with
+// the depth of the brackets around it. Brackets themselves belong to the
+// outer level. It returns the index at which visit returns true, or -1.
+func scanPy(text string, visit func(i int, depth int) bool) int {
+	depth := 0
+	var quote string
+	for i := 0; i < len(text); i++ {
+		if quote != "" {
+			if text[i] == '\\' {
+				i++
+			} else if strings.HasPrefix(text[i:], quote) {
+				i += len(quote) - 1
+				quote = ""
+			}
+			continue
+		}
+		switch text[i] {
+		case '"', '\'':
+			quote = text[i : i+1]
+			if strings.HasPrefix(text[i:], strings.Repeat(quote, 3)) {
+				quote = strings.Repeat(quote, 3)
+				i += 2
+			}
+			continue
+		case ')', ']', '}':
+			depth--
+		}
+		if visit(i, depth) {
+			return i
+		}
+		switch text[i] {
+		case '(', '[', '{':
+			depth++
+		}
+	}
+	return -1
+}
@@ -0,0 +1,308 @@
+package importer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/JSchrtke/structogen/syntax"
+)
+
+// Python translates the function with the given name in the Python source
+// into a structogram. If name is empty, the source has to contain exactly
+// one function. Methods of classes are found as well.
+//
+// Only a subset of Python is supported: simple statements, if, while, for,
+// match, try and the jump statements. Other compound statements, like with,
+// are reported as errors of type syntax.Diagnostic.
+func Python(source string, name string) (*syntax.Structogram, error) {
+	lines, err := splitPyLines(source)
+	if err != nil {
+		return nil, err
+	}
+	statements, _, err := parsePyBlock(lines, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	functions := pyFunctions(statements)
+	var names []string
+	for _, f := range functions {
+		names = append(names, pyFunctionName(f))
+	}
+	i, err := chooseFunction(names, name)
+	if err != nil {
+		return nil, err
+	}
+	function := functions[i]
+
+	nodes, err := pyBody(function.body, false)
+	if err != nil {
+		return nil, err
+	}
+	// The return annotation is not part of the name.
+	signature := function.header
+	if arrow := findTopLevel(signature, func(text string, i int) bool {
+		return strings.HasPrefix(text[i:], "->")
+	}); arrow != -1 {
+		signature = strings.TrimSpace(signature[:arrow])
+	}
+	return &syntax.Structogram{Name: signature, Nodes: nodes}, nil
+}
+
+// pyFunctions returns the functions defined in statements and in the
+// classes among them.
+func pyFunctions(statements []pyStatement) []pyStatement {
+	var functions []pyStatement
+	for _, s := range statements {
+		switch s.keyword {
+		case "class":
+			functions = append(functions, pyFunctions(s.body)...)
+		default:
+			if strings.HasSuffix(s.keyword, "def") {
+				functions = append(functions, s)
+			}
+		}
+	}
+	return functions
+}
+
+func pyFunctionName(function pyStatement) string {
+	return strings.TrimSpace(leadingWord(function.header))
+}
+
+// pyBody translates the body of a compound statement. A body without any
+// statements that can be translated, e.g. one only containing pass, gets an
+// empty instruction, as bodies in structograms can not be empty. inCase is
+// set if the innermost loop or match around the body is a match.
+func pyBody(statements []pyStatement, inCase bool) ([]syntax.Node, error) {
+	nodes, err := pyNodes(statements, inCase)
+	if err == nil && len(nodes) == 0 {
+		nodes = []syntax.Node{{NodeType: "instruction"}}
+	}
+	return nodes, err
+}
+
+func pyNodes(statements []pyStatement, inCase bool) ([]syntax.Node, error) {
+	var nodes []syntax.Node
+	for i := 0; i < len(statements); i++ {
+		s := statements[i]
+		// clauses are the statements that continue s, like elif and else
+		// for an if.
+		end := i + 1
+		for end < len(statements) && continuesPy(s, statements[end]) {
+			end++
+		}
+		clauses := statements[i+1 : end]
+		i = end - 1
+
+		var translated []syntax.Node
+		var err error
+		switch s.keyword {
+		case "":
+			translated, err = pySimple(s, inCase)
+		case "if":
+			translated, err = pyIf(s, clauses, inCase)
+		case "while", "for":
+			translated, err = pyLoop(s, clauses)
+		case "match":
+			translated, err = pyMatch(s)
+		case "try":
+			translated, err = pyTry(s, clauses, inCase)
+		case "elif", "else", "except", "finally", "case":
+			err = sourceError(
+				s.line, s.column,
+				fmt.Sprintf("'%s' does not belong to any statement", s.keyword),
+			)
+		default:
+			err = sourceError(
+				s.line, s.column,
+				fmt.Sprintf("unsupported statement '%s'", s.keyword),
+			)
+		}
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, translated...)
+	}
+	return nodes, nil
+}
+
+// continuesPy reports whether clause belongs to the compound statement s.
+func continuesPy(s pyStatement, clause pyStatement) bool {
+	switch s.keyword {
+	case "if":
+		return clause.keyword == "elif" || clause.keyword == "else"
+	case "while", "for":
+		return clause.keyword == "else"
+	case "try":
+		return clause.keyword == "except" || clause.keyword == "else" ||
+			clause.keyword == "finally"
+	}
+	return false
+}
+
+// pySimple translates a simple statement. Calls of functions and methods
+// become calls, jumps become jumps, everything else is an instruction.
+// Docstrings and statements without any effect on the flow, like pass or
+// global, are left out. A break in a case leaves the loop around the match
+// in Python, but only the case in a structogram, so it is reported.
+func pySimple(s pyStatement, inCase bool) ([]syntax.Node, error) {
+	text := s.header
+	keyword := leadingWord(text)
+	value := strings.TrimSpace(text[len(keyword):])
+	switch keyword {
+	case "pass", "global", "nonlocal":
+		return nil, nil
+	case "return":
+		return []syntax.Node{{NodeType: "return", Value: value}}, nil
+	case "break":
+		if inCase {
+			return nil, sourceError(
+				s.line, s.column, "unsupported 'break' out of a 'match'",
+			)
+		}
+		return []syntax.Node{{NodeType: keyword}}, nil
+	case "continue":
+		return []syntax.Node{{NodeType: keyword}}, nil
+	}
+	if isPyString(text) {
+		return nil, nil
+	}
+	if isPyCall(text) {
+		return []syntax.Node{{NodeType: "call", Value: text}}, nil
+	}
+	return []syntax.Node{{NodeType: "instruction", Value: text}}, nil
+}
+
+// isPyString reports whether text consists of a single string literal,
+// optionally with a prefix like r or f.
+func isPyString(text string) bool {
+	prefix := leadingWord(text)
+	if len(prefix) > 2 || len(prefix) == len(text) {
+		return false
+	}
+	rest := text[len(prefix):]
+	if rest[0] != '"' && rest[0] != '\'' {
+		return false
+	}
+	// Nothing may follow the string.
+	return scanPy(rest, func(int, int) bool { return true }) == -1
+}
+
+// isPyCall reports whether text is a call of a function or method, like
+// print(x) or self.items.append(x).
+func isPyCall(text string) bool {
+	open := strings.IndexByte(text, '(')
+	if open <= 0 {
+		return false
+	}
+	for _, part := range strings.Split(text[:open], ".") {
+		if part == "" || leadingWord(part) != part {
+			return false
+		}
+	}
+	// The parentheses of the call have to close at the end of the text.
+	closing := scanPy(text[open:], func(i int, depth int) bool {
+		return i > 0 && depth == 0
+	})
+	return closing == len(text)-open-1
+}
+
+func pyIf(
+	s pyStatement, clauses []pyStatement, inCase bool,
+) ([]syntax.Node, error) {
+	body, err := pyBody(s.body, inCase)
+	if err != nil {
+		return nil, err
+	}
+	nodes := []syntax.Node{{NodeType: "if", Value: s.header, Nodes: body}}
+	if len(clauses) == 0 {
+		return nodes, nil
+	}
+
+	// An elif becomes an if nested inside of the else.
+	var elseBody []syntax.Node
+	if clauses[0].keyword == "elif" {
+		elseBody, err = pyIf(clauses[0], clauses[1:], inCase)
+	} else {
+		elseBody, err = pyBody(clauses[0].body, inCase)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return append(nodes, syntax.Node{NodeType: "else", Nodes: elseBody}), nil
+}
+
+func pyLoop(s pyStatement, clauses []pyStatement) ([]syntax.Node, error) {
+	if len(clauses) > 0 {
+		return nil, sourceError(
+			clauses[0].line, clauses[0].column,
+			fmt.Sprintf("unsupported 'else' of '%s'", s.keyword),
+		)
+	}
+	body, err := pyBody(s.body, false)
+	if err != nil {
+		return nil, err
+	}
+	return []syntax.Node{{NodeType: s.keyword, Value: s.header, Nodes: body}}, nil
+}
+
+// pyMatch translates a match into a switch. The wildcard case becomes the
+// default, which every switch needs, so an empty one is added if there is
+// no wildcard.
+func pyMatch(s pyStatement) ([]syntax.Node, error) {
+	var cases []syntax.Node
+	var defaultNode *syntax.Node
+	for _, c := range s.body {
+		if c.keyword != "case" {
+			return nil, sourceError(c.line, c.column, "expected 'case'")
+		}
+		body, err := pyBody(c.body, true)
+		if err != nil {
+			return nil, err
+		}
+		if c.header == "_" {
+			defaultNode = &syntax.Node{NodeType: "default", Nodes: body}
+			continue
+		}
+		cases = append(
+			cases, syntax.Node{NodeType: "case", Value: c.header, Nodes: body},
+		)
+	}
+	if defaultNode == nil {
+		body, _ := pyBody(nil, true)
+		defaultNode = &syntax.Node{NodeType: "default", Nodes: body}
+	}
+	cases = append(cases, *defaultNode)
+	return []syntax.Node{{NodeType: "switch", Value: s.header, Nodes: cases}}, nil
+}
+
+// pyTry translates a try into a try with a catch for every except clause.
+// The value of a catch is the exception of the clause, including its name,
+// e.g. "IOError as e".
+func pyTry(
+	s pyStatement, clauses []pyStatement, inCase bool,
+) ([]syntax.Node, error) {
+	body, err := pyBody(s.body, inCase)
+	if err != nil {
+		return nil, err
+	}
+	nodes := []syntax.Node{{NodeType: "try", Nodes: body}}
+	for _, c := range clauses {
+		nodeType := "catch"
+		switch c.keyword {
+		case "else":
+			return nil, sourceError(c.line, c.column, "unsupported 'else' of 'try'")
+		case "finally":
+			nodeType = "finally"
+		}
+		body, err := pyBody(c.body, inCase)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(
+			nodes, syntax.Node{NodeType: nodeType, Value: c.header, Nodes: body},
+		)
+	}
+	return nodes, nil
+}
@@ -0,0 +1,277 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/JSchrtke/structogen/syntax"
+)
+
+func checkOk(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("Did not expect any errors, but got %s", err.Error())
+	}
+}
+
+func checkErrorMsg(t *testing.T, err error, expected string) {
+	t.Helper()
+	if err == nil {
+		t.Fatalf("Expected error %s, but got none", expected)
+	}
+	if err.Error() != expected {
+		t.Errorf("Expected error %s, but got %s", expected, err.Error())
+	}
+}
+
+// checkImport checks that the structogram is formatted as expected and that
+// the result can be parsed again.
+func checkImport(t *testing.T, s *syntax.Structogram, expected string) {
+	t.Helper()
+	formatted := syntax.Format(s)
+	if formatted != expected {
+		t.Errorf("Expected:\n%s\nbut got:\n%s", expected, formatted)
+	}
+	_, err := syntax.Parse(strings.NewReader(formatted))
+	checkOk(t, err)
+}
+
+func TestPythonTranslatesSimpleStatements(t *testing.T) {
+	s, err := Python(`
+def greet(name: str) -> None:
+    """Greets name.
+
+    The docstring is left out.
+    """
+    global count
+    count += 1  # comments are dropped
+    print(f"hello {name}")
+    message = format_greeting(
+        name,
+        "!",
+    ); log.info(message)
+    pass
+    return message
+`, "greet")
+	checkOk(t, err)
+	checkImport(t, s, `name("greet(name: str)")
+
+instruction("count += 1")
+call("print(f\"hello {name}\")")
+instruction("message = format_greeting(name, \"!\",)")
+call("log.info(message)")
+return("message")
+`)
+}
+
+func TestPythonTranslatesElifIntoNestedIf(t *testing.T) {
+	s, err := Python(`
+def sign(x):
+    if x > 0:
+        return 1
+    elif x < 0: return -1
+    else:
+        return 0
+`, "")
+	checkOk(t, err)
+	checkImport(t, s, `name("sign(x)")
+
+if ("x > 0") {
+    return("1")
+} else {
+    if ("x < 0") {
+        return("-1")
+    } else {
+        return("0")
+    }
+}
+`)
+}
+
+func TestPythonTranslatesLoops(t *testing.T) {
+	s, err := Python(`
+def search(items, wanted):
+    for item in items:
+        if item == wanted and \
+                item is not None:
+            break
+        continue
+    while True:
+        pass
+`, "search")
+	checkOk(t, err)
+	checkImport(t, s, `name("search(items, wanted)")
+
+for ("item in items") {
+    if ("item == wanted and item is not None") {
+        break("")
+    }
+    continue("")
+}
+while ("True") {
+    instruction("")
+}
+`)
+}
+
+func TestPythonTranslatesMatchIntoSwitch(t *testing.T) {
+	s, err := Python(`
+def describe(command):
+    match command.split():
+        case ["go", direction]:
+            move(direction)
+        case ["quit"] | ["exit"]:
+            return
+        case _:
+            print("unknown")
+    match = 1
+`, "describe")
+	checkOk(t, err)
+	checkImport(t, s, `name("describe(command)")
+
+switch ("command.split()") {
+    case ("[\"go\", direction]") {
+        call("move(direction)")
+    }
+    case ("[\"quit\"] | [\"exit\"]") {
+        return("")
+    }
+    default {
+        call("print(\"unknown\")")
+    }
+}
+instruction("match = 1")
+`)
+
+	// Every switch needs a default.
+	s, err = Python("def f(x):\n    match x:\n        case 1:\n            g()\n", "f")
+	checkOk(t, err)
+	if cases := s.Nodes[0].Nodes; cases[len(cases)-1].NodeType != "default" {
+		t.Errorf("Expected a default, but got %v", cases)
+	}
+}
+
+func TestPythonTranslatesTry(t *testing.T) {
+	s, err := Python(`
+def read(path):
+    try:
+        f = open(path)
+    except (IOError, OSError) as e:
+        raise
+    except:
+        pass
+    finally:
+        f.close()
+`, "read")
+	checkOk(t, err)
+	checkImport(t, s, `name("read(path)")
+
+try {
+    instruction("f = open(path)")
+} catch ("(IOError, OSError) as e") {
+    instruction("raise")
+} catch ("") {
+    instruction("")
+} finally {
+    call("f.close()")
+}
+`)
+}
+
+func TestPythonFindsMethods(t *testing.T) {
+	source := `
+class Stack:
+    def push(self, item):
+        self.items.append(item)
+
+    def pop(self):
+        return self.items.pop()
+
+def main():
+    pass
+`
+	s, err := Python(source, "pop")
+	checkOk(t, err)
+	checkImport(t, s, "name(\"pop(self)\")\n\nreturn(\"self.items.pop()\")\n")
+
+	_, err = Python(source, "")
+	checkErrorMsg(
+		t, err,
+		"the source contains 3 functions, choose one of them: push, pop, main",
+	)
+	_, err = Python(source, "peek")
+	checkErrorMsg(
+		t, err, "no function named peek, the source contains: push, pop, main",
+	)
+}
+
+func TestPythonReportsUnsupportedStatements(t *testing.T) {
+	_, err := Python("def f():\n    with open(p) as f:\n        pass\n", "f")
+	checkErrorMsg(t, err, "2:5, unsupported statement 'with'")
+
+	_, err = Python("def f():\n    for x in y:\n        g()\n    else:\n        h()\n", "f")
+	checkErrorMsg(t, err, "4:5, unsupported 'else' of 'for'")
+
+	_, err = Python("def f():\n    x = 1\n      y = 2\n", "f")
+	checkErrorMsg(t, err, "3:7, unexpected indentation")
+
+	_, err = Python("def f():\n    if x\n        y = 2\n", "f")
+	checkErrorMsg(t, err, "2:5, expected ':'")
+
+	_, err = Python("def f():\n    x = 'a\n", "f")
+	checkErrorMsg(t, err, "2:1, unterminated string")
+}
+
+func TestPythonReportsBreakOutOfMatch(t *testing.T) {
+	_, err := Python(`
+def f(xs):
+    for x in xs:
+        match x:
+            case 0:
+                if stop:
+                    break
+            case _:
+                g(x)
+`, "f")
+	checkErrorMsg(t, err, "7:21, unsupported 'break' out of a 'match'")
+
+	// A break of a loop inside of a case stays in the case.
+	s, err := Python(`
+def f(x):
+    match x:
+        case _:
+            while True:
+                break
+`, "f")
+	checkOk(t, err)
+	checkImport(t, s, `name("f(x)")
+
+switch ("x") {
+    default {
+        while ("True") {
+            break("")
+        }
+    }
+}
+`)
+}
+
+func TestPythonKeepsStringsIntact(t *testing.T) {
+	s, err := Python(`
+def f():
+    x = "a # not a comment; nor a separator"
+    y = """
+  two lines"""
+`, "f")
+	checkOk(t, err)
+	nodes := s.Nodes
+	if len(nodes) != 2 {
+		t.Fatalf("Expected 2 nodes, but got %v", nodes)
+	}
+	if v := nodes[0].Value; v != `x = "a # not a comment; nor a separator"` {
+		t.Errorf("Wrong value %s", v)
+	}
+	if v := nodes[1].Value; v != "y = \"\"\"\n  two lines\"\"\"" {
+		t.Errorf("Wrong value %s", v)
+	}
+}
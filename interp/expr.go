@@ -0,0 +1,541 @@
+package interp
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// evalError is an error in the text of a statement, or one that occurred
+// while evaluating it. It is turned into an Error at the node the text
+// belongs to.
+type evalError string
+
+func (e evalError) Error() string {
+	return string(e)
+}
+
+func newEvalError(format string, args ...interface{}) error {
+	return evalError(fmt.Sprintf(format, args...))
+}
+
+// exprToken is a token of the text of a statement. Its kind is "int",
+// "float", "string", "bool", "identifier", "operator", "separator", which
+// is a semicolon or a line break, or "end" at the end of the text.
+type exprToken struct {
+	kind  string
+	text  string
+	value interface{}
+}
+
+// operators are the operators of the language, longer ones in front of their
+// prefixes.
+var operators = []string{
+	"==", "!=", "<=", ">=", "&&", "||", "++", "--", "+=", "-=", "*=", "/=",
+	"%=", "+", "-", "*", "/", "%", "<", ">", "=", "!", "(", ")", ",",
+}
+
+// wordOperators are the operators that are written as words.
+var wordOperators = map[string]bool{"and": true, "or": true, "not": true}
+
+func lex(text string) ([]exprToken, error) {
+	var tokens []exprToken
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case r == '\n' || r == ';':
+			tokens = append(tokens, exprToken{kind: "separator", text: string(r)})
+			i++
+		case unicode.IsSpace(r):
+			i++
+		case unicode.IsDigit(r):
+			token, n := lexNumber(runes[i:])
+			tokens = append(tokens, token)
+			i += n
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) ||
+				unicode.IsDigit(runes[i]) || runes[i] == '_') {
+				i++
+			}
+			word := string(runes[start:i])
+			switch {
+			case word == "true" || word == "false":
+				tokens = append(tokens, exprToken{
+					kind: "bool", text: word, value: word == "true",
+				})
+			case wordOperators[word]:
+				tokens = append(tokens, exprToken{kind: "operator", text: word})
+			default:
+				tokens = append(tokens, exprToken{kind: "identifier", text: word})
+			}
+		case r == '"' || r == '\'':
+			token, n, err := lexString(runes[i:])
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token)
+			i += n
+		default:
+			rest := string(runes[i:])
+			operator := ""
+			for _, o := range operators {
+				if strings.HasPrefix(rest, o) {
+					operator = o
+					break
+				}
+			}
+			if operator == "" {
+				return nil, newEvalError("unexpected character %q", r)
+			}
+			tokens = append(tokens, exprToken{kind: "operator", text: operator})
+			// All operators consist of ascii characters.
+			i += len(operator)
+		}
+	}
+	return tokens, nil
+}
+
+// lexNumber reads the int or float at the start of runes and returns it
+// along with the number of runes it consists of.
+func lexNumber(runes []rune) (exprToken, int) {
+	digits := func(i int) int {
+		for i < len(runes) && unicode.IsDigit(runes[i]) {
+			i++
+		}
+		return i
+	}
+	kind := "int"
+	i := digits(0)
+	if i+1 < len(runes) && runes[i] == '.' && unicode.IsDigit(runes[i+1]) {
+		kind = "float"
+		i = digits(i + 1)
+	}
+	if i < len(runes) && (runes[i] == 'e' || runes[i] == 'E') {
+		j := i + 1
+		if j < len(runes) && (runes[j] == '+' || runes[j] == '-') {
+			j++
+		}
+		if j < len(runes) && unicode.IsDigit(runes[j]) {
+			kind = "float"
+			i = digits(j)
+		}
+	}
+
+	text := string(runes[:i])
+	token := exprToken{kind: kind, text: text}
+	if kind == "int" {
+		value, err := strconv.ParseInt(text, 10, 64)
+		if err != nil {
+			// The only digits that do not parse are too large for an int.
+			token.kind = "float"
+			token.value, _ = strconv.ParseFloat(text, 64)
+		} else {
+			token.value = value
+		}
+	} else {
+		token.value, _ = strconv.ParseFloat(text, 64)
+	}
+	return token, i
+}
+
+// lexString reads the string in single or double quotes at the start of
+// runes and returns it along with the number of runes it consists of.
+func lexString(runes []rune) (exprToken, int, error) {
+	quote := runes[0]
+	var b strings.Builder
+	for i := 1; i < len(runes); i++ {
+		switch runes[i] {
+		case quote:
+			s := b.String()
+			return exprToken{
+				kind: "string", text: string(runes[:i+1]), value: s,
+			}, i + 1, nil
+		case '\\':
+			i++
+			if i == len(runes) {
+				break
+			}
+			switch runes[i] {
+			case 'n':
+				b.WriteRune('\n')
+			case 't':
+				b.WriteRune('\t')
+			default:
+				b.WriteRune(runes[i])
+			}
+		default:
+			b.WriteRune(runes[i])
+		}
+	}
+	return exprToken{}, 0, newEvalError("unterminated string")
+}
+
+// expr is an expression of the language.
+type expr interface {
+	eval(f *frame) (interface{}, error)
+}
+
+type literal struct {
+	value interface{}
+}
+
+type variable struct {
+	name string
+}
+
+type unary struct {
+	operator string
+	operand  expr
+}
+
+type binary struct {
+	operator    string
+	left, right expr
+}
+
+type callExpr struct {
+	name      string
+	arguments []expr
+}
+
+// statement is a statement of the language. The text of an instruction or a
+// call consists of statements, separated by semicolons or line breaks.
+type statement interface {
+	exec(f *frame) error
+}
+
+// assignment assigns value to a variable. Compound assignments, like +=,
+// keep their operator, increments and decrements become += 1 and -= 1.
+type assignment struct {
+	name     string
+	operator string
+	value    expr
+}
+
+type printStatement struct {
+	arguments []expr
+}
+
+type exprStatement struct {
+	expr expr
+}
+
+type exprParser struct {
+	tokens []exprToken
+	i      int
+}
+
+func (p *exprParser) next() exprToken {
+	if p.i < len(p.tokens) {
+		return p.tokens[p.i]
+	}
+	return exprToken{kind: "end"}
+}
+
+// is reports whether the next token is the operator or identifier text.
+func (p *exprParser) is(text string) bool {
+	t := p.next()
+	return (t.kind == "operator" || t.kind == "identifier") && t.text == text
+}
+
+func (p *exprParser) atEndOfStatement() bool {
+	kind := p.next().kind
+	return kind == "end" || kind == "separator"
+}
+
+func (p *exprParser) unexpected() error {
+	t := p.next()
+	switch t.kind {
+	case "end":
+		return newEvalError("unexpected end")
+	case "separator":
+		return newEvalError("unexpected end of statement")
+	}
+	return newEvalError("unexpected %q", t.text)
+}
+
+func (p *exprParser) expect(text string) error {
+	if !p.is(text) {
+		return p.unexpected()
+	}
+	p.i++
+	return nil
+}
+
+// statement parses a print statement, an assignment or an expression.
+func (p *exprParser) statement() (statement, error) {
+	t := p.next()
+	if t.kind == "identifier" && t.text == "print" {
+		p.i++
+		arguments, err := p.printArguments()
+		return printStatement{arguments}, err
+	}
+	if t.kind == "identifier" && p.i+1 < len(p.tokens) {
+		operator := p.tokens[p.i+1]
+		if operator.kind == "operator" {
+			switch operator.text {
+			case "=", "+=", "-=", "*=", "/=", "%=":
+				p.i += 2
+				value, err := p.expression()
+				return assignment{t.text, operator.text, value}, err
+			case "++", "--":
+				p.i += 2
+				return assignment{
+					t.text, operator.text[:1] + "=", literal{int64(1)},
+				}, nil
+			}
+		}
+	}
+	e, err := p.expression()
+	return exprStatement{e}, err
+}
+
+// printArguments parses the arguments of print, which can be given with or
+// without parentheses, like print x, y or print(x, y).
+func (p *exprParser) printArguments() ([]expr, error) {
+	if p.atEndOfStatement() {
+		return nil, nil
+	}
+	// The parentheses belong to the first argument in print (a + b) * 2.
+	if p.is("(") && p.closing(p.i)+1 == p.statementEnd() {
+		p.i++
+		return p.arguments()
+	}
+	var arguments []expr
+	for {
+		argument, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		arguments = append(arguments, argument)
+		if !p.is(",") {
+			return arguments, nil
+		}
+		p.i++
+	}
+}
+
+// closing returns the index of the parenthesis that closes the one at i, or
+// -1 if it is not closed.
+func (p *exprParser) closing(i int) int {
+	depth := 0
+	for ; i < len(p.tokens); i++ {
+		switch {
+		case p.tokens[i].kind != "operator":
+		case p.tokens[i].text == "(":
+			depth++
+		case p.tokens[i].text == ")":
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// statementEnd returns the index of the token that ends the current
+// statement.
+func (p *exprParser) statementEnd() int {
+	i := p.i
+	for i < len(p.tokens) && p.tokens[i].kind != "separator" {
+		i++
+	}
+	return i
+}
+
+// arguments parses the arguments of a call up to its closing parenthesis,
+// the opening one having been read already.
+func (p *exprParser) arguments() ([]expr, error) {
+	var arguments []expr
+	if p.is(")") {
+		p.i++
+		return arguments, nil
+	}
+	for {
+		argument, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		arguments = append(arguments, argument)
+		if p.is(")") {
+			p.i++
+			return arguments, nil
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// precedences are the binary operators from the loosest to the tightest
+// binding. Negation with ! and not binds looser than comparisons, so that
+// not a == b negates the comparison.
+var precedences = [][]string{
+	{"||", "or"},
+	{"&&", "and"},
+	nil,
+	{"==", "!=", "<", "<=", ">", ">="},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+func (p *exprParser) expression() (expr, error) {
+	return p.binary(0)
+}
+
+func (p *exprParser) binary(level int) (expr, error) {
+	if level == len(precedences) {
+		return p.unary()
+	}
+	if precedences[level] == nil {
+		if p.is("!") || p.is("not") {
+			p.i++
+			operand, err := p.binary(level)
+			return unary{"!", operand}, err
+		}
+		return p.binary(level + 1)
+	}
+
+	left, err := p.binary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		operator := ""
+		for _, o := range precedences[level] {
+			if p.is(o) {
+				operator = o
+			}
+		}
+		if operator == "" {
+			return left, nil
+		}
+		p.i++
+		right, err := p.binary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = binary{normalizeOperator(operator), left, right}
+	}
+}
+
+// normalizeOperator returns the symbol of an operator that is written as a
+// word.
+func normalizeOperator(operator string) string {
+	switch operator {
+	case "and":
+		return "&&"
+	case "or":
+		return "||"
+	}
+	return operator
+}
+
+func (p *exprParser) unary() (expr, error) {
+	if p.is("-") || p.is("+") {
+		operator := p.next().text
+		p.i++
+		operand, err := p.unary()
+		return unary{operator, operand}, err
+	}
+	return p.primary()
+}
+
+func (p *exprParser) primary() (expr, error) {
+	t := p.next()
+	switch t.kind {
+	case "int", "float", "string", "bool":
+		p.i++
+		return literal{t.value}, nil
+	case "identifier":
+		p.i++
+		if p.is("(") {
+			p.i++
+			arguments, err := p.arguments()
+			return callExpr{t.text, arguments}, err
+		}
+		return variable{t.text}, nil
+	}
+	if p.is("(") {
+		p.i++
+		e, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		return e, p.expect(")")
+	}
+	return nil, p.unexpected()
+}
+
+// parseStatements parses the statements of the text of an instruction or a
+// call. Empty text has no statements.
+func parseStatements(tokens []exprToken) ([]statement, error) {
+	p := &exprParser{tokens: tokens}
+	var statements []statement
+	for {
+		for p.next().kind == "separator" {
+			p.i++
+		}
+		if p.next().kind == "end" {
+			return statements, nil
+		}
+		s, err := p.statement()
+		if err != nil {
+			return nil, err
+		}
+		statements = append(statements, s)
+		if !p.atEndOfStatement() {
+			return nil, p.unexpected()
+		}
+	}
+}
+
+// parseExpressions parses a list of expressions separated by commas, like
+// the values of a case. Empty text is an error.
+func parseExpressions(tokens []exprToken) ([]expr, error) {
+	p := &exprParser{tokens: tokens}
+	var expressions []expr
+	for {
+		e, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		expressions = append(expressions, e)
+		if p.next().kind == "end" {
+			return expressions, nil
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// parseExpression parses a single expression, like a condition.
+func parseExpression(tokens []exprToken) (expr, error) {
+	p := &exprParser{tokens: tokens}
+	e, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	if p.next().kind != "end" {
+		return nil, p.unexpected()
+	}
+	return e, nil
+}
+
+// splitClauses splits tokens at its semicolons.
+func splitClauses(tokens []exprToken) [][]exprToken {
+	clauses := [][]exprToken{nil}
+	for _, t := range tokens {
+		if t.kind == "separator" && t.text == ";" {
+			clauses = append(clauses, nil)
+			continue
+		}
+		clauses[len(clauses)-1] = append(clauses[len(clauses)-1], t)
+	}
+	return clauses
+}
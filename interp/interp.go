@@ -0,0 +1,717 @@
+// Package interp runs structograms, so that it can be checked whether they
+// compute what they are supposed to.
+//
+// The values of instructions, calls and conditions are read as a small
+// language with int, float, string and bool values. An instruction or a call
+// consists of statements separated by semicolons or line breaks, which are
+// assignments like x = 1, x += 2 or x++, print statements like print x, y,
+// or expressions. Expressions combine values with the arithmetic operators
+// + - * / %, the comparisons == != < <= > >= and the logical operators &&
+// (and), || (or) and ! (not), and call other structograms by their name,
+// see project.CalledName. Variables are created by assigning to them, and
+// are local to the structogram they are assigned in.
+package interp
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/JSchrtke/structogen/project"
+	"github.com/JSchrtke/structogen/syntax"
+)
+
+// maxDepth is the number of nested calls at which a run stops, as the
+// structograms most likely call each other endlessly.
+const maxDepth = 1000
+
+// Machine runs structograms. The statements run like their counterparts in
+// most programming languages: a for loop has either three clauses, like
+// "i = 0; i < 10; i++", or only a condition, like a while loop, a switch
+// runs the first case with a value equal to its own, where a case can list
+// several values separated by commas, and the branches of a parallel run
+// one after another. Errors that occur while a try runs, like a division by
+// zero, are handled by its first catch, which assigns the message to the
+// variable at the end of its value, like e in "Exception e".
+type Machine struct {
+	// Output receives the output of print statements. If it is nil, the
+	// output is discarded.
+	Output io.Writer
+	// Structograms are the structograms that can be called.
+	Structograms []*syntax.Structogram
+	// Step is called before a statement runs or a condition is evaluated,
+	// so that a run can be followed step by step. If it returns an error,
+	// the run stops with that error.
+	Step func(Step) error
+	// MaxSteps is the number of steps after which a run stops, so that
+	// endless loops end. Zero means no limit.
+	MaxSteps int
+
+	steps   int
+	stopped bool
+	// compiled caches the parsed text of statements, by its kind and text.
+	compiled map[[2]string]interface{}
+}
+
+// Step is the state of a run before a node runs.
+type Step struct {
+	Structogram *syntax.Structogram
+	Node        *syntax.Node
+	// Depth is the number of calls the structogram is nested in.
+	Depth int
+	// Variables are the variables of the structogram and their values.
+	Variables map[string]interface{}
+}
+
+// String returns the position and the text of the node, followed by the
+// variables in alphabetical order, like 3:5, if "n > 0" | n = 3, s = "a".
+func (s Step) String() string {
+	text := fmt.Sprintf(
+		"%d:%d, %s", s.Node.Start.Line, s.Node.Start.Column, s.Node.NodeType,
+	)
+	if s.Node.Value != "" {
+		text += fmt.Sprintf(" %q", s.Node.Value)
+	}
+
+	var names []string
+	for name := range s.Variables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var variables []string
+	for _, name := range names {
+		variables = append(
+			variables, name+" = "+Quote(s.Variables[name]),
+		)
+	}
+	if len(variables) > 0 {
+		text += " | " + strings.Join(variables, ", ")
+	}
+	return text
+}
+
+// Error is an error that stopped a run, at the node that caused it.
+type Error struct {
+	Structogram *syntax.Structogram
+	Start       syntax.Position
+	End         syntax.Position
+	Message     string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%d:%d, %s", e.Start.Line, e.Start.Column, e.Message)
+}
+
+// exit ends a run, leaving all calls.
+type exit struct {
+	value interface{}
+}
+
+func (exit) Error() string {
+	return "exit"
+}
+
+// flow is the way a body was left.
+type flow int
+
+const (
+	flowNormal flow = iota
+	flowBreak
+	flowContinue
+	flowReturn
+)
+
+// frame is a structogram that is running, along with its variables.
+type frame struct {
+	m           *Machine
+	structogram *syntax.Structogram
+	depth       int
+	variables   map[string]interface{}
+	result      interface{}
+}
+
+// Run runs s with the given arguments, which are assigned to the
+// parameters in its name, like list in "sum(list)". It returns the value of
+// the return or exit statement that ended the run, or nil if there is none.
+func (m *Machine) Run(
+	s *syntax.Structogram, arguments ...interface{},
+) (interface{}, error) {
+	m.steps = 0
+	m.stopped = false
+	result, err := m.call(s, arguments, 0)
+	switch e := err.(type) {
+	case exit:
+		return e.value, nil
+	case evalError:
+		return nil, &Error{
+			Structogram: s, Start: s.NameStart, End: s.NameStart,
+			Message: e.Error(),
+		}
+	}
+	return result, err
+}
+
+// Arguments evaluates a list of expressions separated by commas, like the
+// arguments of a call, which can not refer to variables or structograms.
+func Arguments(text string) ([]interface{}, error) {
+	tokens, err := lex(text)
+	if err != nil || len(tokens) == 0 {
+		return nil, err
+	}
+	expressions, err := parseExpressions(tokens)
+	if err != nil {
+		return nil, err
+	}
+	f := &frame{m: &Machine{}, variables: map[string]interface{}{}}
+	var values []interface{}
+	for _, e := range expressions {
+		v, err := e.eval(f)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+// parameters returns the names of the parameters of a structogram, which
+// are listed in the parentheses of its name, like a and b in "max(a, b)". A
+// parameter can be preceded by its type, like in "max(int a, int b)".
+func parameters(name string) []string {
+	start := strings.Index(name, "(")
+	end := strings.LastIndex(name, ")")
+	if start < 0 || end < start {
+		return nil
+	}
+	var names []string
+	for _, p := range strings.Split(name[start+1:end], ",") {
+		if fields := strings.Fields(p); len(fields) > 0 {
+			names = append(names, fields[len(fields)-1])
+		}
+	}
+	return names
+}
+
+func (m *Machine) call(
+	s *syntax.Structogram, arguments []interface{}, depth int,
+) (interface{}, error) {
+	// These errors are reported at the call.
+	if depth >= maxDepth {
+		return nil, newEvalError("stopped after %d nested calls", maxDepth)
+	}
+	names := parameters(s.Name)
+	if len(names) != len(arguments) {
+		return nil, newEvalError(
+			"%s expects %d arguments, but got %d",
+			project.CalledName(s.Name), len(names), len(arguments),
+		)
+	}
+
+	f := &frame{
+		m: m, structogram: s, depth: depth,
+		variables: map[string]interface{}{},
+	}
+	for i, name := range names {
+		f.variables[name] = arguments[i]
+	}
+	if _, err := f.body(s.Nodes); err != nil {
+		return nil, err
+	}
+	return f.result, nil
+}
+
+// compile parses text as the given kind, "statements", "expression",
+// "expressions" or "clauses", and caches the result.
+func (m *Machine) compile(kind string, text string) (interface{}, error) {
+	key := [2]string{kind, text}
+	if compiled, ok := m.compiled[key]; ok {
+		return compiled, nil
+	}
+	tokens, err := lex(text)
+	if err != nil {
+		return nil, err
+	}
+
+	var compiled interface{}
+	switch kind {
+	case "statements":
+		compiled, err = parseStatements(tokens)
+	case "expression":
+		compiled, err = parseExpression(tokens)
+	case "expressions":
+		compiled, err = parseExpressions(tokens)
+	case "clauses":
+		compiled, err = parseClauses(tokens)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if m.compiled == nil {
+		m.compiled = map[[2]string]interface{}{}
+	}
+	m.compiled[key] = compiled
+	return compiled, nil
+}
+
+// forClauses are the clauses of a for loop. A loop without semicolons only
+// has a condition.
+type forClauses struct {
+	init      []statement
+	condition expr
+	next      []statement
+}
+
+func parseClauses(tokens []exprToken) (forClauses, error) {
+	clauses := splitClauses(tokens)
+	if len(clauses) != 3 {
+		condition, err := parseExpression(tokens)
+		return forClauses{condition: condition}, err
+	}
+	var c forClauses
+	var err error
+	if c.init, err = parseStatements(clauses[0]); err != nil {
+		return c, err
+	}
+	// A for loop without a condition runs until it is left.
+	if len(clauses[1]) > 0 {
+		if c.condition, err = parseExpression(clauses[1]); err != nil {
+			return c, err
+		}
+	}
+	c.next, err = parseStatements(clauses[2])
+	return c, err
+}
+
+// fail turns an error in the text of n into an Error at n. Other errors,
+// like those of called structograms, are returned as they are.
+func (f *frame) fail(n *syntax.Node, err error) error {
+	if _, ok := err.(evalError); !ok {
+		return err
+	}
+	return &Error{
+		Structogram: f.structogram, Start: n.Start, End: n.End,
+		Message: err.Error(),
+	}
+}
+
+// step reports that n is about to run.
+func (f *frame) step(n *syntax.Node) error {
+	f.m.steps++
+	if f.m.MaxSteps > 0 && f.m.steps > f.m.MaxSteps {
+		f.m.stopped = true
+		return &Error{
+			Structogram: f.structogram, Start: n.Start, End: n.End,
+			Message: fmt.Sprintf(
+				"stopped after %d steps, the structogram might run endlessly",
+				f.m.MaxSteps,
+			),
+		}
+	}
+	if f.m.Step == nil {
+		return nil
+	}
+	variables := map[string]interface{}{}
+	for name, value := range f.variables {
+		variables[name] = value
+	}
+	return f.m.Step(Step{
+		Structogram: f.structogram, Node: n, Depth: f.depth,
+		Variables: variables,
+	})
+}
+
+// run runs the statements in the text of n.
+func (f *frame) run(n *syntax.Node, text string) error {
+	compiled, err := f.m.compile("statements", text)
+	if err != nil {
+		return f.fail(n, err)
+	}
+	for _, s := range compiled.([]statement) {
+		if err := s.exec(f); err != nil {
+			return f.fail(n, err)
+		}
+	}
+	return nil
+}
+
+// evaluate evaluates text as an expression of n.
+func (f *frame) evaluate(n *syntax.Node, text string) (interface{}, error) {
+	compiled, err := f.m.compile("expression", text)
+	if err != nil {
+		return nil, f.fail(n, err)
+	}
+	v, err := compiled.(expr).eval(f)
+	return v, f.fail(n, err)
+}
+
+// condition evaluates e, which has to be a bool, as the condition of n.
+func (f *frame) condition(n *syntax.Node, e expr) (bool, error) {
+	if err := f.step(n); err != nil {
+		return false, err
+	}
+	if e == nil {
+		return true, nil
+	}
+	v, err := e.eval(f)
+	if err != nil {
+		return false, f.fail(n, err)
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, f.fail(n, newEvalError(
+			"the condition has to be a bool, but is the %s %s",
+			typeName(v), Quote(v),
+		))
+	}
+	return b, nil
+}
+
+// body runs nodes until one of them leaves the body.
+func (f *frame) body(nodes []syntax.Node) (flow, error) {
+	for i := 0; i < len(nodes); i++ {
+		end := i + 1
+		switch nodes[i].NodeType {
+		case "if":
+			if end < len(nodes) && nodes[end].NodeType == "else" {
+				end++
+			}
+		case "try":
+			for end < len(nodes) && (nodes[end].NodeType == "catch" ||
+				nodes[end].NodeType == "finally") {
+				end++
+			}
+		}
+		fl, err := f.node(&nodes[i], nodes[i+1:end])
+		if err != nil || fl != flowNormal {
+			return fl, err
+		}
+		i = end - 1
+	}
+	return flowNormal, nil
+}
+
+// node runs n along with the nodes that are merged into it: the else of an
+// if, and the catch and finally nodes of a try.
+func (f *frame) node(n *syntax.Node, merged []syntax.Node) (flow, error) {
+	switch n.NodeType {
+	case "instruction", "call":
+		if err := f.step(n); err != nil {
+			return flowNormal, err
+		}
+		return flowNormal, f.run(n, n.Value)
+	case "break", "continue":
+		if err := f.step(n); err != nil {
+			return flowNormal, err
+		}
+		if n.NodeType == "break" {
+			return flowBreak, nil
+		}
+		return flowContinue, nil
+	case "return", "exit":
+		if err := f.step(n); err != nil {
+			return flowNormal, err
+		}
+		var value interface{}
+		if strings.TrimSpace(n.Value) != "" {
+			v, err := f.evaluate(n, n.Value)
+			if err != nil {
+				return flowNormal, err
+			}
+			value = v
+		}
+		if n.NodeType == "exit" {
+			return flowNormal, exit{value}
+		}
+		f.result = value
+		return flowReturn, nil
+	case "if":
+		return f.ifNode(n, merged)
+	case "while", "dowhile", "for":
+		return f.loop(n)
+	case "switch":
+		return f.switchNode(n)
+	case "try":
+		return f.try(n, merged)
+	case "parallel":
+		for _, branch := range n.Nodes {
+			if fl, err := f.body(branch.Nodes); err != nil || fl != flowNormal {
+				return fl, err
+			}
+		}
+	}
+	return flowNormal, nil
+}
+
+func (f *frame) ifNode(n *syntax.Node, merged []syntax.Node) (flow, error) {
+	compiled, err := f.m.compile("expression", n.Value)
+	if err != nil {
+		return flowNormal, f.fail(n, err)
+	}
+	holds, err := f.condition(n, compiled.(expr))
+	if err != nil {
+		return flowNormal, err
+	}
+	if holds {
+		return f.body(n.Nodes)
+	}
+	if len(merged) > 0 {
+		return f.body(merged[0].Nodes)
+	}
+	return flowNormal, nil
+}
+
+// loop runs a while, dowhile or for loop. A break leaves the loop, a
+// continue skips to the next check of its condition.
+func (f *frame) loop(n *syntax.Node) (flow, error) {
+	kind := "expression"
+	if n.NodeType == "for" {
+		kind = "clauses"
+	}
+	compiled, err := f.m.compile(kind, n.Value)
+	if err != nil {
+		return flowNormal, f.fail(n, err)
+	}
+	var clauses forClauses
+	if c, ok := compiled.(forClauses); ok {
+		clauses = c
+	} else {
+		clauses.condition = compiled.(expr)
+	}
+
+	if err := f.exec(n, clauses.init); err != nil {
+		return flowNormal, err
+	}
+	for first := true; ; first = false {
+		if n.NodeType != "dowhile" || !first {
+			holds, err := f.condition(n, clauses.condition)
+			if err != nil || !holds {
+				return flowNormal, err
+			}
+		}
+		fl, err := f.body(n.Nodes)
+		if err != nil || fl == flowReturn {
+			return fl, err
+		}
+		if fl == flowBreak {
+			return flowNormal, nil
+		}
+		if err := f.exec(n, clauses.next); err != nil {
+			return flowNormal, err
+		}
+	}
+}
+
+// exec runs compiled statements of n.
+func (f *frame) exec(n *syntax.Node, statements []statement) error {
+	for _, s := range statements {
+		if err := s.exec(f); err != nil {
+			return f.fail(n, err)
+		}
+	}
+	return nil
+}
+
+// switchNode runs the first case of n whose value equals the value of n,
+// or its default if there is no such case. A break leaves the case.
+func (f *frame) switchNode(n *syntax.Node) (flow, error) {
+	if err := f.step(n); err != nil {
+		return flowNormal, err
+	}
+	value, err := f.evaluate(n, n.Value)
+	if err != nil {
+		return flowNormal, err
+	}
+
+	var matched *syntax.Node
+	for i := range n.Nodes {
+		c := &n.Nodes[i]
+		if c.NodeType == "default" {
+			matched = c
+			continue
+		}
+		compiled, err := f.m.compile("expressions", c.Value)
+		if err != nil {
+			return flowNormal, f.fail(c, err)
+		}
+		found := false
+		for _, e := range compiled.([]expr) {
+			v, err := e.eval(f)
+			if err != nil {
+				return flowNormal, f.fail(c, err)
+			}
+			found = found || equal(value, v)
+		}
+		if found {
+			matched = c
+			break
+		}
+	}
+	if matched == nil {
+		return flowNormal, nil
+	}
+	fl, err := f.body(matched.Nodes)
+	if fl == flowBreak {
+		fl = flowNormal
+	}
+	return fl, err
+}
+
+// try runs the body of n. If it fails, the first catch runs, and the
+// finally runs in any case.
+func (f *frame) try(n *syntax.Node, merged []syntax.Node) (flow, error) {
+	var catch, finally *syntax.Node
+	for i := range merged {
+		if merged[i].NodeType == "finally" {
+			finally = &merged[i]
+		} else if catch == nil {
+			catch = &merged[i]
+		}
+	}
+
+	fl, err := f.body(n.Nodes)
+	if e, ok := err.(*Error); ok && catch != nil && !f.m.stopped {
+		fields := strings.Fields(catch.Value)
+		if len(fields) > 0 {
+			f.variables[fields[len(fields)-1]] = e.Message
+		}
+		fl, err = f.body(catch.Nodes)
+	}
+	if finally != nil {
+		finallyFlow, finallyErr := f.body(finally.Nodes)
+		if finallyErr != nil || finallyFlow != flowNormal {
+			return finallyFlow, finallyErr
+		}
+	}
+	return fl, err
+}
+
+func (a assignment) exec(f *frame) error {
+	value, err := a.value.eval(f)
+	if err != nil {
+		return err
+	}
+	if a.operator != "=" {
+		old, ok := f.variables[a.name]
+		if !ok {
+			return newEvalError("unknown variable %q", a.name)
+		}
+		value, err = evalBinary(a.operator[:1], old, value)
+		if err != nil {
+			return err
+		}
+	}
+	if value == nil {
+		return newEvalError("can not assign nothing to %q", a.name)
+	}
+	f.variables[a.name] = value
+	return nil
+}
+
+func (p printStatement) exec(f *frame) error {
+	var values []string
+	for _, argument := range p.arguments {
+		v, err := argument.eval(f)
+		if err != nil {
+			return err
+		}
+		values = append(values, Format(v))
+	}
+	if f.m.Output == nil {
+		return nil
+	}
+	_, err := fmt.Fprintln(f.m.Output, strings.Join(values, " "))
+	return err
+}
+
+func (s exprStatement) exec(f *frame) error {
+	_, err := s.expr.eval(f)
+	return err
+}
+
+func (l literal) eval(f *frame) (interface{}, error) {
+	return l.value, nil
+}
+
+func (v variable) eval(f *frame) (interface{}, error) {
+	value, ok := f.variables[v.name]
+	if !ok {
+		return nil, newEvalError("unknown variable %q", v.name)
+	}
+	return value, nil
+}
+
+func (u unary) eval(f *frame) (interface{}, error) {
+	v, err := u.operand.eval(f)
+	if err != nil {
+		return nil, err
+	}
+	return evalUnary(u.operator, v)
+}
+
+func (b binary) eval(f *frame) (interface{}, error) {
+	left, err := b.left.eval(f)
+	if err != nil {
+		return nil, err
+	}
+	// && and || only evaluate their right side if it decides the result.
+	if b.operator == "&&" || b.operator == "||" {
+		l, ok := left.(bool)
+		if !ok {
+			return nil, newEvalError(
+				"can not apply %q to %s", b.operator, typeName(left),
+			)
+		}
+		if l == (b.operator == "||") {
+			return l, nil
+		}
+		right, err := b.right.eval(f)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := right.(bool); !ok {
+			return nil, newEvalError(
+				"can not apply %q to %s", b.operator, typeName(right),
+			)
+		}
+		return right, nil
+	}
+
+	right, err := b.right.eval(f)
+	if err != nil {
+		return nil, err
+	}
+	if left == nil || right == nil {
+		return nil, newEvalError("can not use nothing as a value")
+	}
+	return evalBinary(b.operator, left, right)
+}
+
+// eval calls print, or the structogram with the name of the call.
+func (c callExpr) eval(f *frame) (interface{}, error) {
+	if c.name == "print" {
+		return nil, printStatement{c.arguments}.exec(f)
+	}
+	var arguments []interface{}
+	for _, argument := range c.arguments {
+		v, err := argument.eval(f)
+		if err != nil {
+			return nil, err
+		}
+		arguments = append(arguments, v)
+	}
+
+	var definitions []*syntax.Structogram
+	for _, s := range f.m.Structograms {
+		if project.CalledName(s.Name) == c.name {
+			definitions = append(definitions, s)
+		}
+	}
+	switch len(definitions) {
+	case 0:
+		return nil, newEvalError("call of unknown structogram %q", c.name)
+	case 1:
+		return f.m.call(definitions[0], arguments, f.depth+1)
+	}
+	return nil, newEvalError("call of %q is ambiguous", c.name)
+}
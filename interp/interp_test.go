@@ -0,0 +1,188 @@
+package interp
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/JSchrtke/structogen/syntax"
+)
+
+func checkOk(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("Did not expect any errors, but got %s", err.Error())
+	}
+}
+
+// runForTest runs the first structogram of source and returns what it
+// printed and returned.
+func runForTest(
+	t *testing.T, source string, arguments ...interface{},
+) (string, interface{}, error) {
+	t.Helper()
+	structograms, err := syntax.ParseAll(strings.NewReader(source))
+	checkOk(t, err)
+	var output strings.Builder
+	m := &Machine{Output: &output, Structograms: structograms, MaxSteps: 10000}
+	result, err := m.Run(structograms[0], arguments...)
+	return output.String(), result, err
+}
+
+func checkOutput(t *testing.T, actual string, expected string) {
+	t.Helper()
+	if actual != expected {
+		t.Errorf("Expected output:\n%s\nbut got:\n%s", expected, actual)
+	}
+}
+
+func checkError(t *testing.T, err error, expected string) {
+	t.Helper()
+	if err == nil {
+		t.Fatalf("Expected error %q, but got none", expected)
+	}
+	if err.Error() != expected {
+		t.Errorf("Expected error %q, but got %q", expected, err.Error())
+	}
+}
+
+func TestRunEvaluatesExpressions(t *testing.T) {
+	output, _, err := runForTest(t, `name("main")
+instruction("""
+    a = 7; b = 2
+    print a / b, a % b, a / 2.0, -a + 1
+    print "a" + a, 1.5 * 2, 1e3
+    print a > b && !(a == 7), a >= 7 or false, not a < b
+    print("x", 'y', "\\"z\\"")
+""")`)
+	checkOk(t, err)
+	checkOutput(t, output, `3 1 3.5 -6
+a7 3.0 1000.0
+false true true
+x y "z"
+`)
+}
+
+func TestRunExecutesControlFlow(t *testing.T) {
+	output, result, err := runForTest(t, `name("main")
+instruction("sum = 0")
+for ("i = 0; i < 10; i++") {
+    if ("i % 2 == 1") {
+        continue("")
+    }
+    if ("i > 6") {
+        break("")
+    }
+    instruction("sum += i")
+}
+instruction("n = 0")
+while ("n < 3") {
+    instruction("n++")
+}
+dowhile ("false") {
+    instruction("n *= 10")
+}
+switch ("n") {
+    case ("1, 2") {
+        instruction("print \"small\"")
+    }
+    case ("30") {
+        instruction("print \"thirty\"")
+        break("")
+    }
+    default {
+        instruction("print \"other\"")
+    }
+}
+return("sum")`)
+	checkOk(t, err)
+	checkOutput(t, output, "thirty\n")
+	if result != int64(12) {
+		t.Errorf("Expected 12, but got %v", result)
+	}
+}
+
+func TestRunCallsStructograms(t *testing.T) {
+	output, _, err := runForTest(t, `name("main")
+instruction("print fib(10)")
+call("greet(\"Ada\")")
+
+name("fib(n)")
+if ("n < 2") {
+    return("n")
+}
+return("fib(n - 1) + fib(n - 2)")
+
+name("greet(int name)")
+instruction("print \"hello \" + name")
+exit("")
+instruction("print \"unreachable\"")`)
+	checkOk(t, err)
+	checkOutput(t, output, "55\nhello Ada\n")
+}
+
+func TestRunCatchesErrorsInTry(t *testing.T) {
+	output, _, err := runForTest(t, `name("main")
+try {
+    instruction("x = 1 / 0")
+} catch ("ArithmeticException e") {
+    instruction("print \"caught \" + e")
+} finally {
+    instruction("print \"done\"")
+}`)
+	checkOk(t, err)
+	checkOutput(t, output, "caught division by zero\ndone\n")
+}
+
+func TestRunReportsErrorsAtTheirNode(t *testing.T) {
+	for source, expected := range map[string]string{
+		`name("main")
+if ("x > 0") { instruction("") }`: `2:1, unknown variable "x"`,
+		`name("main")
+while ("1") { instruction("") }`: `2:1, the condition has to be a bool, but is the int 1`,
+		`name("main")
+    instruction("x = ")`: `2:5, unexpected end`,
+		`name("main")
+instruction("x = 1 +* 2")`: `2:1, unexpected "*"`,
+		`name("main") instruction("x = \"a\" - 1")`:       `1:14, can not apply "-" to string and int`,
+		`name("main") call("max(1)")`:                     `1:14, call of unknown structogram "max"`,
+		`name("main") call("f(1)") name("f(a, b)")`:       `1:14, f expects 2 arguments, but got 1`,
+		`name("main") while ("true") { instruction("") }`: `1:14, stopped after 10000 steps, the structogram might run endlessly`,
+	} {
+		_, _, err := runForTest(t, source)
+		checkError(t, err, expected)
+	}
+}
+
+func TestRunReportsSteps(t *testing.T) {
+	structograms, err := syntax.ParseAll(strings.NewReader(`name("main")
+instruction("x = 1; s = \"a\"")
+while ("x < 3") {
+    instruction("x++")
+}`))
+	checkOk(t, err)
+	var steps []string
+	m := &Machine{Step: func(s Step) error {
+		steps = append(steps, s.String())
+		if len(steps) == 4 {
+			return fmt.Errorf("stop")
+		}
+		return nil
+	}}
+	_, err = m.Run(structograms[0])
+	checkError(t, err, "stop")
+	checkOutput(t, strings.Join(steps, "\n"), `2:1, instruction "x = 1; s = \"a\""
+3:1, while "x < 3" | s = "a", x = 1
+4:5, instruction "x++" | s = "a", x = 1
+3:1, while "x < 3" | s = "a", x = 2`)
+}
+
+func TestArgumentsEvaluatesConstants(t *testing.T) {
+	values, err := Arguments(`3, "a", -1.5, 2 > 1`)
+	checkOk(t, err)
+	if fmt.Sprint(values) != "[3 a -1.5 true]" {
+		t.Errorf("Wrong arguments %v", values)
+	}
+	_, err = Arguments("x")
+	checkError(t, err, `unknown variable "x"`)
+}
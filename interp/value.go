@@ -0,0 +1,213 @@
+package interp
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Values are int64, float64, string or bool. A call of a structogram that
+// does not return a value evaluates to nil.
+
+// typeName returns the name of the type of v in the language.
+func typeName(v interface{}) string {
+	switch v.(type) {
+	case int64:
+		return "int"
+	case float64:
+		return "float"
+	case string:
+		return "string"
+	case bool:
+		return "bool"
+	}
+	return "nothing"
+}
+
+// Format returns v as print writes it.
+func Format(v interface{}) string {
+	switch v := v.(type) {
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		s := strconv.FormatFloat(v, 'g', -1, 64)
+		// Floats with an integral value keep a fraction, so that they can
+		// be told apart from ints.
+		if !strings.ContainsAny(s, ".eEnN") {
+			s += ".0"
+		}
+		return s
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return "nothing"
+}
+
+// Quote returns v as it is written in the language, which quotes strings.
+func Quote(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return strconv.Quote(s)
+	}
+	return Format(v)
+}
+
+// toFloat returns the value of a number as a float.
+func toFloat(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+func evalUnary(operator string, v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case int64:
+		if operator == "-" {
+			return -v, nil
+		}
+		if operator == "+" {
+			return v, nil
+		}
+	case float64:
+		if operator == "-" {
+			return -v, nil
+		}
+		if operator == "+" {
+			return v, nil
+		}
+	case bool:
+		if operator == "!" {
+			return !v, nil
+		}
+	}
+	return nil, newEvalError("can not apply %q to %s", operator, typeName(v))
+}
+
+// evalBinary applies an arithmetic or comparison operator. Ints stay ints,
+// unless they are combined with a float, and + concatenates strings with
+// values of any type.
+func evalBinary(operator string, a, b interface{}) (interface{}, error) {
+	switch operator {
+	case "==":
+		return equal(a, b), nil
+	case "!=":
+		return !equal(a, b), nil
+	case "<", "<=", ">", ">=":
+		return compare(operator, a, b)
+	}
+
+	if operator == "+" {
+		_, aIsString := a.(string)
+		_, bIsString := b.(string)
+		if aIsString || bIsString {
+			return Format(a) + Format(b), nil
+		}
+	}
+
+	x, xIsInt := a.(int64)
+	y, yIsInt := b.(int64)
+	if xIsInt && yIsInt {
+		switch operator {
+		case "+":
+			return x + y, nil
+		case "-":
+			return x - y, nil
+		case "*":
+			return x * y, nil
+		case "/", "%":
+			if y == 0 {
+				return nil, newEvalError("division by zero")
+			}
+			if operator == "/" {
+				return x / y, nil
+			}
+			return x % y, nil
+		}
+	}
+	xf, xIsNumber := toFloat(a)
+	yf, yIsNumber := toFloat(b)
+	if xIsNumber && yIsNumber {
+		switch operator {
+		case "+":
+			return xf + yf, nil
+		case "-":
+			return xf - yf, nil
+		case "*":
+			return xf * yf, nil
+		case "/", "%":
+			if yf == 0 {
+				return nil, newEvalError("division by zero")
+			}
+			if operator == "/" {
+				return xf / yf, nil
+			}
+			return math.Mod(xf, yf), nil
+		}
+	}
+	return nil, newEvalError(
+		"can not apply %q to %s and %s", operator, typeName(a), typeName(b),
+	)
+}
+
+// equal reports whether a and b are equal. Numbers are compared by their
+// value, values of different types are never equal.
+func equal(a, b interface{}) bool {
+	x, xIsNumber := toFloat(a)
+	y, yIsNumber := toFloat(b)
+	if xIsNumber && yIsNumber {
+		if xi, ok := a.(int64); ok {
+			if yi, ok := b.(int64); ok {
+				return xi == yi
+			}
+		}
+		return x == y
+	}
+	return a == b
+}
+
+// compare orders two numbers or two strings.
+func compare(operator string, a, b interface{}) (interface{}, error) {
+	var order int
+	x, xIsNumber := toFloat(a)
+	y, yIsNumber := toFloat(b)
+	xs, xIsString := a.(string)
+	ys, yIsString := b.(string)
+	switch {
+	case xIsNumber && yIsNumber:
+		xi, xIsInt := a.(int64)
+		yi, yIsInt := b.(int64)
+		switch {
+		case xIsInt && yIsInt && xi != yi:
+			order = 1
+			if xi < yi {
+				order = -1
+			}
+		case xIsInt && yIsInt:
+		case x < y:
+			order = -1
+		case x > y:
+			order = 1
+		}
+	case xIsString && yIsString:
+		order = strings.Compare(xs, ys)
+	default:
+		return nil, newEvalError(
+			"can not compare %s and %s", typeName(a), typeName(b),
+		)
+	}
+
+	switch operator {
+	case "<":
+		return order < 0, nil
+	case "<=":
+		return order <= 0, nil
+	case ">":
+		return order > 0, nil
+	}
+	return order >= 0, nil
+}
@@ -0,0 +1,68 @@
+package layout
+
+import (
+	"sync"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+// GoRegular measures text in the Go Regular font, which the pdf, png, svg,
+// html and TikZ output set their texts in. Runes that are missing from the
+// font are measured like the glyph that replaces them.
+var GoRegular Measurer = &fontMeasurer{ttf: goregular.TTF}
+
+// fontMeasurer measures text with the advances of the glyphs of a TrueType
+// font. The font has no kerning, so the width of a text is the sum of the
+// widths of its runes, which are cached in em.
+type fontMeasurer struct {
+	ttf    []byte
+	once   sync.Once
+	font   *sfnt.Font
+	mu     sync.Mutex
+	widths map[rune]float64
+}
+
+// Width returns the width of s when set in the font at the given size.
+func (m *fontMeasurer) Width(s string, fontSize float64) float64 {
+	m.once.Do(func() {
+		f, err := sfnt.Parse(m.ttf)
+		if err != nil {
+			panic("layout: can not parse the embedded font: " + err.Error())
+		}
+		m.font = f
+		m.widths = map[rune]float64{}
+	})
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var buf sfnt.Buffer
+	var w float64
+	for _, r := range s {
+		width, ok := m.widths[r]
+		if !ok {
+			width = m.measure(&buf, r)
+			m.widths[r] = width
+		}
+		w += width
+	}
+	return w * fontSize
+}
+
+// measure returns the advance of the glyph of r in em.
+func (m *fontMeasurer) measure(buf *sfnt.Buffer, r rune) float64 {
+	unitsPerEm := m.font.UnitsPerEm()
+	idx, err := m.font.GlyphIndex(buf, r)
+	if err != nil {
+		return 0
+	}
+	advance, err := m.font.GlyphAdvance(
+		buf, idx, fixed.I(int(unitsPerEm)), font.HintingNone,
+	)
+	if err != nil {
+		return 0
+	}
+	return float64(advance) / 64 / float64(unitsPerEm)
+}
@@ -0,0 +1,124 @@
+package layout
+
+// decorateSequence computes the connectors and labels of the boxes, which
+// have to be placed already.
+func (s *Style) decorateSequence(boxes []*Box) {
+	for _, b := range boxes {
+		s.decorate(b)
+		for _, br := range b.Branches {
+			s.decorateSequence(br.Boxes)
+		}
+	}
+}
+
+// lines returns labels for text lines in the box with its top edge at y,
+// either left aligned at x or centered around x. Empty lines are left out.
+func (s *Style) lines(text []string, x float64, y float64, center bool) []Label {
+	anchor := "start"
+	if center {
+		anchor = "middle"
+	}
+	var labels []Label
+	for i, l := range text {
+		if l != "" {
+			labels = append(labels, Label{
+				Text: l, X: x, Y: s.Baseline(y, i), Anchor: anchor,
+			})
+		}
+	}
+	return labels
+}
+
+func (s *Style) decorate(b *Box) {
+	line := func(x1 float64, y1 float64, x2 float64, y2 float64) {
+		b.Connectors = append(b.Connectors, Line{x1, y1, x2, y2})
+	}
+	label := func(labels []Label) {
+		b.Labels = append(b.Labels, labels...)
+	}
+
+	switch b.NodeType {
+	case "instruction":
+		label(s.lines(b.Text, b.X+s.Padding, b.Y, false))
+	case "call":
+		line(b.X+s.CallInset, b.Y, b.X+s.CallInset, b.Y+b.H)
+		line(b.X+b.W-s.CallInset, b.Y, b.X+b.W-s.CallInset, b.Y+b.H)
+		label(s.lines(b.Text, b.X+s.CallInset+s.Padding, b.Y, false))
+	case "break", "continue", "return", "exit":
+		// The exit arrow points to the left, out of the surrounding box.
+		line(b.X+s.ArrowWidth, b.Y, b.X, b.Y+b.H/2)
+		line(b.X, b.Y+b.H/2, b.X+s.ArrowWidth, b.Y+b.H)
+		label(s.lines(b.Text, b.X+s.ArrowWidth+s.Padding, b.Y, false))
+	case "if":
+		split := b.Branches[1].X
+		bottom := b.Y + b.HeaderH
+		line(b.X, b.Y, split, bottom)
+		line(b.X+b.W, b.Y, split, bottom)
+		label(s.lines(b.Text, b.X+b.W/2, b.Y, true))
+		labelY := bottom - s.LineHeight - s.Padding
+		label(s.lines(b.Branches[0].Label, b.X+s.Padding, labelY, false))
+		label([]Label{{
+			Text:   b.Branches[1].Label[0],
+			X:      b.X + b.W - s.Padding,
+			Y:      s.Baseline(labelY, 0),
+			Anchor: "end",
+		}})
+	case "while", "for", "case", "default":
+		label(s.lines(b.Text, b.X+s.Padding, b.Y, false))
+	case "dowhile":
+		label(s.lines(b.Text, b.X+s.Padding, b.Y+b.H-b.HeaderH, false))
+	case "switch":
+		s.decorateSwitch(b)
+	case "try":
+		for i, br := range b.Branches {
+			top := br.Y - s.linesHeight(br.Label)
+			if i > 0 {
+				line(b.X, top, b.X+b.W, top)
+			}
+			label(s.lines(br.Label, b.X+s.Padding, top, false))
+		}
+	case "parallel":
+		// The bands above and below the branches are cut off diagonally in
+		// each corner.
+		top, bottom := b.Y+b.HeaderH, b.Y+b.H-b.HeaderH
+		line(b.X, top, b.X+b.W, top)
+		line(b.X, bottom, b.X+b.W, bottom)
+		line(b.X, b.Y, b.X+b.HeaderH, top)
+		line(b.X+b.W, b.Y, b.X+b.W-b.HeaderH, top)
+		line(b.X, b.Y+b.H, b.X+b.HeaderH, bottom)
+		line(b.X+b.W, b.Y+b.H, b.X+b.W-b.HeaderH, bottom)
+	}
+}
+
+// decorateSwitch computes the header of a switch. A diagonal runs from the
+// top left corner down to the start of the last column, which is the
+// default case, and back up to the top right corner. Each case label is
+// written at the bottom of its column, below the diagonal.
+func (s *Style) decorateSwitch(b *Box) {
+	last := b.Branches[len(b.Branches)-1]
+	bottom := b.Y + b.HeaderH
+	b.Connectors = append(
+		b.Connectors,
+		Line{b.X, b.Y, last.X, bottom},
+		Line{b.X + b.W, b.Y, last.X, bottom},
+	)
+	b.Labels = append(b.Labels, s.lines(b.Text, b.X+b.W/2, b.Y, true)...)
+
+	var labelLines int
+	for _, br := range b.Branches {
+		if len(br.Label) > labelLines {
+			labelLines = len(br.Label)
+		}
+	}
+	labelY := bottom - float64(labelLines)*s.LineHeight - s.Padding
+	for i, br := range b.Branches {
+		if i > 0 && br != last {
+			// The separator starts where it meets the diagonal.
+			top := b.Y + (br.X-b.X)/(last.X-b.X)*b.HeaderH
+			b.Connectors = append(b.Connectors, Line{br.X, top, br.X, bottom})
+		}
+		b.Labels = append(
+			b.Labels, s.lines(br.Label, br.X+br.W/2, labelY, true)...,
+		)
+	}
+}
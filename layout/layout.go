@@ -0,0 +1,448 @@
+// Package layout computes where the boxes, lines and texts of
+// Nassi-Shneiderman diagrams go. It does not draw anything itself, the output
+// formats in package render draw the computed geometry, so that diagrams look
+// the same in all of them.
+package layout
+
+import (
+	"math"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/JSchrtke/structogen/syntax"
+)
+
+// Measurer measures the width of text, in the unit of the layout, when it
+// is set in the font of an output format.
+type Measurer interface {
+	Width(s string, fontSize float64) float64
+}
+
+// MeasurerFunc is a function that implements Measurer.
+type MeasurerFunc func(s string, fontSize float64) float64
+
+// Width returns f(s, fontSize).
+func (f MeasurerFunc) Width(s string, fontSize float64) float64 {
+	return f(s, fontSize)
+}
+
+// Monospace approximates the width of text in monospaced fonts, where every
+// glyph is roughly 0.6em wide.
+var Monospace = MeasurerFunc(func(s string, fontSize float64) float64 {
+	return float64(utf8.RuneCountInString(s)) * fontSize * 0.6
+})
+
+// Style holds the dimensions that are used to lay out a structogram.
+// All values are in the same unit, e.g. points for DefaultStyle.
+type Style struct {
+	FontSize     float64
+	LineHeight   float64
+	Padding      float64
+	Margin       float64
+	LoopIndent   float64
+	CallInset    float64
+	ArrowWidth   float64
+	MinWidth     float64
+	MaxTextWidth float64
+	Measurer     Measurer
+	// BandHeight is the height of the bands above and below the branches
+	// of a parallel, or LineHeight if it is zero.
+	BandHeight float64
+}
+
+// Diagram is a structogram that has been laid out. The boxes are positioned
+// relative to the top left corner of the diagram, including the margin.
+type Diagram struct {
+	Title []string
+	// TitleLabels are the lines of the title, positioned above the frame.
+	TitleLabels []Label
+	Width       float64
+	Height      float64
+	// FrameY is the top edge of the first box.
+	FrameY float64
+	Body   []*Box
+}
+
+// Box is a single laid out node. Compound nodes have a header (or footer,
+// in case of dowhile), containing the condition, and one or more branches.
+//
+// A box is drawn as its outline, the outlines of its framed branches, its
+// connectors and its labels, followed by the boxes in its branches.
+type Box struct {
+	NodeType string
+	// Value is the value of the node, Text is the same wrapped into lines.
+	Value string
+	Text  []string
+	// Start and End span the source of the node and the nodes merged into
+	// it, like the else of an if.
+	Start   syntax.Position
+	End     syntax.Position
+	X, Y    float64
+	W, H    float64
+	HeaderH float64
+	// Connectors are the lines inside of the box, like the diagonals of an
+	// if or the arrow of a jump.
+	Connectors []Line
+	Labels     []Label
+	Branches   []*Branch
+	minW       float64
+}
+
+// Branch is a column of boxes inside of a compound box, e.g. the true side
+// of an if, the body of a loop or a single case of a switch.
+type Branch struct {
+	Label []string
+	X, Y  float64
+	W, H  float64
+	// Framed is set if the branch is drawn with an outline of its own, which
+	// separates it from the branches next to it.
+	Framed bool
+	Boxes  []*Box
+	minW   float64
+}
+
+// Line is a straight line from X1, Y1 to X2, Y2.
+type Line struct {
+	X1, Y1, X2, Y2 float64
+}
+
+// Label is a line of text with its baseline at Y. Anchor is one of "start",
+// "middle" or "end" and determines where X is relative to the text.
+type Label struct {
+	Text   string
+	X, Y   float64
+	Anchor string
+	Bold   bool
+}
+
+// DefaultStyle returns the style that all output formats except text use, in
+// points, for texts set in Go Regular. The formats scale the diagram to
+// their own units, so that it has the same proportions in all of them.
+func DefaultStyle() Style {
+	return Style{
+		FontSize:     10,
+		LineHeight:   14,
+		Padding:      4,
+		Margin:       4,
+		LoopIndent:   18,
+		CallInset:    6,
+		ArrowWidth:   9,
+		MinWidth:     30,
+		MaxTextWidth: 240,
+		Measurer:     GoRegular,
+	}
+}
+
+// Compute lays out s in the given style.
+func Compute(s *syntax.Structogram, style Style) *Diagram {
+	d := &Diagram{}
+	d.Title = style.wrap(s.Name)
+	for i, l := range d.Title {
+		d.TitleLabels = append(d.TitleLabels, Label{
+			Text:   l,
+			X:      style.Margin,
+			Y:      style.Baseline(style.Margin, i) - style.Padding,
+			Anchor: "start",
+			Bold:   true,
+		})
+	}
+	d.FrameY = style.Margin + float64(len(d.Title))*style.LineHeight +
+		style.Padding
+	d.Body = style.buildSequence(s.Nodes)
+
+	width := style.sequenceMinWidth(d.Body)
+	for _, l := range d.Title {
+		width = math.Max(width, style.textWidth(l))
+	}
+	height := style.placeSequence(d.Body, style.Margin, d.FrameY, width)
+	style.decorateSequence(d.Body)
+
+	d.Width = width + 2*style.Margin
+	d.Height = d.FrameY + height + style.Margin
+	return d
+}
+
+func (s *Style) textWidth(line string) float64 {
+	return s.Measurer.Width(line, s.FontSize)
+}
+
+func (s *Style) linesWidth(lines []string) float64 {
+	var w float64
+	for _, l := range lines {
+		w = math.Max(w, s.textWidth(l))
+	}
+	return w
+}
+
+func (s *Style) linesHeight(lines []string) float64 {
+	return float64(len(lines))*s.LineHeight + 2*s.Padding
+}
+
+// wrap splits text into lines that are at most MaxTextWidth wide. Explicit
+// line breaks and the indentation of each line are kept, words that are too
+// long on their own get a line of their own.
+func (s *Style) wrap(text string) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(text, "\n") {
+		words := strings.Fields(paragraph)
+		if len(words) == 0 {
+			lines = append(lines, "")
+			continue
+		}
+		trimmed := strings.TrimLeft(paragraph, " \t")
+		indent := paragraph[:len(paragraph)-len(trimmed)]
+		line := strings.ReplaceAll(indent, "\t", "    ") + words[0]
+		for _, word := range words[1:] {
+			if s.textWidth(line+" "+word) > s.MaxTextWidth {
+				lines = append(lines, line)
+				line = word
+			} else {
+				line += " " + word
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// buildSequence turns nodes into boxes and computes their minimum widths.
+// An else node is merged into the if node it belongs to, catch and finally
+// nodes are merged into their try node.
+func (s *Style) buildSequence(nodes []syntax.Node) []*Box {
+	var boxes []*Box
+	for i := 0; i < len(nodes); i++ {
+		n := nodes[i]
+		end := i + 1
+		switch n.NodeType {
+		case "if":
+			if end < len(nodes) && nodes[end].NodeType == "else" {
+				end++
+			}
+		case "try":
+			for end < len(nodes) && (nodes[end].NodeType == "catch" ||
+				nodes[end].NodeType == "finally") {
+				end++
+			}
+		}
+		boxes = append(boxes, s.buildBox(n, nodes[i+1:end]))
+		i = end - 1
+	}
+	return boxes
+}
+
+func (s *Style) buildBranch(label string, nodes []syntax.Node) *Branch {
+	br := &Branch{Boxes: s.buildSequence(nodes)}
+	if label != "" {
+		br.Label = s.wrap(label)
+	}
+	br.minW = math.Max(
+		s.sequenceMinWidth(br.Boxes),
+		s.linesWidth(br.Label)+2*s.Padding,
+	)
+	return br
+}
+
+// buildBox lays out n along with the nodes that are merged into it.
+func (s *Style) buildBox(n syntax.Node, merged []syntax.Node) *Box {
+	b := &Box{
+		NodeType: n.NodeType, Value: n.Value, Text: s.wrap(n.Value),
+		Start: n.Start, End: n.End,
+	}
+	if len(merged) > 0 {
+		b.End = merged[len(merged)-1].End
+	}
+	textW := s.linesWidth(b.Text) + 2*s.Padding
+
+	switch n.NodeType {
+	case "if":
+		var elseBody []syntax.Node
+		if len(merged) > 0 {
+			elseBody = merged[0].Nodes
+		}
+		b.Branches = []*Branch{
+			s.buildBranch("true", n.Nodes),
+			s.buildBranch("false", elseBody),
+		}
+		b.HeaderH = s.linesHeight(b.Text) + s.LineHeight
+		b.minW = math.Max(textW, b.Branches[0].minW+b.Branches[1].minW)
+	case "while", "for", "dowhile":
+		b.Branches = []*Branch{s.buildBranch("", n.Nodes)}
+		b.HeaderH = s.linesHeight(b.Text)
+		b.minW = math.Max(textW, s.LoopIndent+b.Branches[0].minW)
+	case "switch":
+		var labelLines int
+		for _, c := range n.Nodes {
+			label := c.Value
+			if c.NodeType == "default" {
+				label = "default"
+			}
+			br := s.buildBranch(label, c.Nodes)
+			if len(br.Label) > labelLines {
+				labelLines = len(br.Label)
+			}
+			b.Branches = append(b.Branches, br)
+		}
+		var columnsW float64
+		for _, br := range b.Branches {
+			columnsW += br.minW
+		}
+		b.HeaderH = s.linesHeight(b.Text) +
+			float64(labelLines)*s.LineHeight
+		b.minW = math.Max(textW, columnsW)
+	case "try":
+		// Every section gets a header and an indented body, like a loop.
+		// The sections are stacked on top of each other.
+		b.Text = nil
+		b.Branches = []*Branch{s.buildBranch("try", n.Nodes)}
+		for _, handler := range merged {
+			label := handler.NodeType
+			if handler.Value != "" {
+				label += " " + handler.Value
+			}
+			b.Branches = append(b.Branches, s.buildBranch(label, handler.Nodes))
+		}
+		for _, br := range b.Branches {
+			b.minW = math.Max(b.minW, s.LoopIndent+br.minW)
+		}
+	case "parallel":
+		var columnsW float64
+		for _, br := range n.Nodes {
+			b.Branches = append(b.Branches, s.buildBranch("", br.Nodes))
+			columnsW += b.Branches[len(b.Branches)-1].minW
+		}
+		// The bands above and below the branches have no text.
+		b.Text = nil
+		b.HeaderH = s.LineHeight
+		if s.BandHeight > 0 {
+			b.HeaderH = s.BandHeight
+		}
+		b.minW = math.Max(columnsW, 4*b.HeaderH)
+	case "case", "default":
+		// A case outside of a switch body is drawn as a labeled box with
+		// its body underneath.
+		if n.NodeType == "default" {
+			b.Text = []string{"default"}
+			textW = s.linesWidth(b.Text) + 2*s.Padding
+		}
+		b.Branches = []*Branch{s.buildBranch("", n.Nodes)}
+		b.HeaderH = s.linesHeight(b.Text)
+		b.minW = math.Max(textW, b.Branches[0].minW)
+	case "call":
+		b.minW = textW + 2*s.CallInset
+	case "break", "continue", "return", "exit":
+		// The keyword is part of the text, as the shape is the same for
+		// all jumps.
+		text := n.NodeType
+		if n.Value != "" {
+			text += " " + n.Value
+		}
+		b.Text = s.wrap(text)
+		b.minW = s.linesWidth(b.Text) + 2*s.Padding + s.ArrowWidth
+	default:
+		b.minW = textW
+	}
+	switch n.NodeType {
+	case "if", "switch", "parallel":
+		// The branches are side by side.
+		for _, br := range b.Branches {
+			br.Framed = true
+		}
+	}
+	b.minW = math.Max(b.minW, s.MinWidth)
+	return b
+}
+
+func (s *Style) sequenceMinWidth(boxes []*Box) float64 {
+	w := s.MinWidth
+	for _, b := range boxes {
+		w = math.Max(w, b.minW)
+	}
+	return w
+}
+
+// placeSequence positions boxes below each other, starting at x and y, and
+// stretches them to width w. It returns the height of the sequence.
+func (s *Style) placeSequence(
+	boxes []*Box, x float64, y float64, w float64,
+) float64 {
+	if len(boxes) == 0 {
+		return s.linesHeight([]string{""})
+	}
+	top := y
+	for _, b := range boxes {
+		s.placeBox(b, x, y, w)
+		y += b.H
+	}
+	return y - top
+}
+
+// placeBranches positions branches next to each other, distributing any
+// width beyond their minimum width proportionally. All branches get the
+// height of the tallest one.
+func (s *Style) placeBranches(
+	branches []*Branch, x float64, y float64, w float64,
+) float64 {
+	var minW float64
+	for _, br := range branches {
+		minW += br.minW
+	}
+	var h float64
+	for i, br := range branches {
+		br.X = x
+		br.Y = y
+		br.W = br.minW * w / minW
+		if i == len(branches)-1 {
+			// Avoid gaps due to rounding errors.
+			br.W = w - (x - branches[0].X)
+		}
+		br.H = s.placeSequence(br.Boxes, br.X, br.Y, br.W)
+		h = math.Max(h, br.H)
+		x += br.W
+	}
+	for _, br := range branches {
+		br.H = h
+	}
+	return h
+}
+
+func (s *Style) placeBox(b *Box, x float64, y float64, w float64) {
+	b.X = x
+	b.Y = y
+	b.W = w
+
+	switch b.NodeType {
+	case "if", "switch":
+		b.H = b.HeaderH + s.placeBranches(b.Branches, x, y+b.HeaderH, w)
+	case "while", "for":
+		b.H = b.HeaderH + s.placeBranches(
+			b.Branches, x+s.LoopIndent, y+b.HeaderH, w-s.LoopIndent,
+		)
+	case "dowhile":
+		b.H = s.placeBranches(
+			b.Branches, x+s.LoopIndent, y, w-s.LoopIndent,
+		) + b.HeaderH
+	case "case", "default":
+		b.H = b.HeaderH + s.placeBranches(b.Branches, x, y+b.HeaderH, w)
+	case "parallel":
+		b.H = 2*b.HeaderH + s.placeBranches(b.Branches, x, y+b.HeaderH, w)
+	case "try":
+		for _, br := range b.Branches {
+			y += s.linesHeight(br.Label)
+			br.X = x + s.LoopIndent
+			br.Y = y
+			br.W = w - s.LoopIndent
+			br.H = s.placeSequence(br.Boxes, br.X, br.Y, br.W)
+			y += br.H
+		}
+		b.H = y - b.Y
+	default:
+		b.H = s.linesHeight(b.Text)
+	}
+}
+
+// Baseline returns the y coordinate of the baseline of the i-th line of text
+// in a box whose top edge is at y.
+func (s *Style) Baseline(y float64, i int) float64 {
+	return y + s.Padding + float64(i)*s.LineHeight +
+		(s.LineHeight-s.FontSize)/2 + 0.8*s.FontSize
+}
@@ -0,0 +1,289 @@
+package layout
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/JSchrtke/structogen/syntax"
+)
+
+func checkOk(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Errorf("Did not expect any errors, but got %s", err.Error())
+	}
+}
+
+func parseForLayout(t *testing.T, s string) *syntax.Structogram {
+	t.Helper()
+	structogram, err := syntax.Parse(strings.NewReader(s))
+	checkOk(t, err)
+	return structogram
+}
+
+func checkFloat(t *testing.T, name string, actual float64, expected float64) {
+	t.Helper()
+	if actual != expected {
+		t.Errorf("Wrong %s, expected %v, but got %v", name, expected, actual)
+	}
+}
+
+func TestLayoutStacksInstructions(t *testing.T) {
+	style := DefaultStyle()
+	s := parseForLayout(t, `name("a") instruction("b") instruction("c")`)
+	d := Compute(s, style)
+
+	if len(d.Body) != 2 {
+		t.Fatalf("Expected 2 blocks, but got %d", len(d.Body))
+	}
+	first := d.Body[0]
+	second := d.Body[1]
+	checkFloat(t, "x", first.X, style.Margin)
+	checkFloat(t, "y", first.Y, d.FrameY)
+	checkFloat(t, "y", second.Y, first.Y+first.H)
+	checkFloat(t, "width", first.W, second.W)
+	checkFloat(t, "height", d.Height, second.Y+second.H+style.Margin)
+}
+
+func TestLayoutMergesElseIntoIf(t *testing.T) {
+	s := parseForLayout(
+		t,
+		`name("a") if("b") {instruction("c")} else {instruction("d")}`,
+	)
+	d := Compute(s, DefaultStyle())
+
+	if len(d.Body) != 1 {
+		t.Fatalf("Expected 1 block, but got %d", len(d.Body))
+	}
+	ifBlock := d.Body[0]
+	if len(ifBlock.Branches) != 2 {
+		t.Fatalf("Expected 2 branches, but got %d", len(ifBlock.Branches))
+	}
+	trueBranch := ifBlock.Branches[0]
+	falseBranch := ifBlock.Branches[1]
+	checkFloat(t, "x", falseBranch.X, trueBranch.X+trueBranch.W)
+	checkFloat(t, "width", trueBranch.W+falseBranch.W, ifBlock.W)
+	checkFloat(t, "height", ifBlock.H, ifBlock.HeaderH+trueBranch.H)
+	if falseBranch.Boxes[0].Text[0] != "d" {
+		t.Errorf("Expected else body in false branch")
+	}
+}
+
+func TestLayoutIndentsLoopBodies(t *testing.T) {
+	style := DefaultStyle()
+	s := parseForLayout(
+		t,
+		`name("a") while("b") {instruction("c")} dowhile("d") {call("e")}`,
+	)
+	d := Compute(s, style)
+
+	whileBlock := d.Body[0]
+	body := whileBlock.Branches[0].Boxes[0]
+	checkFloat(t, "x", body.X, whileBlock.X+style.LoopIndent)
+	checkFloat(t, "y", body.Y, whileBlock.Y+whileBlock.HeaderH)
+
+	doWhileBlock := d.Body[1]
+	body = doWhileBlock.Branches[0].Boxes[0]
+	checkFloat(t, "x", body.X, doWhileBlock.X+style.LoopIndent)
+	checkFloat(t, "y", body.Y, doWhileBlock.Y)
+	checkFloat(
+		t, "height", doWhileBlock.H, body.H+doWhileBlock.HeaderH,
+	)
+}
+
+func TestLayoutGivesEachCaseAColumn(t *testing.T) {
+	s := parseForLayout(
+		t,
+		`name("a") switch("b") {
+			case("c") {instruction("d")}
+			case("e") {instruction("f")}
+			default {instruction("g")}
+		}`,
+	)
+	d := Compute(s, DefaultStyle())
+
+	switchBlock := d.Body[0]
+	if len(switchBlock.Branches) != 3 {
+		t.Fatalf("Expected 3 branches, but got %d", len(switchBlock.Branches))
+	}
+	var w float64
+	for _, br := range switchBlock.Branches {
+		checkFloat(t, "x", br.X, switchBlock.X+w)
+		w += br.W
+	}
+	checkFloat(t, "width", w, switchBlock.W)
+	if switchBlock.Branches[2].Label[0] != "default" {
+		t.Errorf(
+			"Expected default label, but got %s",
+			switchBlock.Branches[2].Label[0],
+		)
+	}
+}
+
+func TestLayoutWrapsLongText(t *testing.T) {
+	style := DefaultStyle()
+	style.MaxTextWidth = style.textWidth("aaa bbb")
+
+	lines := style.wrap("aaa bbb ccc\nddd")
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 lines, but got %d", len(lines))
+	}
+	if lines[0] != "aaa bbb" || lines[1] != "ccc" || lines[2] != "ddd" {
+		t.Errorf("Wrong lines, got %q", lines)
+	}
+}
+
+func TestLayoutKeepsIndentationOfLines(t *testing.T) {
+	style := DefaultStyle()
+	lines := style.wrap("if a:\n    b\n\tc")
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 lines, but got %d", len(lines))
+	}
+	if lines[1] != "    b" || lines[2] != "    c" {
+		t.Errorf("Wrong lines, got %q", lines)
+	}
+}
+
+func TestLayoutPrefixesJumpsWithTheirKeyword(t *testing.T) {
+	style := DefaultStyle()
+	s := parseForLayout(t, `name("a") return("result") exit("")`)
+	d := Compute(s, style)
+
+	returnBlock := d.Body[0]
+	if len(returnBlock.Text) != 1 || returnBlock.Text[0] != "return result" {
+		t.Errorf("Expected the line 'return result', but got %v", returnBlock.Text)
+	}
+	checkFloat(
+		t, "minimum width", returnBlock.minW,
+		style.textWidth("return result")+2*style.Padding+style.ArrowWidth,
+	)
+	if exitBlock := d.Body[1]; exitBlock.Text[0] != "exit" {
+		t.Errorf("Expected the line 'exit', but got %v", exitBlock.Text)
+	}
+}
+
+func TestLayoutPutsParallelBranchesSideBySide(t *testing.T) {
+	style := DefaultStyle()
+	s := parseForLayout(
+		t,
+		`name("a") parallel {
+			branch {instruction("b") instruction("c")}
+			branch {instruction("d")}
+		}`,
+	)
+	d := Compute(s, style)
+
+	parallelBlock := d.Body[0]
+	if len(parallelBlock.Branches) != 2 {
+		t.Fatalf("Expected 2 branches, but got %d", len(parallelBlock.Branches))
+	}
+	first, second := parallelBlock.Branches[0], parallelBlock.Branches[1]
+	checkFloat(t, "x", first.X, parallelBlock.X)
+	checkFloat(t, "x", second.X, first.X+first.W)
+	checkFloat(t, "y", first.Y, parallelBlock.Y+parallelBlock.HeaderH)
+	checkFloat(t, "height", second.H, first.H)
+	checkFloat(
+		t, "height", parallelBlock.H, first.H+2*parallelBlock.HeaderH,
+	)
+}
+
+func TestLayoutStacksTryCatchAndFinally(t *testing.T) {
+	style := DefaultStyle()
+	s := parseForLayout(
+		t,
+		`name("a")
+		try {instruction("b")}
+		catch("E e") {instruction("c") instruction("d")}
+		finally {instruction("e")}`,
+	)
+	d := Compute(s, style)
+
+	if len(d.Body) != 1 {
+		t.Fatalf("Expected 1 block, but got %d", len(d.Body))
+	}
+	tryBlock := d.Body[0]
+	if len(tryBlock.Branches) != 3 {
+		t.Fatalf("Expected 3 sections, but got %d", len(tryBlock.Branches))
+	}
+	labels := []string{"try", "catch E e", "finally"}
+	y := tryBlock.Y
+	for i, br := range tryBlock.Branches {
+		if br.Label[0] != labels[i] {
+			t.Errorf("Expected label %s, but got %s", labels[i], br.Label[0])
+		}
+		y += style.linesHeight(br.Label)
+		checkFloat(t, "x", br.X, tryBlock.X+style.LoopIndent)
+		checkFloat(t, "y", br.Y, y)
+		y += br.H
+	}
+	checkFloat(t, "height", tryBlock.H, y-tryBlock.Y)
+}
+
+func TestLayoutMeasuresTextWithTheMeasurer(t *testing.T) {
+	style := DefaultStyle()
+	s := parseForLayout(t, `name("a") instruction("bbbb")`)
+	narrow := Compute(s, style)
+
+	style.Measurer = MeasurerFunc(func(s string, fontSize float64) float64 {
+		return float64(len(s)) * fontSize * 10
+	})
+	wide := Compute(s, style)
+
+	checkFloat(
+		t, "width", wide.Body[0].W, 4*style.FontSize*10+2*style.Padding,
+	)
+	if wide.Width <= narrow.Width {
+		t.Errorf(
+			"Expected wider diagram than %v, but got %v",
+			narrow.Width, wide.Width,
+		)
+	}
+}
+
+func TestLayoutConnectsTheConditionOfIfToTheSplit(t *testing.T) {
+	s := parseForLayout(
+		t,
+		`name("a") if("b") {instruction("c")} else {instruction("d")}`,
+	)
+	d := Compute(s, DefaultStyle())
+
+	ifBox := d.Body[0]
+	split := ifBox.Branches[1].X
+	bottom := ifBox.Y + ifBox.HeaderH
+	expected := []Line{
+		{ifBox.X, ifBox.Y, split, bottom},
+		{ifBox.X + ifBox.W, ifBox.Y, split, bottom},
+	}
+	if len(ifBox.Connectors) != len(expected) {
+		t.Fatalf(
+			"Expected %d connectors, but got %d",
+			len(expected), len(ifBox.Connectors),
+		)
+	}
+	for i, l := range expected {
+		if ifBox.Connectors[i] != l {
+			t.Errorf(
+				"Wrong connector, expected %v, but got %v",
+				l, ifBox.Connectors[i],
+			)
+		}
+	}
+
+	var texts []string
+	for _, l := range ifBox.Labels {
+		texts = append(texts, l.Text)
+	}
+	if strings.Join(texts, " ") != "b true false" {
+		t.Errorf("Wrong labels, got %v", texts)
+	}
+}
+
+func TestLayoutMeasuresGoRegular(t *testing.T) {
+	narrow := GoRegular.Width("iiii", 10)
+	wide := GoRegular.Width("mmmm", 10)
+	if narrow <= 0 || wide <= narrow {
+		t.Errorf("Expected 0 < %v < %v", narrow, wide)
+	}
+	checkFloat(t, "width", GoRegular.Width("mmmm", 20), 2*wide)
+	checkFloat(t, "width", GoRegular.Width("iimm", 10), (narrow+wide)/2)
+}
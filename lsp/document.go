@@ -0,0 +1,330 @@
+package lsp
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/JSchrtke/structogen/syntax"
+)
+
+// document is an open .str file along with its tokens and parse results.
+type document struct {
+	lines        []string
+	tokens       []syntax.Token
+	structograms []*syntax.Structogram
+	errors       syntax.Diagnostics
+}
+
+func newDocument(text string) *document {
+	tokens := syntax.Tokenize(text)
+	s, err := syntax.ParseAllTokens(tokens)
+	doc := &document{
+		lines:        strings.Split(text, "\n"),
+		tokens:       tokens,
+		structograms: s,
+	}
+	if diagnostics, ok := err.(syntax.Diagnostics); ok {
+		doc.errors = diagnostics
+	}
+	return doc
+}
+
+// position converts a source position, with 1-based line and rune column,
+// to a protocol position.
+func (d *document) position(p syntax.Position) position {
+	line := p.Line - 1
+	if line < 0 || line >= len(d.lines) {
+		return position{Line: line}
+	}
+	character := 0
+	for i, r := range []rune(d.lines[line]) {
+		if i >= p.Column-1 {
+			break
+		}
+		character += utf16Len(r)
+	}
+	return position{Line: line, Character: character}
+}
+
+// sourcePosition is the inverse of position.
+func (d *document) sourcePosition(p position) syntax.Position {
+	if p.Line < 0 || p.Line >= len(d.lines) {
+		return syntax.Position{Line: p.Line + 1, Column: 1}
+	}
+	column := 1
+	character := 0
+	for _, r := range d.lines[p.Line] {
+		character += utf16Len(r)
+		if character > p.Character {
+			break
+		}
+		column++
+	}
+	return syntax.Position{Line: p.Line + 1, Column: column}
+}
+
+func (d *document) textRange(start syntax.Position, end syntax.Position) textRange {
+	if end.Line == 0 {
+		end = start
+	}
+	return textRange{Start: d.position(start), End: d.position(end)}
+}
+
+// utf16Len returns the number of UTF-16 code units needed to encode r.
+func utf16Len(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+	return 1
+}
+
+func (d *document) diagnostics() []diagnostic {
+	diagnostics := []diagnostic{}
+	for _, e := range d.errors {
+		diagnostics = append(diagnostics, diagnostic{
+			Range:    d.textRange(e.Start, e.End),
+			Severity: 1,
+			Source:   "structogen",
+			Message:  e.Message,
+		})
+	}
+	return diagnostics
+}
+
+// semanticTokenTypes is the legend of the semantic tokens. The type of a
+// token is encoded as its index.
+var semanticTokenTypes = []string{"keyword", "string", "comment"}
+
+func semanticTokenType(tokenType string) (int, bool) {
+	switch tokenType {
+	case "name", "instruction", "call", "if", "else", "while", "dowhile",
+		"for", "switch", "case", "default", "break", "continue", "return",
+		"exit", "parallel", "branch", "try", "catch", "finally", "import":
+		return 0, true
+	case "string":
+		return 1, true
+	case "comment":
+		return 2, true
+	}
+	return 0, false
+}
+
+// semanticTokens encodes the keywords, strings and comments of d relative
+// to each other, as five integers per token. Tokens that span multiple lines
+// are split into one token per line, as not all clients support multi-line
+// tokens.
+func (d *document) semanticTokens() []int {
+	data := []int{}
+	var previous position
+	for _, t := range d.tokens {
+		tokenType, ok := semanticTokenType(t.Type)
+		if !ok {
+			continue
+		}
+		for line := t.Line; line <= t.EndLine; line++ {
+			start := syntax.Position{Line: line, Column: 1}
+			if line == t.Line {
+				start.Column = t.Column
+			}
+			end := syntax.Position{Line: line, Column: t.EndColumn}
+			if line != t.EndLine && line-1 < len(d.lines) {
+				end.Column = len([]rune(d.lines[line-1])) + 1
+			}
+			from, to := d.position(start), d.position(end)
+			if to.Character <= from.Character {
+				continue
+			}
+
+			deltaCharacter := from.Character
+			if from.Line == previous.Line {
+				deltaCharacter -= previous.Character
+			}
+			data = append(
+				data,
+				from.Line-previous.Line, deltaCharacter,
+				to.Character-from.Character, tokenType, 0,
+			)
+			previous = from
+		}
+	}
+	return data
+}
+
+// symbolKinds maps the node types that are listed as document symbols to
+// the closest symbol kinds of the protocol.
+var symbolKinds = map[string]int{
+	"if":       17, // Boolean
+	"else":     17,
+	"while":    18, // Array, standing in for a loop
+	"dowhile":  18,
+	"for":      18,
+	"switch":   10, // Enum
+	"case":     22, // EnumMember
+	"default":  22,
+	"parallel": 23, // Struct
+	"branch":   8,  // Field
+	"try":      24, // Event, standing in for exceptions
+	"catch":    24,
+	"finally":  24,
+}
+
+// outline returns the symbols of the document. If it contains several
+// structograms, each of them is a function symbol with the symbols of its
+// nodes as children.
+func (d *document) outline() []documentSymbol {
+	if len(d.structograms) == 1 {
+		return d.symbols(d.structograms[0].Nodes)
+	}
+	symbols := []documentSymbol{}
+	for _, s := range d.structograms {
+		end := s.NameStart
+		if len(s.Nodes) > 0 {
+			end = s.Nodes[len(s.Nodes)-1].End
+		}
+		symbols = append(symbols, documentSymbol{
+			Name:           s.Name,
+			Kind:           12, // Function
+			Range:          d.textRange(s.NameStart, end),
+			SelectionRange: d.nameRange(s),
+			Children:       d.symbols(s.Nodes),
+		})
+	}
+	return symbols
+}
+
+// nameRange returns the range of the name keyword of s.
+func (d *document) nameRange(s *syntax.Structogram) textRange {
+	end := s.NameStart
+	end.Column += len("name")
+	return d.textRange(s.NameStart, end)
+}
+
+// symbols returns the compound nodes among nodes as a tree of symbols. The
+// keyword of a node is its selection range.
+func (d *document) symbols(nodes []syntax.Node) []documentSymbol {
+	symbols := []documentSymbol{}
+	for _, n := range nodes {
+		kind, ok := symbolKinds[n.NodeType]
+		if !ok {
+			continue
+		}
+		keywordEnd := n.Start
+		keywordEnd.Column += len([]rune(n.NodeType))
+		symbols = append(symbols, documentSymbol{
+			Name:           n.NodeType,
+			Detail:         n.Value,
+			Kind:           kind,
+			Range:          d.textRange(n.Start, n.End),
+			SelectionRange: d.textRange(n.Start, keywordEnd),
+			Children:       d.symbols(n.Nodes),
+		})
+	}
+	return symbols
+}
+
+// foldingRanges returns a range for every pair of braces and every block
+// comment that spans multiple lines. The line of a closing brace stays
+// visible when its range is folded.
+func (d *document) foldingRanges() []foldingRange {
+	ranges := []foldingRange{}
+	var open []syntax.Token
+	for _, t := range d.tokens {
+		switch t.Type {
+		case "openBrace":
+			open = append(open, t)
+		case "closeBrace":
+			if len(open) == 0 {
+				continue
+			}
+			o := open[len(open)-1]
+			open = open[:len(open)-1]
+			if t.Line > o.Line+1 {
+				ranges = append(ranges, foldingRange{
+					StartLine: o.Line - 1,
+					EndLine:   t.Line - 2,
+				})
+			}
+		case "comment":
+			if t.EndLine > t.Line {
+				ranges = append(ranges, foldingRange{
+					StartLine: t.Line - 1,
+					EndLine:   t.EndLine - 1,
+					Kind:      "comment",
+				})
+			}
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].StartLine < ranges[j].StartLine
+	})
+	return ranges
+}
+
+var nodeDescriptions = map[string]string{
+	"instruction": "Instruction",
+	"call":        "Call of another structogram",
+	"if":          "If, runs its body if the condition is true",
+	"else":        "Else, runs its body if the condition of the if is false",
+	"while":       "While loop, checks its condition before each iteration",
+	"dowhile":     "Do-while loop, checks its condition after each iteration",
+	"for":         "For loop, runs its body for each step of the count",
+	"switch":      "Switch, runs the case matching its value",
+	"case":        "Case of a switch",
+	"default":     "Default case of a switch, runs if no case matches",
+	"break":       "Break, leaves the surrounding loop or case",
+	"continue":    "Continue, skips to the next iteration of the surrounding loop",
+	"return":      "Return, leaves the structogram",
+	"exit":        "Exit, ends the program",
+	"parallel":    "Parallel, runs its branches concurrently",
+	"branch":      "Branch of a parallel",
+	"try":         "Try, runs its body and handles the exceptions it throws",
+	"catch":       "Catch, runs if the try throws a matching exception",
+	"finally":     "Finally, runs after the try and catch in any case",
+}
+
+// nodeAt returns the innermost node at p in any of the structograms, or nil
+// if there is none.
+func (d *document) nodeAt(p position) *syntax.Node {
+	for _, s := range d.structograms {
+		if n := nodeAt(s.Nodes, d.sourcePosition(p)); n != nil {
+			return n
+		}
+	}
+	return nil
+}
+
+// hover describes the innermost node at p, or returns nil if there is none.
+func (d *document) hover(p position) *hover {
+	n := d.nodeAt(p)
+	if n == nil {
+		return nil
+	}
+	contents := fmt.Sprintf("**%s**\n\n%s", n.NodeType, nodeDescriptions[n.NodeType])
+	if n.Value != "" {
+		contents += fmt.Sprintf("\n\n```\n%s\n```", n.Value)
+	}
+	return &hover{
+		Contents: markupContent{Kind: "markdown", Value: contents},
+		Range:    d.textRange(n.Start, n.End),
+	}
+}
+
+// nodeAt returns the innermost node among nodes whose span contains p.
+func nodeAt(nodes []syntax.Node, p syntax.Position) *syntax.Node {
+	for i := range nodes {
+		n := &nodes[i]
+		if before(p, n.Start) || !before(p, n.End) {
+			continue
+		}
+		if inner := nodeAt(n.Nodes, p); inner != nil {
+			return inner
+		}
+		return n
+	}
+	return nil
+}
+
+func before(a syntax.Position, b syntax.Position) bool {
+	return a.Line < b.Line || a.Line == b.Line && a.Column < b.Column
+}
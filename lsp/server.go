@@ -0,0 +1,269 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/JSchrtke/structogen/project"
+)
+
+// server keeps the state of a session, which is the set of open documents.
+type server struct {
+	out       io.Writer
+	documents map[string]*document
+	shutdown  bool
+}
+
+// Serve runs a language server that reads messages from r and writes its
+// responses and notifications to w, until the client sends the exit
+// notification or r ends. Exiting without a prior shutdown request is an
+// error.
+func Serve(r io.Reader, w io.Writer) error {
+	s := server{out: w, documents: map[string]*document{}}
+	in := bufio.NewReader(r)
+	for {
+		msg, err := readMessage(in)
+		if err == io.EOF {
+			return nil
+		}
+		if rerr, ok := err.(*responseError); ok {
+			// The id of a message that can not be decoded is unknown, which
+			// is answered with a null id.
+			if err := s.reply(message{}, nil, rerr); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if msg.Method == "exit" {
+			if !s.shutdown {
+				return errors.New("exit without shutdown")
+			}
+			return nil
+		}
+
+		result, err := s.handle(msg)
+		// Notifications are never answered, not even if they fail.
+		if msg.ID == nil {
+			continue
+		}
+		if err := s.reply(msg, result, err); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *server) reply(msg message, result interface{}, err error) error {
+	if err == nil {
+		return writeMessage(s.out, response{
+			JSONRPC: "2.0",
+			ID:      msg.ID,
+			Result:  result,
+		})
+	}
+	rerr, ok := err.(*responseError)
+	if !ok {
+		rerr = &responseError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	return writeMessage(s.out, errorResponse{
+		JSONRPC: "2.0",
+		ID:      msg.ID,
+		Error:   rerr,
+	})
+}
+
+func (s *server) notify(method string, params interface{}) error {
+	return writeMessage(s.out, notification{
+		JSONRPC: "2.0",
+		Method:  method,
+		Params:  params,
+	})
+}
+
+// handle dispatches msg to the method it calls and returns the result.
+func (s *server) handle(msg message) (interface{}, error) {
+	if s.shutdown {
+		return nil, &responseError{
+			Code:    codeInvalidRequest,
+			Message: "the server is shut down",
+		}
+	}
+
+	switch msg.Method {
+	case "initialize":
+		return initializeResult(), nil
+	case "initialized":
+		return nil, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "textDocument/didOpen":
+		var params didOpenTextDocumentParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, err
+		}
+		return nil, s.update(params.TextDocument.URI, params.TextDocument.Text)
+	case "textDocument/didChange":
+		var params didChangeTextDocumentParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, err
+		}
+		// The documents are synchronized in full, so only the last change
+		// matters.
+		changes := params.ContentChanges
+		if len(changes) == 0 {
+			return nil, nil
+		}
+		text := changes[len(changes)-1].Text
+		return nil, s.update(params.TextDocument.URI, text)
+	case "textDocument/didClose":
+		var params didCloseTextDocumentParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, err
+		}
+		delete(s.documents, params.TextDocument.URI)
+		return nil, s.notify(
+			"textDocument/publishDiagnostics",
+			publishDiagnosticsParams{
+				URI:         params.TextDocument.URI,
+				Diagnostics: []diagnostic{},
+			},
+		)
+	case "textDocument/semanticTokens/full":
+		doc, err := s.document(msg.Params)
+		if err != nil {
+			return nil, err
+		}
+		return semanticTokens{Data: doc.semanticTokens()}, nil
+	case "textDocument/documentSymbol":
+		doc, err := s.document(msg.Params)
+		if err != nil {
+			return nil, err
+		}
+		return doc.outline(), nil
+	case "textDocument/foldingRange":
+		doc, err := s.document(msg.Params)
+		if err != nil {
+			return nil, err
+		}
+		return doc.foldingRanges(), nil
+	case "textDocument/hover":
+		var params textDocumentPositionParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, err
+		}
+		doc, ok := s.documents[params.TextDocument.URI]
+		if !ok {
+			return nil, unknownDocument(params.TextDocument.URI)
+		}
+		return doc.hover(params.Position), nil
+	case "textDocument/definition":
+		var params textDocumentPositionParams
+		if err := json.Unmarshal(msg.Params, &params); err != nil {
+			return nil, err
+		}
+		doc, ok := s.documents[params.TextDocument.URI]
+		if !ok {
+			return nil, unknownDocument(params.TextDocument.URI)
+		}
+		return s.definition(doc, params.Position), nil
+	}
+
+	if msg.ID == nil {
+		// Unknown notifications, like $/cancelRequest, are ignored.
+		return nil, nil
+	}
+	return nil, &responseError{
+		Code:    codeMethodNotFound,
+		Message: fmt.Sprintf("unknown method %q", msg.Method),
+	}
+}
+
+// update parses the new text of a document and publishes its diagnostics.
+func (s *server) update(uri string, text string) error {
+	doc := newDocument(text)
+	s.documents[uri] = doc
+	return s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{
+		URI:         uri,
+		Diagnostics: doc.diagnostics(),
+	})
+}
+
+// definition returns the locations of the structograms that the call at p
+// refers to, among the structograms of all open documents.
+func (s *server) definition(doc *document, p position) []location {
+	locations := []location{}
+	n := doc.nodeAt(p)
+	if n == nil || n.NodeType != "call" {
+		return locations
+	}
+
+	var uris []string
+	for uri := range s.documents {
+		uris = append(uris, uri)
+	}
+	sort.Strings(uris)
+	var files []*project.File
+	for _, uri := range uris {
+		files = append(files, &project.File{
+			Name: uri, Structograms: s.documents[uri].structograms,
+		})
+	}
+	for _, d := range project.New(files).Resolve(n.Value) {
+		locations = append(locations, location{
+			URI:   d.File.Name,
+			Range: s.documents[d.File.Name].nameRange(d.Structogram),
+		})
+	}
+	return locations
+}
+
+// document returns the open document the params of a request refer to.
+func (s *server) document(raw json.RawMessage) (*document, error) {
+	var params documentParams
+	if err := json.Unmarshal(raw, &params); err != nil {
+		return nil, err
+	}
+	doc, ok := s.documents[params.TextDocument.URI]
+	if !ok {
+		return nil, unknownDocument(params.TextDocument.URI)
+	}
+	return doc, nil
+}
+
+func unknownDocument(uri string) error {
+	return &responseError{
+		Code:    codeInvalidParams,
+		Message: fmt.Sprintf("document %s is not open", uri),
+	}
+}
+
+// initializeResult returns the capabilities of the server. Documents are
+// always synchronized in full, as .str files are small.
+func initializeResult() interface{} {
+	return map[string]interface{}{
+		"capabilities": map[string]interface{}{
+			"textDocumentSync":       1,
+			"hoverProvider":          true,
+			"definitionProvider":     true,
+			"documentSymbolProvider": true,
+			"foldingRangeProvider":   true,
+			"semanticTokensProvider": map[string]interface{}{
+				"legend": map[string]interface{}{
+					"tokenTypes":     semanticTokenTypes,
+					"tokenModifiers": []string{},
+				},
+				"full": true,
+			},
+		},
+		"serverInfo": map[string]interface{}{
+			"name": "structogen",
+		},
+	}
+}
@@ -0,0 +1,404 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testURI = "file:///test.str"
+
+func checkOk(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("expected no error, but got %s", err)
+	}
+}
+
+func request(id int, method string, params interface{}) interface{} {
+	return map[string]interface{}{
+		"jsonrpc": "2.0", "id": id, "method": method, "params": params,
+	}
+}
+
+func notify(method string, params interface{}) interface{} {
+	return map[string]interface{}{
+		"jsonrpc": "2.0", "method": method, "params": params,
+	}
+}
+
+func open(text string) interface{} {
+	return notify("textDocument/didOpen", map[string]interface{}{
+		"textDocument": map[string]interface{}{
+			"uri": testURI, "languageId": "structogen", "version": 1,
+			"text": text,
+		},
+	})
+}
+
+func textDocument() map[string]interface{} {
+	return map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": testURI},
+	}
+}
+
+// serveForTest runs a session with the given messages, followed by shutdown
+// and exit, and returns everything the server sent, without the response to
+// shutdown.
+func serveForTest(t *testing.T, messages ...interface{}) []map[string]json.RawMessage {
+	t.Helper()
+	var in bytes.Buffer
+	messages = append(messages, request(-1, "shutdown", nil), notify("exit", nil))
+	for _, m := range messages {
+		checkOk(t, writeMessage(&in, m))
+	}
+	var out bytes.Buffer
+	checkOk(t, Serve(&in, &out))
+
+	var received []map[string]json.RawMessage
+	r := bufio.NewReader(&out)
+	for {
+		header, err := r.ReadString('\n')
+		if err == io.EOF {
+			break
+		}
+		checkOk(t, err)
+		var length int
+		_, err = fmt.Sscanf(header, "Content-Length: %d", &length)
+		checkOk(t, err)
+		_, err = r.ReadString('\n')
+		checkOk(t, err)
+		body := make([]byte, length)
+		_, err = io.ReadFull(r, body)
+		checkOk(t, err)
+		var m map[string]json.RawMessage
+		checkOk(t, json.Unmarshal(body, &m))
+		received = append(received, m)
+	}
+	if len(received) == 0 {
+		t.Fatalf("expected at least the response to shutdown")
+	}
+	return received[:len(received)-1]
+}
+
+// result returns the result of the response to the request with the id.
+func result(t *testing.T, received []map[string]json.RawMessage, id int, v interface{}) {
+	t.Helper()
+	for _, m := range received {
+		if string(m["id"]) == strings.TrimSpace(jsonString(t, id)) {
+			if e, ok := m["error"]; ok {
+				t.Fatalf("expected a result, but got error %s", e)
+			}
+			checkOk(t, json.Unmarshal(m["result"], v))
+			return
+		}
+	}
+	t.Fatalf("no response to request %d", id)
+}
+
+func jsonString(t *testing.T, v interface{}) string {
+	t.Helper()
+	j, err := json.Marshal(v)
+	checkOk(t, err)
+	return string(j)
+}
+
+func published(t *testing.T, received []map[string]json.RawMessage) []publishDiagnosticsParams {
+	t.Helper()
+	var all []publishDiagnosticsParams
+	for _, m := range received {
+		if string(m["method"]) != `"textDocument/publishDiagnostics"` {
+			continue
+		}
+		var params publishDiagnosticsParams
+		checkOk(t, json.Unmarshal(m["params"], &params))
+		all = append(all, params)
+	}
+	return all
+}
+
+func TestInitializeAnnouncesCapabilities(t *testing.T) {
+	received := serveForTest(t, request(1, "initialize", map[string]interface{}{}))
+	var r struct {
+		Capabilities map[string]json.RawMessage
+	}
+	result(t, received, 1, &r)
+	for _, c := range []string{
+		"textDocumentSync", "hoverProvider", "documentSymbolProvider",
+		"foldingRangeProvider", "semanticTokensProvider", "definitionProvider",
+	} {
+		if _, ok := r.Capabilities[c]; !ok {
+			t.Errorf("expected capability %s, got %v", c, r.Capabilities)
+		}
+	}
+}
+
+func TestPublishesDiagnosticsOnOpenAndChange(t *testing.T) {
+	received := serveForTest(
+		t,
+		open("name(\"a\")\nif(\"x\") {\n    foo\n}\n"),
+		notify("textDocument/didChange", map[string]interface{}{
+			"textDocument": map[string]interface{}{"uri": testURI, "version": 2},
+			"contentChanges": []interface{}{
+				map[string]interface{}{"text": "name(\"a\")\ninstruction(\"x\")\n"},
+			},
+		}),
+	)
+	all := published(t, received)
+	if len(all) != 2 {
+		t.Fatalf("expected 2 notifications, got %d", len(all))
+	}
+	if len(all[0].Diagnostics) != 1 {
+		t.Fatalf("expected 1 diagnostic, got %v", all[0].Diagnostics)
+	}
+	d := all[0].Diagnostics[0]
+	expected := textRange{Start: position{2, 4}, End: position{2, 7}}
+	if d.Range != expected || d.Severity != 1 {
+		t.Errorf("expected error at %v, got %v", expected, d)
+	}
+	if all[0].URI != testURI {
+		t.Errorf("expected uri %s, got %s", testURI, all[0].URI)
+	}
+	if len(all[1].Diagnostics) != 0 {
+		t.Errorf("expected the errors to be cleared, got %v", all[1].Diagnostics)
+	}
+}
+
+func TestSemanticTokens(t *testing.T) {
+	received := serveForTest(
+		t,
+		open("name(\"a\") // c\nif(\"ä\") {\n    call(\"\"\"\n    x\n    \"\"\")\n}\n"),
+		request(1, "textDocument/semanticTokens/full", textDocument()),
+	)
+	var tokens semanticTokens
+	result(t, received, 1, &tokens)
+	expected := []int{
+		0, 0, 4, 0, 0, // name
+		0, 5, 3, 1, 0, // "a"
+		0, 5, 4, 2, 0, // // c
+		1, 0, 2, 0, 0, // if
+		0, 3, 3, 1, 0, // "ä"
+		1, 4, 4, 0, 0, // call
+		0, 5, 3, 1, 0, // the multi-line string, one token per line
+		1, 0, 5, 1, 0,
+		1, 0, 7, 1, 0,
+	}
+	if !reflect.DeepEqual(tokens.Data, expected) {
+		t.Errorf("expected %v, got %v", expected, tokens.Data)
+	}
+}
+
+func TestDocumentSymbols(t *testing.T) {
+	received := serveForTest(
+		t,
+		open(`name("a")
+if("x") {
+    while("y") {
+        instruction("z")
+    }
+} else {
+    instruction("w")
+}
+`),
+		request(1, "textDocument/documentSymbol", textDocument()),
+	)
+	var symbols []documentSymbol
+	result(t, received, 1, &symbols)
+	if len(symbols) != 2 {
+		t.Fatalf("expected if and else, got %v", symbols)
+	}
+	ifSymbol := symbols[0]
+	if ifSymbol.Name != "if" || ifSymbol.Detail != "x" {
+		t.Errorf("expected if x, got %s %s", ifSymbol.Name, ifSymbol.Detail)
+	}
+	expected := textRange{Start: position{1, 0}, End: position{5, 1}}
+	if ifSymbol.Range != expected {
+		t.Errorf("expected range %v, got %v", expected, ifSymbol.Range)
+	}
+	expected = textRange{Start: position{1, 0}, End: position{1, 2}}
+	if ifSymbol.SelectionRange != expected {
+		t.Errorf("expected selection %v, got %v", expected, ifSymbol.SelectionRange)
+	}
+	if len(ifSymbol.Children) != 1 || ifSymbol.Children[0].Name != "while" {
+		t.Errorf("expected the while as child, got %v", ifSymbol.Children)
+	}
+	if symbols[1].Name != "else" || len(symbols[1].Children) != 0 {
+		t.Errorf("expected else without children, got %v", symbols[1])
+	}
+}
+
+func TestFoldingRanges(t *testing.T) {
+	received := serveForTest(
+		t,
+		open(`name("a")
+/*
+ * block
+ */
+switch("x") {
+    case("1") { instruction("a") }
+    case("2") {
+        instruction("b")
+    }
+    default {
+        instruction("c")
+    }
+}
+`),
+		request(1, "textDocument/foldingRange", textDocument()),
+	)
+	var ranges []foldingRange
+	result(t, received, 1, &ranges)
+	expected := []foldingRange{
+		{StartLine: 1, EndLine: 3, Kind: "comment"},
+		{StartLine: 4, EndLine: 11},
+		{StartLine: 6, EndLine: 7},
+		{StartLine: 9, EndLine: 10},
+	}
+	if !reflect.DeepEqual(ranges, expected) {
+		t.Errorf("expected %v, got %v", expected, ranges)
+	}
+}
+
+func TestHoverShowsInnermostNode(t *testing.T) {
+	received := serveForTest(
+		t,
+		open(`name("a")
+while("i < 10") {
+    call("step")
+}
+`),
+		request(1, "textDocument/hover", map[string]interface{}{
+			"textDocument": map[string]interface{}{"uri": testURI},
+			"position":     position{Line: 2, Character: 6},
+		}),
+		request(2, "textDocument/hover", map[string]interface{}{
+			"textDocument": map[string]interface{}{"uri": testURI},
+			"position":     position{Line: 3, Character: 0},
+		}),
+		request(3, "textDocument/hover", map[string]interface{}{
+			"textDocument": map[string]interface{}{"uri": testURI},
+			"position":     position{Line: 0, Character: 2},
+		}),
+	)
+	var h *hover
+	result(t, received, 1, &h)
+	if h == nil || !strings.HasPrefix(h.Contents.Value, "**call**") ||
+		!strings.Contains(h.Contents.Value, "step") {
+		t.Errorf("expected the call to be described, got %v", h)
+	}
+	expected := textRange{Start: position{2, 4}, End: position{2, 16}}
+	if h != nil && h.Range != expected {
+		t.Errorf("expected range %v, got %v", expected, h.Range)
+	}
+
+	h = nil
+	result(t, received, 2, &h)
+	if h == nil || !strings.HasPrefix(h.Contents.Value, "**while**") {
+		t.Errorf("expected the while to be described, got %v", h)
+	}
+
+	h = nil
+	result(t, received, 3, &h)
+	if h != nil {
+		t.Errorf("expected no hover outside of nodes, got %v", h)
+	}
+}
+
+func TestUnknownRequestIsAnError(t *testing.T) {
+	received := serveForTest(
+		t,
+		notify("$/cancelRequest", map[string]interface{}{"id": 1}),
+		request(1, "textDocument/unknown", nil),
+	)
+	if len(received) != 1 {
+		t.Fatalf("expected only the response to the request, got %v", received)
+	}
+	var e responseError
+	checkOk(t, json.Unmarshal(received[0]["error"], &e))
+	if e.Code != codeMethodNotFound {
+		t.Errorf("expected code %d, got %d", codeMethodNotFound, e.Code)
+	}
+}
+
+func TestExitWithoutShutdownIsAnError(t *testing.T) {
+	var in bytes.Buffer
+	checkOk(t, writeMessage(&in, notify("exit", nil)))
+	if err := Serve(&in, io.Discard); err == nil {
+		t.Errorf("expected an error")
+	}
+}
+
+func TestDocumentSymbolsOfSeveralStructograms(t *testing.T) {
+	received := serveForTest(
+		t,
+		open(`name("a")
+instruction("b")
+
+name("c")
+while("d") {
+    instruction("e")
+}
+`),
+		request(1, "textDocument/documentSymbol", textDocument()),
+	)
+	var symbols []documentSymbol
+	result(t, received, 1, &symbols)
+	if len(symbols) != 2 {
+		t.Fatalf("expected a and c, got %v", symbols)
+	}
+	c := symbols[1]
+	if c.Name != "c" || c.Kind != 12 {
+		t.Errorf("expected function c, got %s of kind %d", c.Name, c.Kind)
+	}
+	expected := textRange{Start: position{3, 0}, End: position{6, 1}}
+	if c.Range != expected {
+		t.Errorf("expected range %v, got %v", expected, c.Range)
+	}
+	if len(c.Children) != 1 || c.Children[0].Name != "while" {
+		t.Errorf("expected the while as child, got %v", c.Children)
+	}
+}
+
+func TestDefinitionFindsStructogramsInOpenDocuments(t *testing.T) {
+	const libURI = "file:///lib.str"
+	received := serveForTest(
+		t,
+		open(`name("main")
+call("total = sum(list)")
+call("print(total)")
+`),
+		notify("textDocument/didOpen", map[string]interface{}{
+			"textDocument": map[string]interface{}{
+				"uri": libURI, "languageId": "structogen", "version": 1,
+				"text": "name(\"max\") instruction(\"a\")\n\n" +
+					"name(\"sum(list)\") instruction(\"b\")\n",
+			},
+		}),
+		request(1, "textDocument/definition", map[string]interface{}{
+			"textDocument": map[string]interface{}{"uri": testURI},
+			"position":     position{Line: 1, Character: 10},
+		}),
+		request(2, "textDocument/definition", map[string]interface{}{
+			"textDocument": map[string]interface{}{"uri": testURI},
+			"position":     position{Line: 2, Character: 10},
+		}),
+	)
+	var locations []location
+	result(t, received, 1, &locations)
+	expected := []location{{
+		URI:   libURI,
+		Range: textRange{Start: position{2, 0}, End: position{2, 4}},
+	}}
+	if !reflect.DeepEqual(locations, expected) {
+		t.Errorf("expected %v, got %v", expected, locations)
+	}
+	result(t, received, 2, &locations)
+	if len(locations) != 0 {
+		t.Errorf("expected no definition of print, got %v", locations)
+	}
+}
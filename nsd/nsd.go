@@ -0,0 +1,106 @@
+// Package nsd reads and writes the .nsd files of Structorizer, which are XML
+// documents.
+//
+// Structorizer stores the text of an element as a comma separated list of
+// lines in double quotes, in which double quotes are doubled. Its repeat
+// loop runs until its condition holds, so its condition is the negation of
+// the condition of a dowhile. A Structorizer try has exactly one catch.
+package nsd
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Keywords that Structorizer expects in front of the text of elements,
+// unless configured otherwise in the attributes of the root element.
+const (
+	preWhile  = "while "
+	preRepeat = "until "
+	preFor    = "for "
+	preReturn = "return"
+	preLeave  = "leave"
+	preExit   = "exit"
+)
+
+// splitText splits the text attribute of an element into its lines. A text
+// that is not a list of quoted lines is a single line.
+func splitText(text string) []string {
+	if !strings.HasPrefix(text, `"`) {
+		return []string{text}
+	}
+	var lines []string
+	var line strings.Builder
+	quoted := false
+	for i := 0; i < len(text); i++ {
+		ch := text[i]
+		switch {
+		case ch == '"' && quoted && i+1 < len(text) && text[i+1] == '"':
+			line.WriteByte('"')
+			i++
+		case ch == '"':
+			quoted = !quoted
+		case ch == ',' && !quoted:
+			lines = append(lines, line.String())
+			line.Reset()
+		case quoted:
+			line.WriteByte(ch)
+		}
+	}
+	return append(lines, line.String())
+}
+
+// joinText joins lines into the text attribute of an element.
+func joinText(lines ...string) string {
+	quoted := make([]string, len(lines))
+	for i, l := range lines {
+		quoted[i] = `"` + strings.ReplaceAll(l, `"`, `""`) + `"`
+	}
+	return strings.Join(quoted, ",")
+}
+
+// trimKeyword removes keyword and the spaces after it from the start of
+// text, ignoring case.
+func trimKeyword(text string, keyword string) (string, bool) {
+	keyword = strings.TrimSpace(keyword)
+	if len(text) < len(keyword) || !strings.EqualFold(text[:len(keyword)], keyword) {
+		return text, false
+	}
+	rest := text[len(keyword):]
+	if rest != "" && (unicode.IsLetter(rune(rest[0])) ||
+		unicode.IsDigit(rune(rest[0])) || rest[0] == '_') {
+		// The keyword is only the start of a longer word.
+		return text, false
+	}
+	return strings.TrimLeft(rest, " \t"), true
+}
+
+// negate returns the negation of condition, removing a negation instead of
+// adding a second one.
+func negate(condition string) string {
+	if inner, ok := trimKeyword(condition, "not"); ok && isParenthesized(inner) {
+		return inner[1 : len(inner)-1]
+	}
+	return "not (" + condition + ")"
+}
+
+// isParenthesized reports whether s is enclosed in a pair of matching
+// parentheses.
+func isParenthesized(s string) bool {
+	if !strings.HasPrefix(s, "(") || !strings.HasSuffix(s, ")") {
+		return false
+	}
+	depth := 0
+	for i, ch := range s {
+		switch ch {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 && i != len(s)-1 {
+				return false
+			}
+		}
+	}
+	return true
+}
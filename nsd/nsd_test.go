@@ -0,0 +1,226 @@
+package nsd
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/JSchrtke/structogen/syntax"
+)
+
+func checkOk(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("Did not expect any errors, but got %s", err.Error())
+	}
+}
+
+func checkFormatted(t *testing.T, s *syntax.Structogram, expected string) {
+	t.Helper()
+	formatted := syntax.Format(s)
+	if formatted != expected {
+		t.Errorf("Expected:\n%s\nbut got:\n%s", expected, formatted)
+	}
+}
+
+// withoutPositions returns a copy of nodes without their source positions,
+// which are used by syntax.Format to keep blank lines.
+func withoutPositions(nodes []syntax.Node) []syntax.Node {
+	var copied []syntax.Node
+	for _, n := range nodes {
+		copied = append(copied, syntax.Node{
+			NodeType: n.NodeType,
+			Value:    n.Value,
+			Nodes:    withoutPositions(n.Nodes),
+		})
+	}
+	return copied
+}
+
+// roundTrip writes the structogram in source as an .nsd file, reads it
+// again and checks that it is formatted like source.
+func roundTrip(t *testing.T, source string) string {
+	t.Helper()
+	s, err := syntax.Parse(strings.NewReader(source))
+	checkOk(t, err)
+	expected := syntax.Format(&syntax.Structogram{
+		Name:  s.Name,
+		Nodes: withoutPositions(s.Nodes),
+	})
+
+	var b strings.Builder
+	checkOk(t, Write(&b, s))
+	read, err := Read(strings.NewReader(b.String()))
+	checkOk(t, err)
+	checkFormatted(t, read, expected)
+	return b.String()
+}
+
+func TestRoundTripOfTemplate(t *testing.T) {
+	source, err := os.ReadFile("../template.str")
+	checkOk(t, err)
+	roundTrip(t, string(source))
+}
+
+func TestRoundTripOfEveryStatement(t *testing.T) {
+	for _, source := range []string{
+		`name("a") instruction("")`,
+		`name("a") instruction("x = \"quoted\", y = 'single' & <tag>")`,
+		`name("a") instruction("first\nsecond")`,
+		`name("sum(a, b)") return("a + b")`,
+		`name("a") call("f(\"x\", 1)")`,
+		`name("a") if("b") {instruction("c")}`,
+		`name("a") if("b") {instruction("c")} else {instruction("")}`,
+		`name("a") while("b") {break("")} dowhile("c") {continue("")}`,
+		`name("a") dowhile("not (b)") {exit("1")}`,
+		`name("a") dowhile("not (b) or not (c)") {exit("")}`,
+		`name("a") for("i = 1 to 10") {break("outer")}`,
+		`name("a") switch("b") {
+			case("1") {instruction("c")}
+			case("\"two\"") {instruction("d")}
+			default {instruction("")}
+		}`,
+		`name("a") parallel {branch {call("b")} branch {call("c")}}`,
+		`name("a") try {call("b")} catch("e") {instruction("c")}`,
+		`name("a") try {call("b")} finally {instruction("c")}`,
+		`name("a") try {call("b")} catch("") {instruction("")} finally {instruction("c")}`,
+	} {
+		roundTrip(t, source)
+	}
+}
+
+func TestWriteMapsStatementsToElements(t *testing.T) {
+	written := roundTrip(t, `name("a")
+dowhile ("x < 5") {
+    instruction("say \"hi\"")
+}
+return("x")
+`)
+	for _, expected := range []string{
+		`<root xmlns:nsd="https://structorizer.fisch.lu"`,
+		`text="&#34;a&#34;"`,
+		`type="program"`,
+		`<repeat text="&#34;until not (x &lt; 5)&#34;"`,
+		`<instruction text="&#34;say &#34;&#34;hi&#34;&#34;&#34;"`,
+		`<jump text="&#34;return x&#34;"`,
+	} {
+		if !strings.Contains(written, expected) {
+			t.Errorf("Expected the output to contain %s, but got:\n%s", expected, written)
+		}
+	}
+}
+
+func TestReadTranslatesStructorizerFiles(t *testing.T) {
+	s, err := Read(strings.NewReader(`<?xml version="1.0" encoding="UTF-8"?>
+<root xmlns:nsd="https://structorizer.fisch.lu" version="3.30" preRepeat="until " preWhile="solange " text="&#34;ggT(a, b)&#34;" type="sub" style="nice">
+	<children>
+		<instruction text="&#34;INPUT a&#34;,&#34;INPUT b&#34;" comment="" color="ffffff" rotated="0" disabled="0"></instruction>
+		<instruction text="&#34;skipped&#34;" disabled="1"></instruction>
+		<while text="&#34;solange a &lt;&gt; b&#34;" comment="" color="ffffff" disabled="0">
+			<qWhile>
+				<alternative text="&#34;a &gt; b&#34;" comment="" color="ffffff" disabled="0">
+					<qTrue>
+						<instruction text="&#34;a &lt;- a - b&#34;" comment="" color="ffffff" rotated="0" disabled="0"></instruction>
+					</qTrue>
+					<qFalse color="ffffff">
+						<instruction text="&#34;b &lt;- b - a&#34;" comment="" color="ffffff" rotated="0" disabled="0"></instruction>
+					</qFalse>
+				</alternative>
+			</qWhile>
+		</while>
+		<repeat text="&#34;until a &gt; 0&#34;" comment="" color="ffffff" disabled="0">
+			<qRepeat>
+			</qRepeat>
+		</repeat>
+		<for text="&#34;for i &lt;- 1 to 10&#34;" counterVar="i" startValue="1" endValue="10" stepConst="1" style="COUNTER" comment="" color="ffffff" disabled="0">
+			<qFor>
+				<call text="&#34;show(i)&#34;" comment="" color="ffffff" disabled="0"></call>
+			</qFor>
+		</for>
+		<case text="&#34;a&#34;,&#34;1&#34;,&#34;%&#34;" comment="" color="ffffff" disabled="0">
+			<qCase>
+				<jump text="&#34;&#34;" comment="" color="ffffff" disabled="0"></jump>
+			</qCase>
+			<qCase color="ffffff">
+			</qCase>
+		</case>
+		<jump text="&#34;return a&#34;" comment="" color="ffffff" disabled="0"></jump>
+	</children>
+</root>
+`))
+	checkOk(t, err)
+	checkFormatted(t, s, `name("ggT(a, b)")
+
+instruction("""
+    INPUT a
+    INPUT b
+""")
+while ("a <> b") {
+    if ("a > b") {
+        instruction("a <- a - b")
+    } else {
+        instruction("b <- b - a")
+    }
+}
+dowhile ("not (a > 0)") {
+    instruction("")
+}
+for ("i <- 1 to 10") {
+    call("show(i)")
+}
+switch ("a") {
+    case ("1") {
+        break("")
+    }
+    default {
+        instruction("")
+    }
+}
+return("a")
+`)
+}
+
+func TestReadAddsADefaultToACaseWithoutValues(t *testing.T) {
+	s, err := Read(strings.NewReader(`<root text="&#34;a&#34;"><children>
+		<case text="&#34;x&#34;,&#34;%&#34;"><qCase></qCase></case>
+	</children></root>`))
+	checkOk(t, err)
+	checkFormatted(t, s, `name("a")
+
+switch ("x") {
+    default {
+        instruction("")
+    }
+}
+`)
+}
+
+func TestReadReportsUnsupportedElements(t *testing.T) {
+	_, err := Read(strings.NewReader(
+		`<root text="&#34;a&#34;"><children><foo></foo></children></root>`,
+	))
+	if err == nil || err.Error() != "unsupported element <foo>" {
+		t.Errorf("Expected an error for <foo>, but got %v", err)
+	}
+
+	_, err = Read(strings.NewReader(`<svg></svg>`))
+	if err == nil || !strings.Contains(err.Error(), "expected <root>") {
+		t.Errorf("Expected an error for <svg>, but got %v", err)
+	}
+}
+
+func TestWriteRejectsMultipleCatches(t *testing.T) {
+	s, err := syntax.Parse(strings.NewReader(`name("a")
+try {
+    call("b")
+} catch ("E1 e") {
+    call("c")
+} catch ("E2 e") {
+    call("d")
+}`))
+	checkOk(t, err)
+	err = Write(&strings.Builder{}, s)
+	if err == nil || err.Error() != "6:3, Structorizer only supports one catch per try" {
+		t.Errorf("Expected an error for the second catch, but got %v", err)
+	}
+}
@@ -0,0 +1,290 @@
+package nsd
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/JSchrtke/structogen/syntax"
+)
+
+// element is an element of an .nsd file, with the attributes that are
+// translated.
+type element struct {
+	XMLName   xml.Name
+	Text      string    `xml:"text,attr"`
+	Disabled  string    `xml:"disabled,attr"`
+	PreWhile  *string   `xml:"preWhile,attr"`
+	PreRepeat *string   `xml:"preRepeat,attr"`
+	PreFor    *string   `xml:"preFor,attr"`
+	PreForIn  *string   `xml:"preForIn,attr"`
+	PreReturn *string   `xml:"preReturn,attr"`
+	PreLeave  *string   `xml:"preLeave,attr"`
+	PreExit   *string   `xml:"preExit,attr"`
+	Children  []element `xml:",any"`
+}
+
+// child returns the first child of e with the given name, or nil if there
+// is none.
+func (e *element) child(name string) *element {
+	for i := range e.Children {
+		if e.Children[i].XMLName.Local == name {
+			return &e.Children[i]
+		}
+	}
+	return nil
+}
+
+// children returns the elements nested in the child of e with the given
+// name.
+func (e *element) children(name string) []element {
+	if c := e.child(name); c != nil {
+		return c.Children
+	}
+	return nil
+}
+
+// keywords are the keywords in front of the texts of elements, as
+// configured by the root element of a file.
+type keywords struct {
+	while, repeat, forLoop, forIn, ret, leave, exit string
+}
+
+func orDefault(attr *string, fallback string) string {
+	if attr == nil {
+		return fallback
+	}
+	return *attr
+}
+
+// Read reads a Structorizer .nsd file. Disabled elements are left out, and
+// the bodies that are empty in Structorizer get an empty instruction, as
+// bodies of structograms can not be empty.
+func Read(r io.Reader) (*syntax.Structogram, error) {
+	var root element
+	if err := xml.NewDecoder(r).Decode(&root); err != nil {
+		return nil, fmt.Errorf("invalid nsd file: %s", err)
+	}
+	if root.XMLName.Local != "root" {
+		return nil, fmt.Errorf(
+			"invalid nsd file: expected <root>, but got <%s>", root.XMLName.Local,
+		)
+	}
+	k := keywords{
+		while:   orDefault(root.PreWhile, preWhile),
+		repeat:  orDefault(root.PreRepeat, preRepeat),
+		forLoop: orDefault(root.PreFor, preFor),
+		forIn:   orDefault(root.PreForIn, "foreach"),
+		ret:     orDefault(root.PreReturn, preReturn),
+		leave:   orDefault(root.PreLeave, preLeave),
+		exit:    orDefault(root.PreExit, preExit),
+	}
+
+	nodes, err := k.body(root.children("children"))
+	if err != nil {
+		return nil, err
+	}
+	return &syntax.Structogram{
+		Name:  strings.Join(splitText(root.Text), " "),
+		Nodes: nodes,
+	}, nil
+}
+
+// text returns the lines of the text of e joined by line breaks.
+func text(e element) string {
+	return strings.Join(splitText(e.Text), "\n")
+}
+
+// strip removes keyword from the start of the text of e.
+func strip(e element, keyword string) string {
+	value, _ := trimKeyword(text(e), keyword)
+	return value
+}
+
+// body translates the elements of a body, which gets an empty instruction if
+// there are none.
+func (k keywords) body(elements []element) ([]syntax.Node, error) {
+	nodes, err := k.elements(elements)
+	if len(nodes) == 0 && err == nil {
+		nodes = []syntax.Node{{NodeType: "instruction"}}
+	}
+	return nodes, err
+}
+
+func (k keywords) elements(elements []element) ([]syntax.Node, error) {
+	var nodes []syntax.Node
+	for _, e := range elements {
+		if e.Disabled == "1" {
+			continue
+		}
+		translated, err := k.element(e)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, translated...)
+	}
+	return nodes, nil
+}
+
+func (k keywords) element(e element) ([]syntax.Node, error) {
+	switch e.XMLName.Local {
+	case "instruction":
+		return []syntax.Node{{NodeType: "instruction", Value: text(e)}}, nil
+	case "call":
+		return []syntax.Node{{NodeType: "call", Value: text(e)}}, nil
+	case "jump":
+		return []syntax.Node{k.jump(text(e))}, nil
+	case "alternative":
+		return k.alternative(e)
+	case "while":
+		return k.loop("while", strip(e, k.while), e.children("qWhile"))
+	case "repeat":
+		return k.loop(
+			"dowhile", negate(strip(e, k.repeat)), e.children("qRepeat"),
+		)
+	case "for":
+		value := text(e)
+		if v, ok := trimKeyword(value, k.forIn); ok {
+			value = v
+		} else {
+			value = strip(e, k.forLoop)
+		}
+		return k.loop("for", value, e.children("qFor"))
+	case "case":
+		return k.caseElement(e)
+	case "parallel":
+		var branches []syntax.Node
+		for _, q := range e.Children {
+			if q.XMLName.Local != "qPara" {
+				continue
+			}
+			nodes, err := k.body(q.Children)
+			if err != nil {
+				return nil, err
+			}
+			branches = append(branches, syntax.Node{NodeType: "branch", Nodes: nodes})
+		}
+		return []syntax.Node{{NodeType: "parallel", Nodes: branches}}, nil
+	case "try":
+		return k.try(e)
+	}
+	return nil, fmt.Errorf("unsupported element <%s>", e.XMLName.Local)
+}
+
+// jump translates the text of a jump, which is a leave, a return, an exit
+// or a continue. An empty jump leaves the surrounding loop.
+func (k keywords) jump(text string) syntax.Node {
+	for _, j := range []struct{ keyword, nodeType string }{
+		{k.ret, "return"},
+		{k.leave, "break"},
+		{k.exit, "exit"},
+		{"continue", "continue"},
+	} {
+		if value, ok := trimKeyword(text, j.keyword); ok {
+			return syntax.Node{NodeType: j.nodeType, Value: value}
+		}
+	}
+	return syntax.Node{NodeType: "break", Value: text}
+}
+
+func (k keywords) loop(
+	nodeType string, value string, elements []element,
+) ([]syntax.Node, error) {
+	nodes, err := k.body(elements)
+	if err != nil {
+		return nil, err
+	}
+	return []syntax.Node{{NodeType: nodeType, Value: value, Nodes: nodes}}, nil
+}
+
+// alternative translates an alternative into an if, which is followed by an
+// else if the false branch is not empty.
+func (k keywords) alternative(e element) ([]syntax.Node, error) {
+	trueNodes, err := k.body(e.children("qTrue"))
+	if err != nil {
+		return nil, err
+	}
+	nodes := []syntax.Node{{NodeType: "if", Value: text(e), Nodes: trueNodes}}
+	falseNodes, err := k.elements(e.children("qFalse"))
+	if err != nil {
+		return nil, err
+	}
+	if len(falseNodes) > 0 {
+		nodes = append(nodes, syntax.Node{NodeType: "else", Nodes: falseNodes})
+	}
+	return nodes, nil
+}
+
+// caseElement translates a case into a switch. The first line of its text
+// is the value of the switch, the following lines are the values of the
+// cases, where the last line is the default case, unless it is %.
+func (k keywords) caseElement(e element) ([]syntax.Node, error) {
+	lines := splitText(e.Text)
+	var bodies [][]element
+	for _, q := range e.Children {
+		if q.XMLName.Local == "qCase" {
+			bodies = append(bodies, q.Children)
+		}
+	}
+	if len(lines) < 2 || len(bodies) != len(lines)-1 {
+		return nil, fmt.Errorf(
+			"invalid nsd file: case %q has %d branches, but %d values",
+			lines[0], len(bodies), len(lines)-1,
+		)
+	}
+
+	var cases []syntax.Node
+	for i, body := range bodies {
+		nodes, err := k.body(body)
+		if err != nil {
+			return nil, err
+		}
+		value := lines[i+1]
+		if i == len(bodies)-1 {
+			if value != "%" {
+				cases = append(cases, syntax.Node{NodeType: "default", Nodes: nodes})
+			}
+			break
+		}
+		cases = append(cases, syntax.Node{NodeType: "case", Value: value, Nodes: nodes})
+	}
+	if len(cases) == 0 || cases[len(cases)-1].NodeType != "default" {
+		cases = append(cases, syntax.Node{
+			NodeType: "default",
+			Nodes:    []syntax.Node{{NodeType: "instruction"}},
+		})
+	}
+	return []syntax.Node{{NodeType: "switch", Value: lines[0], Nodes: cases}}, nil
+}
+
+// try translates a try, whose text is the variable of its catch. A catch
+// without a variable and body, or a finally without a body, is left out.
+func (k keywords) try(e element) ([]syntax.Node, error) {
+	nodes, err := k.body(e.children("qTry"))
+	if err != nil {
+		return nil, err
+	}
+	result := []syntax.Node{{NodeType: "try", Nodes: nodes}}
+
+	catchNodes, err := k.elements(e.children("qCatch"))
+	if err != nil {
+		return nil, err
+	}
+	finallyNodes, err := k.elements(e.children("qFinally"))
+	if err != nil {
+		return nil, err
+	}
+	if len(catchNodes) > 0 || text(e) != "" || len(finallyNodes) == 0 {
+		if len(catchNodes) == 0 {
+			catchNodes = []syntax.Node{{NodeType: "instruction"}}
+		}
+		result = append(result, syntax.Node{
+			NodeType: "catch", Value: text(e), Nodes: catchNodes,
+		})
+	}
+	if len(finallyNodes) > 0 {
+		result = append(result, syntax.Node{NodeType: "finally", Nodes: finallyNodes})
+	}
+	return result, nil
+}
@@ -0,0 +1,247 @@
+package nsd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/JSchrtke/structogen/syntax"
+)
+
+// writer writes the elements of an .nsd file into a buffer.
+type writer struct {
+	buf   bytes.Buffer
+	depth int
+}
+
+// Write writes s as a Structorizer .nsd file to w. A try can only be written
+// with at most one catch, a second one is reported as a syntax.Diagnostic.
+// Line breaks in the values of switches and cases, which are single lines in
+// Structorizer, are replaced by spaces.
+func Write(w io.Writer, s *syntax.Structogram) error {
+	wr := &writer{}
+	wr.buf.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	rootType := "program"
+	if strings.Contains(s.Name, "(") {
+		rootType = "sub"
+	}
+	wr.open("root", fmt.Sprintf(
+		`xmlns:nsd="https://structorizer.fisch.lu" version="3.30" `+
+			`preWhile=%s preRepeat=%s preFor=%s preReturn=%s preLeave=%s `+
+			`preExit=%s text=%s comment="" color="ffffff" type="%s" style="nice"`,
+		attr(preWhile), attr(preRepeat), attr(preFor), attr(preReturn),
+		attr(preLeave), attr(preExit), attr(joinText(singleLine(s.Name))),
+		rootType,
+	))
+	wr.open("children", "")
+	if err := wr.nodes(s.Nodes); err != nil {
+		return err
+	}
+	wr.close("children")
+	wr.close("root")
+
+	_, err := w.Write(wr.buf.Bytes())
+	return err
+}
+
+// attr quotes and escapes the value of an attribute.
+func attr(value string) string {
+	var b bytes.Buffer
+	xml.EscapeText(&b, []byte(value))
+	return `"` + b.String() + `"`
+}
+
+// singleLine replaces the line breaks in s by spaces.
+func singleLine(s string) string {
+	return strings.Join(strings.Fields(strings.ReplaceAll(s, "\n", " ")), " ")
+}
+
+func (wr *writer) indent() {
+	wr.buf.WriteString(strings.Repeat("\t", wr.depth))
+}
+
+// open writes the start tag of an element with the given attributes.
+func (wr *writer) open(name string, attrs string) {
+	wr.indent()
+	if attrs == "" {
+		wr.buf.WriteString("<" + name + ">\n")
+	} else {
+		wr.buf.WriteString("<" + name + " " + attrs + ">\n")
+	}
+	wr.depth++
+}
+
+func (wr *writer) close(name string) {
+	wr.depth--
+	wr.indent()
+	wr.buf.WriteString("</" + name + ">\n")
+}
+
+// elementAttrs returns the attributes of an element with the given text
+// lines.
+func elementAttrs(lines ...string) string {
+	return "text=" + attr(joinText(lines...)) +
+		` comment="" color="ffffff" disabled="0"`
+}
+
+// simple writes an element without children.
+func (wr *writer) simple(name string, lines ...string) {
+	wr.indent()
+	wr.buf.WriteString(
+		"<" + name + " " + elementAttrs(lines...) + "></" + name + ">\n",
+	)
+}
+
+// body writes nodes into a child element with the given name.
+func (wr *writer) body(name string, nodes []syntax.Node) error {
+	wr.open(name, "")
+	err := wr.nodes(nodes)
+	wr.close(name)
+	return err
+}
+
+// nodes writes nodes, merging else nodes into their if and catch and finally
+// nodes into their try.
+func (wr *writer) nodes(nodes []syntax.Node) error {
+	for i := 0; i < len(nodes); i++ {
+		n := nodes[i]
+		end := i + 1
+		switch n.NodeType {
+		case "if":
+			if end < len(nodes) && nodes[end].NodeType == "else" {
+				end++
+			}
+		case "try":
+			for end < len(nodes) && (nodes[end].NodeType == "catch" ||
+				nodes[end].NodeType == "finally") {
+				end++
+			}
+		}
+		if err := wr.node(n, nodes[i+1:end]); err != nil {
+			return err
+		}
+		i = end - 1
+	}
+	return nil
+}
+
+func (wr *writer) node(n syntax.Node, merged []syntax.Node) error {
+	switch n.NodeType {
+	case "instruction", "call":
+		wr.simple(n.NodeType, strings.Split(n.Value, "\n")...)
+	case "break":
+		wr.simple("jump", jumpText(preLeave, n.Value))
+	case "return":
+		wr.simple("jump", jumpText(preReturn, n.Value))
+	case "exit":
+		wr.simple("jump", jumpText(preExit, n.Value))
+	case "continue":
+		wr.simple("jump", jumpText("continue", n.Value))
+	case "if":
+		wr.open("alternative", elementAttrs(strings.Split(n.Value, "\n")...))
+		if err := wr.body("qTrue", n.Nodes); err != nil {
+			return err
+		}
+		var elseNodes []syntax.Node
+		if len(merged) > 0 {
+			elseNodes = merged[0].Nodes
+		}
+		if err := wr.body("qFalse", elseNodes); err != nil {
+			return err
+		}
+		wr.close("alternative")
+	case "while":
+		return wr.loop("while", "qWhile", preWhile+n.Value, n.Nodes, "")
+	case "dowhile":
+		return wr.loop("repeat", "qRepeat", preRepeat+negate(n.Value), n.Nodes, "")
+	case "for":
+		return wr.loop("for", "qFor", preFor+n.Value, n.Nodes, ` style="FREETEXT"`)
+	case "switch":
+		lines := []string{singleLine(n.Value)}
+		for _, c := range n.Nodes {
+			if c.NodeType == "default" {
+				lines = append(lines, "default")
+			} else {
+				lines = append(lines, singleLine(c.Value))
+			}
+		}
+		wr.open("case", elementAttrs(lines...))
+		for _, c := range n.Nodes {
+			if err := wr.body("qCase", c.Nodes); err != nil {
+				return err
+			}
+		}
+		wr.close("case")
+	case "parallel":
+		wr.open("parallel", elementAttrs(fmt.Sprint(len(n.Nodes))))
+		for _, b := range n.Nodes {
+			if err := wr.body("qPara", b.Nodes); err != nil {
+				return err
+			}
+		}
+		wr.close("parallel")
+	case "try":
+		return wr.try(n, merged)
+	}
+	return nil
+}
+
+// jumpText returns the text of a jump with the given keyword.
+func jumpText(keyword string, value string) string {
+	if value == "" {
+		return keyword
+	}
+	return keyword + " " + value
+}
+
+func (wr *writer) loop(
+	name string, bodyName string, value string, nodes []syntax.Node,
+	attrs string,
+) error {
+	wr.open(name, elementAttrs(strings.Split(value, "\n")...)+attrs)
+	if err := wr.body(bodyName, nodes); err != nil {
+		return err
+	}
+	wr.close(name)
+	return nil
+}
+
+func (wr *writer) try(n syntax.Node, merged []syntax.Node) error {
+	var catch, finally *syntax.Node
+	for i, m := range merged {
+		switch {
+		case m.NodeType == "finally":
+			finally = &merged[i]
+		case catch != nil:
+			return syntax.Diagnostic{
+				Start:   m.Start,
+				End:     m.End,
+				Message: "Structorizer only supports one catch per try",
+			}
+		default:
+			catch = &merged[i]
+		}
+	}
+
+	var variable string
+	var catchNodes, finallyNodes []syntax.Node
+	if catch != nil {
+		variable, catchNodes = singleLine(catch.Value), catch.Nodes
+	}
+	if finally != nil {
+		finallyNodes = finally.Nodes
+	}
+	wr.open("try", elementAttrs(variable))
+	for _, b := range []struct {
+		name  string
+		nodes []syntax.Node
+	}{{"qTry", n.Nodes}, {"qCatch", catchNodes}, {"qFinally", finallyNodes}} {
+		if err := wr.body(b.name, b.nodes); err != nil {
+			return err
+		}
+	}
+	wr.close("try")
+	return nil
+}
@@ -0,0 +1,154 @@
+package project
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/JSchrtke/structogen/syntax"
+)
+
+// Loader parses files along with the files they import. An import is looked
+// up relative to the directory of the importing file first, and then in the
+// directories of SearchPath, in order. Every file is loaded once, even if it
+// is imported several times, but a file that imports itself, directly or
+// through other files, is reported as an import cycle.
+type Loader struct {
+	SearchPath []string
+	// ReadFile reads the file at a path. If it is nil, os.ReadFile is used.
+	ReadFile func(path string) ([]byte, error)
+
+	files    []*File
+	problems []Problem
+	// loaded maps the absolute paths of the loaded files onto them.
+	loaded map[string]*File
+	// loading are the files whose imports are being loaded, the outermost
+	// first.
+	loading []*File
+}
+
+// Files returns all files that were loaded so far, in the order in which
+// they were loaded.
+func (l *Loader) Files() []*File {
+	return l.files
+}
+
+// Problems returns the syntax errors of the loaded files and the imports
+// that could not be loaded.
+func (l *Loader) Problems() []Problem {
+	return l.problems
+}
+
+// Load parses source, which was read from path, and loads its imports. The
+// file is called name in problems. If path is empty, e.g. for stdin,
+// imports are looked up relative to the working directory. A file that was
+// already loaded, e.g. as the import of another one, is not loaded again.
+func (l *Loader) Load(name string, path string, source string) *File {
+	if f, ok := l.loaded[absolute(path)]; path != "" && ok {
+		return f
+	}
+	return l.load(name, path, source, nil)
+}
+
+func (l *Loader) load(
+	name string, path string, source string, chain []Location,
+) *File {
+	if l.loaded == nil {
+		l.loaded = map[string]*File{}
+	}
+	f := &File{Name: name, Path: path, Source: source, Chain: chain}
+	if path != "" {
+		l.loaded[absolute(path)] = f
+	}
+	l.files = append(l.files, f)
+
+	structograms, err := syntax.ParseAllTokens(syntax.Tokenize(source))
+	f.Structograms = structograms
+	if diagnostics, ok := err.(syntax.Diagnostics); ok {
+		for _, d := range diagnostics {
+			l.problems = append(
+				l.problems, Problem{File: f, Diagnostic: d, Chain: chain},
+			)
+		}
+	}
+
+	l.loading = append(l.loading, f)
+	for _, s := range structograms {
+		for _, imp := range s.Imports {
+			l.importFile(f, imp)
+		}
+	}
+	l.loading = l.loading[:len(l.loading)-1]
+	return f
+}
+
+// importFile loads the file that imp in from refers to, unless it was
+// loaded already.
+func (l *Loader) importFile(from *File, imp syntax.Import) {
+	report := func(message string) {
+		l.problems = append(l.problems, Problem{
+			File: from,
+			Diagnostic: syntax.Diagnostic{
+				Start: imp.Start, End: imp.End, Message: message,
+			},
+			Chain: from.Chain,
+		})
+	}
+
+	path, source, ok := l.find(from.Path, imp.Path)
+	if !ok {
+		report(fmt.Sprintf("can not find import %q", imp.Path))
+		return
+	}
+	for i, f := range l.loading {
+		if f.Path == "" || absolute(f.Path) != absolute(path) {
+			continue
+		}
+		var names []string
+		for _, f := range l.loading[i:] {
+			names = append(names, f.Name)
+		}
+		names = append(names, f.Name)
+		report("import cycle: " + strings.Join(names, " imports "))
+		return
+	}
+	if _, ok := l.loaded[absolute(path)]; ok {
+		return
+	}
+
+	at := Location{File: from, Position: imp.Start}
+	l.load(path, path, source, append([]Location{at}, from.Chain...))
+}
+
+// find reads the file that an import in the file at from refers to, and
+// returns its path and content.
+func (l *Loader) find(from string, path string) (string, string, bool) {
+	candidates := []string{path}
+	if !filepath.IsAbs(path) {
+		candidates = []string{filepath.Join(filepath.Dir(from), path)}
+		for _, dir := range l.SearchPath {
+			candidates = append(candidates, filepath.Join(dir, path))
+		}
+	}
+
+	readFile := l.ReadFile
+	if readFile == nil {
+		readFile = os.ReadFile
+	}
+	for _, candidate := range candidates {
+		if source, err := readFile(candidate); err == nil {
+			return candidate, string(source), true
+		}
+	}
+	return "", "", false
+}
+
+// absolute returns the absolute form of path, which identifies a file
+// regardless of the directory it is given relative to.
+func absolute(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return filepath.Clean(path)
+}
@@ -0,0 +1,119 @@
+package project
+
+import (
+	"os"
+	"testing"
+)
+
+// fakeFiles returns a ReadFile function that reads from files instead of
+// the file system.
+func fakeFiles(files map[string]string) func(string) ([]byte, error) {
+	return func(path string) ([]byte, error) {
+		if source, ok := files[path]; ok {
+			return []byte(source), nil
+		}
+		return nil, os.ErrNotExist
+	}
+}
+
+func checkFileNames(t *testing.T, files []*File, expected ...string) {
+	t.Helper()
+	var actual []string
+	for _, f := range files {
+		actual = append(actual, f.Name)
+	}
+	if len(actual) != len(expected) {
+		t.Fatalf("Expected files %v, but got %v", expected, actual)
+	}
+	for i := range actual {
+		if actual[i] != expected[i] {
+			t.Errorf("Expected files %v, but got %v", expected, actual)
+			return
+		}
+	}
+}
+
+func TestLoadResolvesImportsRelativeToTheImportingFile(t *testing.T) {
+	l := &Loader{ReadFile: fakeFiles(map[string]string{
+		"courses/common/sorting.str": `import("../shared/swap.str")
+name("sort(list)") call("swap(a, b)")`,
+		"courses/shared/swap.str": `name("swap(a, b)") call("tmp(a)")`,
+	})}
+	l.Load("courses/main.str", "courses/main.str", `import("common/sorting.str")
+name("main") call("sort(list)")`)
+
+	checkProblems(t, l.Problems())
+	checkFileNames(
+		t, l.Files(), "courses/main.str", "courses/common/sorting.str",
+		"courses/shared/swap.str",
+	)
+	checkProblems(
+		t, New(l.Files()).Check(),
+		"courses/shared/swap.str:1:20, call of unknown structogram \"tmp\"\n"+
+			"\timported from courses/common/sorting.str:1:1\n"+
+			"\timported from courses/main.str:1:1",
+	)
+}
+
+func TestLoadLooksUpImportsInTheSearchPath(t *testing.T) {
+	l := &Loader{
+		SearchPath: []string{"lib", "std"},
+		ReadFile: fakeFiles(map[string]string{
+			"std/sorting.str": `name("sort") instruction("a")`,
+		}),
+	}
+	l.Load("<stdin>", "", `import("sorting.str") name("main")`)
+
+	checkProblems(t, l.Problems())
+	checkFileNames(t, l.Files(), "<stdin>", "std/sorting.str")
+}
+
+func TestLoadLoadsFilesImportedSeveralTimesOnce(t *testing.T) {
+	l := &Loader{ReadFile: fakeFiles(map[string]string{
+		"a.str":    `import("base.str") name("a")`,
+		"b.str":    `import("./base.str") name("b")`,
+		"base.str": `name("base")`,
+	})}
+	l.Load("main.str", "main.str", `import("a.str") import("b.str") name("main")`)
+	l.Load("base.str", "base.str", `name("base")`)
+
+	checkProblems(t, l.Problems())
+	checkFileNames(t, l.Files(), "main.str", "a.str", "base.str", "b.str")
+}
+
+func TestLoadReportsImportCycles(t *testing.T) {
+	main := `import("a.str") name("main")`
+	l := &Loader{ReadFile: fakeFiles(map[string]string{
+		"main.str": main,
+		"a.str":    `import("b.str") name("a")`,
+		"b.str": `name("b")
+
+import("main.str") name("c")`,
+	})}
+	l.Load("main.str", "main.str", main)
+
+	checkProblems(
+		t, l.Problems(),
+		"b.str:3:1, import cycle: main.str imports a.str imports b.str imports main.str\n"+
+			"\timported from a.str:1:1\n"+
+			"\timported from main.str:1:1",
+	)
+}
+
+func TestLoadReportsErrorsWithTheImportChain(t *testing.T) {
+	l := &Loader{ReadFile: fakeFiles(map[string]string{
+		"lib/sorting.str": `import("missing.str")
+name("sort") if`,
+	})}
+	l.Load("main.str", "main.str", `name("x")
+
+import("lib/sorting.str") name("main")`)
+
+	checkProblems(
+		t, l.Problems(),
+		"lib/sorting.str:2:16, expected 'openParentheses', but got 'EOF'\n"+
+			"\timported from main.str:3:1",
+		"lib/sorting.str:1:1, can not find import \"missing.str\"\n"+
+			"\timported from main.str:3:1",
+	)
+}
@@ -0,0 +1,150 @@
+// Package project resolves the calls in structograms against the
+// structograms of a set of files, so that large algorithms can be split into
+// subprograms across files.
+package project
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/JSchrtke/structogen/syntax"
+)
+
+// File is a source file with the structograms that were parsed from it.
+// Path is the path the file was read from, Source its content and Chain the
+// import statements through which it was loaded, the innermost one first.
+// They are only known for files that were read by a Loader.
+type File struct {
+	Name         string
+	Path         string
+	Source       string
+	Structograms []*syntax.Structogram
+	Chain        []Location
+}
+
+// Definition is a structogram that calls can refer to, along with the file
+// it is defined in.
+type Definition struct {
+	File        *File
+	Structogram *syntax.Structogram
+}
+
+// Project is a set of files whose structograms can call each other.
+type Project struct {
+	Files       []*File
+	definitions map[string][]Definition
+}
+
+// Problem is an error in one of the files of a project, like a syntax error,
+// a missing import or a call that does not refer to exactly one structogram.
+// Chain is the chain of the file, see File.
+type Problem struct {
+	File       *File
+	Diagnostic syntax.Diagnostic
+	Chain      []Location
+}
+
+// Location is a position in a file.
+type Location struct {
+	File     *File
+	Position syntax.Position
+}
+
+func (l Location) String() string {
+	return fmt.Sprintf("%s:%d:%d", l.File.Name, l.Position.Line, l.Position.Column)
+}
+
+func (p Problem) Error() string {
+	message := fmt.Sprintf("%s:%s", p.File.Name, p.Diagnostic.Error())
+	for _, l := range p.Chain {
+		message += fmt.Sprintf("\n\timported from %s", l)
+	}
+	return message
+}
+
+// calledNamePattern matches the name of the function in the text of a
+// call, which is the identifier in front of the first opening parenthesis.
+var calledNamePattern = regexp.MustCompile(`([\p{L}_][\p{L}\p{N}_.]*)\s*\(`)
+
+// CalledName returns the name that a call or a structogram with the given
+// text is known by, which is the identifier in front of its parentheses, so
+// that call("x = sum(list)") refers to name("sum(list)"). Text without
+// parentheses is taken as the name itself.
+func CalledName(text string) string {
+	if m := calledNamePattern.FindStringSubmatch(text); m != nil {
+		return m[1]
+	}
+	return strings.TrimSpace(text)
+}
+
+// New returns a project of the given files.
+func New(files []*File) *Project {
+	p := &Project{Files: files, definitions: map[string][]Definition{}}
+	for _, f := range files {
+		for _, s := range f.Structograms {
+			name := CalledName(s.Name)
+			p.definitions[name] = append(
+				p.definitions[name], Definition{File: f, Structogram: s},
+			)
+		}
+	}
+	return p
+}
+
+// Resolve returns the structograms that a call with the given text refers
+// to, in the order of the files. Unless there is exactly one, the call can
+// not be linked.
+func (p *Project) Resolve(call string) []Definition {
+	return p.definitions[CalledName(call)]
+}
+
+// Check returns a problem for every call that refers to no structogram or
+// to several of them, in the order of the files.
+func (p *Project) Check() []Problem {
+	var problems []Problem
+	for _, f := range p.Files {
+		for _, s := range f.Structograms {
+			p.checkCalls(f, s.Nodes, &problems)
+		}
+	}
+	return problems
+}
+
+func (p *Project) checkCalls(f *File, nodes []syntax.Node, problems *[]Problem) {
+	for _, n := range nodes {
+		p.checkCalls(f, n.Nodes, problems)
+		if n.NodeType != "call" {
+			continue
+		}
+
+		var message string
+		definitions := p.Resolve(n.Value)
+		switch len(definitions) {
+		case 0:
+			message = fmt.Sprintf(
+				"call of unknown structogram %q", CalledName(n.Value),
+			)
+		case 1:
+			continue
+		default:
+			var places []string
+			for _, d := range definitions {
+				places = append(places, Location{
+					File: d.File, Position: d.Structogram.NameStart,
+				}.String())
+			}
+			message = fmt.Sprintf(
+				"call of %q is ambiguous, it is defined at %s",
+				CalledName(n.Value), strings.Join(places, " and "),
+			)
+		}
+		*problems = append(*problems, Problem{
+			File: f,
+			Diagnostic: syntax.Diagnostic{
+				Start: n.Start, End: n.End, Message: message,
+			},
+			Chain: f.Chain,
+		})
+	}
+}
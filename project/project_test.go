@@ -0,0 +1,92 @@
+package project
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/JSchrtke/structogen/syntax"
+)
+
+func checkOk(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Errorf("Did not expect any errors, but got %s", err.Error())
+	}
+}
+
+func parseFile(t *testing.T, name string, source string) *File {
+	t.Helper()
+	structograms, err := syntax.ParseAll(strings.NewReader(source))
+	checkOk(t, err)
+	return &File{Name: name, Structograms: structograms}
+}
+
+func checkProblems(t *testing.T, problems []Problem, expected ...string) {
+	t.Helper()
+	var actual []string
+	for _, p := range problems {
+		actual = append(actual, p.Error())
+	}
+	if strings.Join(actual, "\n") != strings.Join(expected, "\n") {
+		t.Errorf(
+			"Expected problems:\n%s\nbut got:\n%s",
+			strings.Join(expected, "\n"), strings.Join(actual, "\n"),
+		)
+	}
+}
+
+func TestCalledName(t *testing.T) {
+	for text, expected := range map[string]string{
+		"sort(list)":         "sort",
+		"x = Math.max(a, b)": "Math.max",
+		"  printAll  ":       "printAll",
+		"template name":      "template name",
+		"größe ( liste )":    "größe",
+		"(a + b)":            "(a + b)",
+	} {
+		if actual := CalledName(text); actual != expected {
+			t.Errorf(
+				"Wrong name for %q, expected %q, but got %q",
+				text, expected, actual,
+			)
+		}
+	}
+}
+
+func TestResolveFindsStructogramsInOtherFiles(t *testing.T) {
+	main := parseFile(t, "main.str", `name("main") call("total = sum(list)")`)
+	lib := parseFile(t, "lib.str", `name("max(a, b)") instruction("c")
+		name("sum(list)") instruction("d")`)
+	p := New([]*File{main, lib})
+
+	definitions := p.Resolve("total = sum(list)")
+	if len(definitions) != 1 {
+		t.Fatalf("Expected 1 definition, but got %d", len(definitions))
+	}
+	if definitions[0].File != lib ||
+		definitions[0].Structogram != lib.Structograms[1] {
+		t.Errorf("Resolved the wrong structogram")
+	}
+	checkProblems(t, p.Check())
+}
+
+func TestCheckReportsUnknownAndAmbiguousCalls(t *testing.T) {
+	main := parseFile(t, "main.str", `name("main")
+if ("a") {
+    call("sort(list)")
+} else {
+    call("shuffle(list)")
+}`)
+	first := parseFile(t, "first.str", `name("sort") instruction("a")`)
+	second := parseFile(t, "second.str", `name("a") instruction("b")
+
+name("sort(items)") instruction("c")`)
+	p := New([]*File{main, first, second})
+
+	checkProblems(
+		t, p.Check(),
+		`main.str:3:5, call of "sort" is ambiguous, it is defined at `+
+			`first.str:1:1 and second.str:3:1`,
+		`main.str:5:5, call of unknown structogram "shuffle"`,
+	)
+}
@@ -0,0 +1,66 @@
+// Package render draws structograms as Nassi-Shneiderman diagrams in various
+// output formats, using the geometry computed by package layout.
+package render
+
+import (
+	"math"
+
+	"github.com/JSchrtke/structogen/layout"
+)
+
+// canvas is implemented by the output formats that a laid out structogram
+// can be drawn on. Coordinates have their origin in the top left corner, y
+// grows downwards.
+type canvas interface {
+	rect(x float64, y float64, w float64, h float64)
+	line(x1 float64, y1 float64, x2 float64, y2 float64)
+	// text draws s with its baseline at y. The anchor is one of "start",
+	// "middle" or "end" and determines where x is relative to the text.
+	text(s string, x float64, y float64, anchor string, bold bool)
+	// beginGroup starts the elements of b, which endGroup ends.
+	beginGroup(b *layout.Box)
+	endGroup()
+}
+
+// drawLabels draws the labels of a diagram or a box.
+func drawLabels(c canvas, labels []layout.Label) {
+	for _, l := range labels {
+		c.text(l.Text, l.X, l.Y, l.Anchor, l.Bold)
+	}
+}
+
+// drawDiagram draws the title and the boxes of d.
+func drawDiagram(c canvas, d *layout.Diagram) {
+	drawLabels(c, d.TitleLabels)
+	drawSequence(c, d.Body)
+}
+
+func drawSequence(c canvas, boxes []*layout.Box) {
+	drawRange(c, boxes, math.Inf(-1), math.Inf(1))
+}
+
+// drawRange draws the boxes that reach into the range from top to bottom,
+// and of their branches only the boxes that do as well.
+func drawRange(c canvas, boxes []*layout.Box, top float64, bottom float64) {
+	for _, b := range boxes {
+		if b.Y <= bottom && b.Y+b.H >= top {
+			drawBox(c, b, top, bottom)
+		}
+	}
+}
+
+func drawBox(c canvas, b *layout.Box, top float64, bottom float64) {
+	c.beginGroup(b)
+	c.rect(b.X, b.Y, b.W, b.H)
+	for _, l := range b.Connectors {
+		c.line(l.X1, l.Y1, l.X2, l.Y2)
+	}
+	drawLabels(c, b.Labels)
+	for _, br := range b.Branches {
+		if br.Framed {
+			c.rect(br.X, br.Y, br.W, br.H)
+		}
+		drawRange(c, br.Boxes, top, bottom)
+	}
+	c.endGroup()
+}
@@ -0,0 +1,322 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+
+	"github.com/JSchrtke/structogen/layout"
+	"github.com/JSchrtke/structogen/project"
+	"github.com/JSchrtke/structogen/syntax"
+)
+
+// HTMLFile is a source file of an html bundle, along with the structograms
+// that were parsed from it.
+type HTMLFile struct {
+	Name         string
+	Source       string
+	Structograms []*syntax.Structogram
+}
+
+// htmlWriter writes the structograms of a bundle as inline svg. It embeds
+// an svgCanvas for the shapes, but writes the groups of the boxes itself,
+// as they carry the data for the script.
+type htmlWriter struct {
+	svgCanvas
+	file    int
+	project *project.Project
+	// ids are the ids of the sections of the structograms, which calls link
+	// to.
+	ids map[*syntax.Structogram]string
+}
+
+// HTML writes the structograms of files to w as a single html page, which
+// needs nothing but a browser. The diagrams are inline svg, and a small
+// script makes them interactive: clicking a loop or branch collapses and
+// expands its bodies, hovering over a statement highlights its lines in the
+// source next to the diagram, and clicking a call jumps to the structogram
+// it calls, if exactly one structogram in the bundle has its name, see
+// project.CalledName.
+func HTML(w io.Writer, files []HTMLFile) error {
+	hw := &htmlWriter{ids: map[*syntax.Structogram]string{}}
+	hw.style = layout.DefaultStyle()
+	var names []string
+	var projectFiles []*project.File
+	for _, f := range files {
+		for _, s := range f.Structograms {
+			hw.ids[s] = fmt.Sprintf("structogram-%d", len(names))
+			names = append(names, s.Name)
+		}
+		projectFiles = append(projectFiles, &project.File{
+			Name: f.Name, Structograms: f.Structograms,
+		})
+	}
+	hw.project = project.New(projectFiles)
+	title := "Structograms"
+	if len(names) == 1 {
+		title = names[0]
+	}
+
+	buf := &hw.buf
+	fmt.Fprintf(buf, "<!DOCTYPE html>\n<html>\n<head>\n")
+	fmt.Fprintf(buf, "<meta charset=\"utf-8\">\n")
+	fmt.Fprintf(buf, "<title>%s</title>\n", html.EscapeString(title))
+	fmt.Fprintf(buf, "<style>\n%s</style>\n</head>\n<body>\n", htmlStyle)
+	if len(names) > 1 {
+		fmt.Fprintf(buf, "<nav>\n")
+		for i, name := range names {
+			fmt.Fprintf(
+				buf, "<a href=\"#structogram-%d\">%s</a>\n",
+				i, html.EscapeString(name),
+			)
+		}
+		fmt.Fprintf(buf, "</nav>\n")
+	}
+
+	fmt.Fprintf(buf, "<main>\n")
+	for i, f := range files {
+		hw.file = i
+		for _, s := range f.Structograms {
+			fmt.Fprintf(
+				buf, "<section id=\"%s\" data-file=\"%d\">\n", hw.ids[s], i,
+			)
+			hw.diagram(layout.Compute(s, hw.style))
+			fmt.Fprintf(buf, "</section>\n")
+		}
+	}
+	fmt.Fprintf(buf, "</main>\n<aside>\n")
+	for i, f := range files {
+		writeHTMLSource(buf, i, f)
+	}
+	fmt.Fprintf(buf, "</aside>\n<script>\n%s</script>\n", htmlScript)
+	fmt.Fprintf(buf, "</body>\n</html>\n")
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// writeHTMLSource writes the source of the file with the given index, one
+// element per line, so that the lines can be highlighted. Only the source
+// of the file under the mouse is shown.
+func writeHTMLSource(buf *bytes.Buffer, index int, f HTMLFile) {
+	class := "source"
+	if index == 0 {
+		class += " shown"
+	}
+	fmt.Fprintf(
+		buf, "<div class=\"%s\" data-file=\"%d\">\n<h2>%s</h2>\n<pre>",
+		class, index, html.EscapeString(f.Name),
+	)
+	lines := strings.Split(strings.TrimSuffix(f.Source, "\n"), "\n")
+	for i, l := range lines {
+		fmt.Fprintf(
+			buf, "<span class=\"line\" data-line=\"%d\">%s</span>",
+			i+1, html.EscapeString(l),
+		)
+	}
+	fmt.Fprintf(buf, "</pre>\n</div>\n")
+}
+
+func (hw *htmlWriter) diagram(d *layout.Diagram) {
+	fmt.Fprintf(
+		&hw.buf,
+		`<svg xmlns="http://www.w3.org/2000/svg" width="%spt" height="%spt" `+
+			`viewBox="0 0 %s %s" %s font-size="%s" `+
+			`fill="none" stroke="black" stroke-width="%s">`+"\n",
+		num(d.Width), num(d.Height), num(d.Width), num(d.Height),
+		svgFont, num(hw.style.FontSize), num(svgLineWidth),
+	)
+	drawLabels(hw, d.TitleLabels)
+	for _, b := range d.Body {
+		hw.box(b)
+	}
+	fmt.Fprintf(&hw.buf, "</svg>\n")
+}
+
+// box writes b as a group, whose data attributes tell the script the lines
+// of the node in the source and the target of calls. The boxes in each
+// branch are grouped as a body, which is followed by a hidden placeholder
+// that takes its place when the box is collapsed.
+func (hw *htmlWriter) box(b *layout.Box) {
+	class := "box " + b.NodeType
+	var target string
+	if b.NodeType == "call" {
+		if definitions := hw.project.Resolve(b.Value); len(definitions) == 1 {
+			target = hw.ids[definitions[0].Structogram]
+			class += " link"
+		}
+	}
+	if len(b.Branches) > 0 {
+		class += " collapsible"
+	}
+	fmt.Fprintf(
+		&hw.buf,
+		`<g class="%s" data-file="%d" data-start="%d" data-end="%d"`,
+		class, hw.file, b.Start.Line, b.End.Line,
+	)
+	if target != "" {
+		fmt.Fprintf(&hw.buf, ` data-target="%s"`, target)
+	}
+	fmt.Fprintf(&hw.buf, ">\n")
+
+	hw.rect(b.X, b.Y, b.W, b.H)
+	for _, l := range b.Connectors {
+		hw.line(l.X1, l.Y1, l.X2, l.Y2)
+	}
+	drawLabels(hw, b.Labels)
+	for _, br := range b.Branches {
+		if br.Framed {
+			hw.rect(br.X, br.Y, br.W, br.H)
+		}
+		fmt.Fprintf(&hw.buf, "<g class=\"body\">\n")
+		for _, child := range br.Boxes {
+			hw.box(child)
+		}
+		fmt.Fprintf(&hw.buf, "</g>\n<g class=\"placeholder\">\n")
+		fmt.Fprintf(
+			&hw.buf,
+			`<rect x="%s" y="%s" width="%s" height="%s" fill="white"/>`+"\n",
+			num(br.X), num(br.Y), num(br.W), num(br.H),
+		)
+		hw.text("…", br.X+br.W/2, br.Y+br.H/2, "middle", false)
+		fmt.Fprintf(&hw.buf, "</g>\n")
+	}
+	fmt.Fprintf(&hw.buf, "</g>\n")
+}
+
+const htmlStyle = `body {
+    margin: 0;
+    font-family: sans-serif;
+}
+nav {
+    padding: 8px 16px;
+    border-bottom: 1px solid #ccc;
+}
+nav a {
+    margin-right: 16px;
+}
+main {
+    display: inline-block;
+    vertical-align: top;
+    padding: 16px;
+}
+section {
+    margin-bottom: 24px;
+}
+section:target svg {
+    outline: 2px solid #4a90d9;
+}
+aside {
+    display: inline-block;
+    vertical-align: top;
+    position: sticky;
+    top: 0;
+    padding: 16px;
+}
+h2 {
+    font-size: 1em;
+}
+pre {
+    counter-reset: line;
+    margin: 0;
+}
+.source {
+    display: none;
+}
+.source.shown {
+    display: block;
+}
+.line {
+    display: block;
+    min-height: 1.2em;
+}
+.line::before {
+    counter-increment: line;
+    content: counter(line);
+    display: inline-block;
+    width: 3em;
+    margin-right: 1em;
+    text-align: right;
+    color: #999;
+}
+.line.highlighted {
+    background: #fff3b0;
+}
+text {
+    white-space: pre;
+}
+.box > rect {
+    pointer-events: visible;
+}
+.box.hover > rect:first-of-type {
+    fill: #fff3b0;
+}
+.collapsible, .link {
+    cursor: pointer;
+}
+.link > text {
+    fill: #1a5fb4;
+    text-decoration: underline;
+}
+.placeholder, .collapsed > .body {
+    display: none;
+}
+.collapsed > .placeholder {
+    display: inline;
+}
+`
+
+const htmlScript = `(function () {
+    "use strict";
+    var hovered = null;
+
+    function highlight(box, on) {
+        box.classList.toggle("hover", on);
+        var source = document.querySelector(
+            '.source[data-file="' + box.dataset.file + '"]'
+        );
+        if (on) {
+            document.querySelectorAll(".source.shown").forEach(function (s) {
+                s.classList.remove("shown");
+            });
+            source.classList.add("shown");
+        }
+        var start = Number(box.dataset.start);
+        var end = Number(box.dataset.end);
+        source.querySelectorAll(".line").forEach(function (line) {
+            var n = Number(line.dataset.line);
+            if (n >= start && n <= end) {
+                line.classList.toggle("highlighted", on);
+            }
+        });
+    }
+
+    document.addEventListener("mouseover", function (event) {
+        var box = event.target.closest(".box");
+        if (box === hovered) {
+            return;
+        }
+        if (hovered) {
+            highlight(hovered, false);
+        }
+        hovered = box;
+        if (box) {
+            highlight(box, true);
+        }
+    });
+
+    document.addEventListener("click", function (event) {
+        var box = event.target.closest(".box");
+        if (!box) {
+            return;
+        }
+        if (box.dataset.target) {
+            location.hash = box.dataset.target;
+        } else if (box.classList.contains("collapsible")) {
+            box.classList.toggle("collapsed");
+        }
+    });
+})();
+`
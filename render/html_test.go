@@ -0,0 +1,79 @@
+package render
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/JSchrtke/structogen/syntax"
+)
+
+func renderHTML(t *testing.T, sources ...string) string {
+	t.Helper()
+	var files []HTMLFile
+	for i, source := range sources {
+		files = append(files, HTMLFile{
+			Name:         string(rune('a'+i)) + ".str",
+			Source:       source,
+			Structograms: []*syntax.Structogram{parseForRender(t, source)},
+		})
+	}
+	var buf bytes.Buffer
+	checkOk(t, HTML(&buf, files))
+	return buf.String()
+}
+
+func TestHTMLTagsBoxesWithTheirSourceLines(t *testing.T) {
+	out := renderHTML(t, `name("a")
+instruction("b")
+if ("c") {
+    instruction("d")
+} else {
+    instruction("e")
+}`)
+	checkContains(
+		t, out,
+		`<g class="box instruction" data-file="0" data-start="2" data-end="2">`,
+	)
+	checkContains(
+		t, out,
+		`<g class="box if collapsible" data-file="0" data-start="3" data-end="7">`,
+	)
+	checkContains(t, out, `<span class="line" data-line="3">if (&#34;c&#34;) {</span>`)
+}
+
+func TestHTMLLinksCallsToStructogramsInTheBundle(t *testing.T) {
+	out := renderHTML(
+		t,
+		`name("main") call("result = sort(list)") call("print(result)")`,
+		`name("sort(list)") instruction("b")`,
+	)
+	checkContains(t, out, `<section id="structogram-1" data-file="1">`)
+	checkContains(t, out, `data-target="structogram-1"`)
+	checkContains(t, out, `<a href="#structogram-1">sort(list)</a>`)
+	if strings.Count(out, `class="box call link"`) != 1 {
+		t.Errorf("Expected only the call of sort to be a link")
+	}
+}
+
+func TestHTMLHasAPlaceholderForEveryBody(t *testing.T) {
+	out := renderHTML(t, `name("a")
+		while("b") {instruction("c")}
+		switch("d") {case("e") {instruction("f")} default {instruction("g")}}`)
+	if n := strings.Count(out, `<g class="placeholder">`); n != 3 {
+		t.Errorf("Expected 3 placeholders, but got %d", n)
+	}
+	if n := strings.Count(out, `<g class="body">`); n != 3 {
+		t.Errorf("Expected 3 bodies, but got %d", n)
+	}
+}
+
+func TestHTMLDoesNotLinkAmbiguousCalls(t *testing.T) {
+	out := renderHTML(
+		t, `name("main") call("sort(list)")`,
+		`name("sort(a)") instruction("b")`, `name("sort(b)") instruction("c")`,
+	)
+	if strings.Contains(out, "data-target") {
+		t.Errorf("Expected the ambiguous call not to be linked")
+	}
+}
@@ -0,0 +1,351 @@
+package render
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/JSchrtke/structogen/layout"
+	"github.com/JSchrtke/structogen/syntax"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+// The page size is A4, in points.
+const (
+	pdfPageWidth  = 595.28
+	pdfPageHeight = 841.89
+	pdfPageMargin = 40
+)
+
+// pdfCanvas implements canvas by writing pdf content stream operators. The
+// content stream has to set up a transformation that flips the y axis, see
+// PDF.
+type pdfCanvas struct {
+	buf     bytes.Buffer
+	regular *pdfFont
+	bold    *pdfFont
+	size    float64
+}
+
+// pdfPage is a horizontal slice of the body of a diagram, from the y
+// coordinate top to bottom, that fits on a single page when drawn at the
+// given scale.
+type pdfPage struct {
+	top    float64
+	bottom float64
+	scale  float64
+}
+
+// PDF writes s as a Nassi-Shneiderman diagram in pdf format to w.
+// Diagrams that are too wide for a page are scaled down, diagrams that are
+// too long are split onto multiple pages, between blocks or between the
+// statements in the bodies of compound blocks, see paginate.
+func PDF(w io.Writer, s *syntax.Structogram) error {
+	regular, err := loadPDFFont("GoRegular", goregular.TTF)
+	if err != nil {
+		return err
+	}
+	bold, err := loadPDFFont("GoBold", gobold.TTF)
+	if err != nil {
+		return err
+	}
+	style := layout.DefaultStyle()
+	d := layout.Compute(s, style)
+
+	var contents [][]byte
+	pages := paginate(d, &style)
+	for i, page := range pages {
+		c := &pdfCanvas{regular: regular, bold: bold, size: style.FontSize}
+
+		fmt.Fprintf(
+			&c.buf, "%s 0 0 %s %s %s cm\n",
+			num(page.scale), num(-page.scale),
+			num(pdfPageMargin), num(pdfPageHeight-pdfPageMargin),
+		)
+		fmt.Fprintf(&c.buf, "%s w\n", num(0.5/page.scale))
+		drawLabels(c, d.TitleLabels)
+		top := d.FrameY
+		if i > 0 {
+			c.text(
+				"(continued)", style.Margin,
+				style.Baseline(style.Margin, len(d.Title))-style.Padding,
+				"start", true,
+			)
+			top += style.LineHeight
+			c.text(
+				fmt.Sprintf("continued from page %d", i),
+				style.Margin, style.Baseline(top, 0), "start", false,
+			)
+			top += style.LineHeight + style.Padding
+		}
+		// The slice is clipped, leaving room for the lines on its edges, so
+		// that the parts of the blocks around it are hidden.
+		bottom := top + page.bottom - page.top
+		fmt.Fprintf(
+			&c.buf, "q %s %s %s %s re W n 1 0 0 1 0 %s cm\n",
+			num(-1), num(top-1), num(d.Width+2), num(bottom-top+2),
+			num(top-page.top),
+		)
+		drawRange(c, d.Body, page.top, page.bottom)
+		fmt.Fprintf(&c.buf, "Q\n")
+		if i < len(pages)-1 {
+			c.text(
+				fmt.Sprintf("continued on page %d", i+2),
+				d.Width-style.Margin, style.Baseline(bottom, 0), "end", false,
+			)
+		}
+		contents = append(contents, c.buf.Bytes())
+	}
+
+	return writePDF(w, contents, regular, bold)
+}
+
+// paginate splits the body of d into pages. Every page reserves space for
+// the title and the continuation markers. A page ends between two blocks or
+// between two statements in the bodies of a compound block, where it cuts
+// through no text. If there is no such place on a page, e.g. because a
+// single instruction is taller than a page, the page ends at the next one
+// and is scaled down to fit.
+func paginate(d *layout.Diagram, style *layout.Style) []pdfPage {
+	printableW := pdfPageWidth - 2*pdfPageMargin
+	printableH := pdfPageHeight - 2*pdfPageMargin
+	scale := math.Min(1, printableW/d.Width)
+	fixed := d.FrameY + style.LineHeight + 2*(style.LineHeight+style.Padding)
+	available := printableH/scale - fixed
+
+	end := d.FrameY
+	if len(d.Body) > 0 {
+		last := d.Body[len(d.Body)-1]
+		end = last.Y + last.H
+	}
+	var cuts []float64
+	for _, y := range boundaries(d.Body) {
+		if y > d.FrameY && y <= end && cutsSequence(d.Body, y) {
+			cuts = append(cuts, y)
+		}
+	}
+	sort.Float64s(cuts)
+
+	var pages []pdfPage
+	page := pdfPage{top: d.FrameY, bottom: end, scale: scale}
+	for page.bottom > page.top && page.bottom-page.top > available {
+		// The last cut that fits, or the first one if none does.
+		i := sort.SearchFloat64s(cuts, page.top+available+1e-9)
+		if i == 0 || cuts[i-1] <= page.top {
+			i = sort.SearchFloat64s(cuts, page.top+1e-9) + 1
+		}
+		page.bottom = cuts[i-1]
+		if page.bottom-page.top > available {
+			// Solve printableH/s - fixed = height for s.
+			page.scale = printableH / (page.bottom - page.top + fixed)
+		}
+		pages = append(pages, page)
+		if page.bottom == end {
+			return pages
+		}
+		page = pdfPage{top: page.bottom, bottom: end, scale: scale}
+	}
+	return append(pages, page)
+}
+
+// boundaries returns the top and bottom edges of boxes and all boxes in
+// their branches, which are the places where a page can end.
+func boundaries(boxes []*layout.Box) []float64 {
+	var ys []float64
+	for _, b := range boxes {
+		ys = append(ys, b.Y, b.Y+b.H)
+		for _, br := range b.Branches {
+			ys = append(ys, boundaries(br.Boxes)...)
+		}
+	}
+	return ys
+}
+
+// cutsSequence reports whether a page can end at y in a sequence of boxes,
+// which is the case if y cuts through none of them or only between the
+// statements of their branches.
+func cutsSequence(boxes []*layout.Box, y float64) bool {
+	for _, b := range boxes {
+		if b.Y < y && y < b.Y+b.H {
+			return cutsBox(b, y)
+		}
+	}
+	return true
+}
+
+// cutsBox reports whether a page can end at y inside of b. This is not the
+// case in texts, e.g. the header of a loop or the label of a catch, which
+// lie outside of the branches.
+func cutsBox(b *layout.Box, y float64) bool {
+	inBranch := false
+	for _, br := range b.Branches {
+		if br.Y <= y && y <= br.Y+br.H {
+			inBranch = true
+			if !cutsSequence(br.Boxes, y) {
+				return false
+			}
+		}
+	}
+	return inBranch
+}
+
+func (c *pdfCanvas) rect(x float64, y float64, w float64, h float64) {
+	fmt.Fprintf(&c.buf, "%s %s %s %s re S\n", num(x), num(y), num(w), num(h))
+}
+
+func (c *pdfCanvas) line(x1 float64, y1 float64, x2 float64, y2 float64) {
+	fmt.Fprintf(
+		&c.buf, "%s %s m %s %s l S\n", num(x1), num(y1), num(x2), num(y2),
+	)
+}
+
+func (c *pdfCanvas) text(
+	s string, x float64, y float64, anchor string, bold bool,
+) {
+	f, name := c.regular, "F1"
+	if bold {
+		f, name = c.bold, "F2"
+	}
+	switch anchor {
+	case "middle":
+		x -= f.width(s, c.size) / 2
+	case "end":
+		x -= f.width(s, c.size)
+	}
+	// The text matrix flips the y axis back, otherwise the glyphs would be
+	// upside down.
+	fmt.Fprintf(
+		&c.buf, "BT /%s %s Tf 1 0 0 -1 %s %s Tm (%s) Tj ET\n",
+		name, num(c.size), num(x), num(y), escapePDFString(s),
+	)
+}
+
+func (c *pdfCanvas) beginGroup(b *layout.Box) {}
+
+func (c *pdfCanvas) endGroup() {}
+
+// escapePDFString encodes s for use inside of a literal pdf string.
+func escapePDFString(s string) string {
+	var buf bytes.Buffer
+	for _, b := range encodeWinAnsi(s) {
+		switch b {
+		case '(', ')', '\\':
+			buf.WriteByte('\\')
+			buf.WriteByte(b)
+		default:
+			buf.WriteByte(b)
+		}
+	}
+	return buf.String()
+}
+
+// pdfWriter keeps track of the byte offsets of the objects it writes, which
+// are needed for the cross-reference table.
+type pdfWriter struct {
+	buf     bytes.Buffer
+	offsets []int
+}
+
+func (pw *pdfWriter) object(dict string) int {
+	pw.offsets = append(pw.offsets, pw.buf.Len())
+	n := len(pw.offsets)
+	fmt.Fprintf(&pw.buf, "%d 0 obj\n%s\nendobj\n", n, dict)
+	return n
+}
+
+// stream writes data as a compressed stream object. The dictionary entries
+// given in dict are added to the ones describing the stream itself.
+func (pw *pdfWriter) stream(dict string, data []byte) int {
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	_, _ = zw.Write(data)
+	_ = zw.Close()
+
+	pw.offsets = append(pw.offsets, pw.buf.Len())
+	n := len(pw.offsets)
+	fmt.Fprintf(
+		&pw.buf, "%d 0 obj\n<< /Length %d /Filter /FlateDecode %s>>\nstream\n",
+		n, compressed.Len(), dict,
+	)
+	pw.buf.Write(compressed.Bytes())
+	fmt.Fprintf(&pw.buf, "\nendstream\nendobj\n")
+	return n
+}
+
+func (pw *pdfWriter) font(f *pdfFont) int {
+	file := pw.stream(fmt.Sprintf("/Length1 %d ", len(f.ttf)), f.ttf)
+	descriptor := pw.object(fmt.Sprintf(
+		"<< /Type /FontDescriptor /FontName /%s /Flags 32 "+
+			"/FontBBox [%s %s %s %s] /ItalicAngle 0 /Ascent %s /Descent %s "+
+			"/CapHeight %s /StemV 80 /FontFile2 %d 0 R >>",
+		f.name, num(f.bbox[0]), num(f.bbox[1]), num(f.bbox[2]),
+		num(f.bbox[3]), num(f.ascent), num(f.descent), num(f.capHeight),
+		file,
+	))
+	var widths bytes.Buffer
+	for b := 0x20; b <= 0xff; b++ {
+		fmt.Fprintf(&widths, "%s ", num(f.widths[b]))
+	}
+	return pw.object(fmt.Sprintf(
+		"<< /Type /Font /Subtype /TrueType /BaseFont /%s /FirstChar 32 "+
+			"/LastChar 255 /Widths [%s] /Encoding /WinAnsiEncoding "+
+			"/FontDescriptor %d 0 R >>",
+		f.name, widths.String(), descriptor,
+	))
+}
+
+// writePDF writes a pdf document with one page per content stream.
+func writePDF(
+	w io.Writer, contents [][]byte, regular *pdfFont, bold *pdfFont,
+) error {
+	pw := pdfWriter{}
+	pw.buf.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	// The catalog and the page tree are written last, but they get the
+	// first object numbers, so the page objects can refer to their parent.
+	pw.offsets = []int{0, 0}
+	const catalog, pageTree = 1, 2
+	f1 := pw.font(regular)
+	f2 := pw.font(bold)
+
+	var kids bytes.Buffer
+	for _, content := range contents {
+		stream := pw.stream("", content)
+		page := pw.object(fmt.Sprintf(
+			"<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %s %s] "+
+				"/Resources << /Font << /F1 %d 0 R /F2 %d 0 R >> >> "+
+				"/Contents %d 0 R >>",
+			pageTree, num(pdfPageWidth), num(pdfPageHeight), f1, f2, stream,
+		))
+		fmt.Fprintf(&kids, "%d 0 R ", page)
+	}
+
+	pw.offsets[catalog-1] = pw.buf.Len()
+	fmt.Fprintf(
+		&pw.buf, "%d 0 obj\n<< /Type /Catalog /Pages %d 0 R >>\nendobj\n",
+		catalog, pageTree,
+	)
+	pw.offsets[pageTree-1] = pw.buf.Len()
+	fmt.Fprintf(
+		&pw.buf,
+		"%d 0 obj\n<< /Type /Pages /Kids [%s] /Count %d >>\nendobj\n",
+		pageTree, kids.String(), len(contents),
+	)
+
+	xref := pw.buf.Len()
+	fmt.Fprintf(&pw.buf, "xref\n0 %d\n0000000000 65535 f \n", len(pw.offsets)+1)
+	for _, offset := range pw.offsets {
+		fmt.Fprintf(&pw.buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(
+		&pw.buf, "trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%%%EOF\n",
+		len(pw.offsets)+1, catalog, xref,
+	)
+
+	_, err := w.Write(pw.buf.Bytes())
+	return err
+}
@@ -0,0 +1,166 @@
+package render
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/JSchrtke/structogen/layout"
+)
+
+func renderPDFForTest(t *testing.T, s string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	checkOk(t, PDF(&buf, parseForRender(t, s)))
+	return buf.Bytes()
+}
+
+// pdfPageContents returns the decompressed content streams of all pages.
+func pdfPageContents(t *testing.T, pdf []byte) []string {
+	t.Helper()
+	re := regexp.MustCompile(
+		`(?s)obj\n<< /Length (\d+) /Filter /FlateDecode >>\nstream\n`,
+	)
+	var contents []string
+	for _, m := range re.FindAllSubmatchIndex(pdf, -1) {
+		length, _ := strconv.Atoi(string(pdf[m[2]:m[3]]))
+		r, err := zlib.NewReader(bytes.NewReader(pdf[m[1] : m[1]+length]))
+		checkOk(t, err)
+		content, err := io.ReadAll(r)
+		checkOk(t, err)
+		contents = append(contents, string(content))
+	}
+	return contents
+}
+
+func TestPdfCrossReferenceTablePointsToObjects(t *testing.T) {
+	pdf := renderPDFForTest(t, `name("a") instruction("b")`)
+
+	m := regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`).FindSubmatch(pdf)
+	if m == nil {
+		t.Fatalf("Expected startxref at the end of the file")
+	}
+	xref, _ := strconv.Atoi(string(m[1]))
+	if !bytes.HasPrefix(pdf[xref:], []byte("xref\n")) {
+		t.Fatalf("startxref does not point to the xref table")
+	}
+	lines := strings.Split(string(pdf[xref:]), "\n")
+	count, _ := strconv.Atoi(strings.Fields(lines[1])[1])
+	for i := 1; i < count; i++ {
+		offset, _ := strconv.Atoi(lines[2+i][:10])
+		prefix := fmt.Sprintf("%d 0 obj\n", i)
+		if !bytes.HasPrefix(pdf[offset:], []byte(prefix)) {
+			t.Errorf("Object %d is not at offset %d", i, offset)
+		}
+	}
+}
+
+func TestPdfEmbedsFonts(t *testing.T) {
+	pdf := string(renderPDFForTest(t, `name("a") instruction("b")`))
+	checkContains(t, pdf, "/BaseFont /GoRegular")
+	checkContains(t, pdf, "/BaseFont /GoBold")
+	checkContains(t, pdf, "/FontFile2")
+}
+
+func TestPdfContainsText(t *testing.T) {
+	pdf := renderPDFForTest(
+		t, `name("a") instruction("f(x) = ä") if("b") {call("c")}`,
+	)
+	contents := pdfPageContents(t, pdf)
+	if len(contents) != 1 {
+		t.Fatalf("Expected 1 page, but got %d", len(contents))
+	}
+	checkContains(t, contents[0], `(f\(x\) = `+"\xe4"+`) Tj`)
+	checkContains(t, contents[0], "(true) Tj")
+	checkContains(t, contents[0], "(false) Tj")
+}
+
+func TestPdfSplitsLongDiagramsOntoPages(t *testing.T) {
+	source := `name("a")`
+	for i := 0; i < 100; i++ {
+		source += fmt.Sprintf(`instruction("%d")`, i)
+	}
+	contents := pdfPageContents(t, renderPDFForTest(t, source))
+	if len(contents) < 2 {
+		t.Fatalf("Expected multiple pages, but got %d", len(contents))
+	}
+	checkContains(t, contents[0], "(continued on page 2) Tj")
+	checkContains(t, contents[1], "(continued from page 1) Tj")
+	checkContains(t, contents[1], "(\\(continued\\)) Tj")
+	last := contents[len(contents)-1]
+	if strings.Contains(last, "continued on page") {
+		t.Errorf("Did not expect the last page to be continued")
+	}
+	checkContains(t, last, "(99) Tj")
+}
+
+func TestPdfSplitsLongBlocksBetweenTheirStatements(t *testing.T) {
+	source := `name("a") while("b") {`
+	for i := 0; i < 100; i++ {
+		source += fmt.Sprintf(`instruction("%d")`, i)
+	}
+	source += `}`
+	s := parseForRender(t, source)
+	style := layout.DefaultStyle()
+	d := layout.Compute(s, style)
+
+	pages := paginate(d, &style)
+	if len(pages) < 2 {
+		t.Fatalf("Expected multiple pages, but got %d", len(pages))
+	}
+	body := d.Body[0].Branches[0].Boxes
+	for i, page := range pages {
+		if page.scale != 1 {
+			t.Errorf("Expected page %d not to be scaled, got %v", i+1, page.scale)
+		}
+		if i == 0 {
+			continue
+		}
+		found := false
+		for _, b := range body {
+			found = found || b.Y == page.top
+		}
+		if !found {
+			t.Errorf("Expected page %d to start at a statement", i+1)
+		}
+	}
+
+	contents := pdfPageContents(t, renderPDFForTest(t, source))
+	if len(contents) != len(pages) {
+		t.Fatalf("Expected %d pages, but got %d", len(pages), len(contents))
+	}
+	checkContains(t, contents[0], "(b) Tj")
+	checkContains(t, contents[0], "(continued on page 2) Tj")
+	checkContains(t, contents[1], "(continued from page 1) Tj")
+	if strings.Contains(contents[0], "(99) Tj") {
+		t.Errorf("Did not expect the last statement on the first page")
+	}
+	checkContains(t, contents[len(contents)-1], "(99) Tj")
+}
+
+func TestPdfScalesDownStatementsTallerThanAPage(t *testing.T) {
+	source := `name("a") instruction("` + strings.Repeat(`x\n`, 100) + `")`
+	s := parseForRender(t, source)
+	style := layout.DefaultStyle()
+	pages := paginate(layout.Compute(s, style), &style)
+	if len(pages) != 1 || pages[0].scale >= 1 {
+		t.Errorf("Expected a single scaled down page, but got %v", pages)
+	}
+}
+
+func TestPdfScalesDownWideDiagrams(t *testing.T) {
+	pages := paginate(
+		&layout.Diagram{Width: 2 * pdfPageWidth}, &layout.Style{LineHeight: 10},
+	)
+	if len(pages) != 1 {
+		t.Fatalf("Expected 1 page, but got %d", len(pages))
+	}
+	if pages[0].scale >= 0.5 {
+		t.Errorf("Expected diagram to be scaled down, got %v", pages[0].scale)
+	}
+}
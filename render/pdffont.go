@@ -0,0 +1,118 @@
+package render
+
+import (
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+// pdfFont is a TrueType font that gets embedded into pdf files. Text is
+// encoded using the WinAnsiEncoding, so only the runes of Windows-1252 can
+// be displayed, everything else is replaced by a question mark.
+type pdfFont struct {
+	name      string
+	ttf       []byte
+	widths    [256]float64
+	ascent    float64
+	descent   float64
+	capHeight float64
+	bbox      [4]float64
+}
+
+// winAnsiSpecials maps the runes of the 0x80 to 0x9f range of
+// Windows-1252 to their byte values. All other bytes above 0x1f are the same
+// as their unicode code point.
+var winAnsiSpecials = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86,
+	'‡': 0x87, 'ˆ': 0x88, '‰': 0x89, 'Š': 0x8a, '‹': 0x8b, 'Œ': 0x8c,
+	'Ž': 0x8e, '‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95,
+	'–': 0x96, '—': 0x97, '˜': 0x98, '™': 0x99, 'š': 0x9a, '›': 0x9b,
+	'œ': 0x9c, 'ž': 0x9e, 'Ÿ': 0x9f,
+}
+
+func winAnsiByte(r rune) byte {
+	if r >= 0x20 && r < 0x7f || r >= 0xa0 && r <= 0xff {
+		return byte(r)
+	}
+	if b, ok := winAnsiSpecials[r]; ok {
+		return b
+	}
+	return '?'
+}
+
+func winAnsiRune(b byte) rune {
+	for r, v := range winAnsiSpecials {
+		if v == b {
+			return r
+		}
+	}
+	return rune(b)
+}
+
+func encodeWinAnsi(s string) []byte {
+	var encoded []byte
+	for _, r := range s {
+		encoded = append(encoded, winAnsiByte(r))
+	}
+	return encoded
+}
+
+// loadPDFFont parses ttf and extracts the metrics needed for embedding it.
+// All metrics are in thousandths of an em, as is customary in pdf files.
+func loadPDFFont(name string, ttf []byte) (*pdfFont, error) {
+	f, err := sfnt.Parse(ttf)
+	if err != nil {
+		return nil, err
+	}
+	var buf sfnt.Buffer
+	unitsPerEm := float64(f.UnitsPerEm())
+	ppem := fixed.I(int(f.UnitsPerEm()))
+	scale := func(v fixed.Int26_6) float64 {
+		return float64(v) / 64 * 1000 / unitsPerEm
+	}
+
+	pf := &pdfFont{name: name, ttf: ttf}
+	for b := 0x20; b <= 0xff; b++ {
+		r := winAnsiRune(byte(b))
+		if b >= 0x7f && b < 0xa0 && r == rune(b) {
+			continue
+		}
+		idx, err := f.GlyphIndex(&buf, r)
+		if err != nil {
+			return nil, err
+		}
+		advance, err := f.GlyphAdvance(&buf, idx, ppem, font.HintingNone)
+		if err != nil {
+			return nil, err
+		}
+		pf.widths[b] = scale(advance)
+	}
+
+	metrics, err := f.Metrics(&buf, ppem, font.HintingNone)
+	if err != nil {
+		return nil, err
+	}
+	pf.ascent = scale(metrics.Ascent)
+	pf.descent = -scale(metrics.Descent)
+	pf.capHeight = scale(metrics.CapHeight)
+
+	// The font package has its y axis pointing down, pdf has it pointing up.
+	bounds, err := f.Bounds(&buf, ppem, font.HintingNone)
+	if err != nil {
+		return nil, err
+	}
+	pf.bbox = [4]float64{
+		scale(bounds.Min.X), -scale(bounds.Max.Y),
+		scale(bounds.Max.X), -scale(bounds.Min.Y),
+	}
+	return pf, nil
+}
+
+// width returns the width of s when set in this font at the given size.
+func (f *pdfFont) width(s string, fontSize float64) float64 {
+	var w float64
+	for _, b := range encodeWinAnsi(s) {
+		w += f.widths[b]
+	}
+	return w * fontSize / 1000
+}
@@ -0,0 +1,190 @@
+package render
+
+import (
+	"errors"
+	"image"
+	"image/draw"
+	"image/png"
+	"io"
+	"math"
+
+	"github.com/JSchrtke/structogen/layout"
+	"github.com/JSchrtke/structogen/syntax"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/image/vector"
+)
+
+// pngLineWidth is the width of the lines in png images, in points. At
+// 96 dpi it is a single pixel.
+const pngLineWidth = 0.75
+
+// pngCanvas implements canvas on an image. Lines are collected as polygons
+// in a rasterizer and filled all at once, texts are drawn on top of them
+// afterwards.
+type pngCanvas struct {
+	img       *image.RGBA
+	lines     *vector.Rasterizer
+	texts     []layout.Label
+	regular   font.Face
+	bold      font.Face
+	scale     float64
+	lineWidth float64
+}
+
+// PNG writes s as a Nassi-Shneiderman diagram in png format to w. The
+// diagram has the same layout as the pdf output and is rasterized at dpi
+// pixels per inch, multiplied by scale. Diagonal lines and text are
+// anti-aliased, horizontal and vertical lines are aligned to the pixels.
+// If transparent is set, the background is left transparent instead of
+// white.
+func PNG(
+	w io.Writer, s *syntax.Structogram, dpi float64, scale float64,
+	transparent bool,
+) error {
+	if dpi <= 0 || scale <= 0 {
+		return errors.New("dpi and scale have to be positive")
+	}
+	regular, err := opentype.Parse(goregular.TTF)
+	if err != nil {
+		return err
+	}
+	bold, err := opentype.Parse(gobold.TTF)
+	if err != nil {
+		return err
+	}
+	style := layout.DefaultStyle()
+	d := layout.Compute(s, style)
+
+	// One point is 1/72 inch.
+	k := dpi / 72 * scale
+	width := int(math.Ceil(d.Width * k))
+	height := int(math.Ceil(d.Height * k))
+	c := newPNGCanvas(width, height, k)
+	if c.regular, err = pngFace(regular, style.FontSize*scale, dpi); err != nil {
+		return err
+	}
+	if c.bold, err = pngFace(bold, style.FontSize*scale, dpi); err != nil {
+		return err
+	}
+	if !transparent {
+		draw.Draw(c.img, c.img.Bounds(), image.White, image.Point{}, draw.Src)
+	}
+
+	drawDiagram(c, d)
+	c.finish()
+	return png.Encode(w, c.img)
+}
+
+// newPNGCanvas returns a transparent canvas of the given size in pixels, on
+// which a point is k pixels.
+func newPNGCanvas(width int, height int, k float64) *pngCanvas {
+	return &pngCanvas{
+		img:       image.NewRGBA(image.Rect(0, 0, width, height)),
+		lines:     vector.NewRasterizer(width, height),
+		scale:     k,
+		lineWidth: math.Max(1, math.Round(pngLineWidth*k)),
+	}
+}
+
+// finish draws the collected lines and texts onto the image.
+func (c *pngCanvas) finish() {
+	c.lines.Draw(c.img, c.img.Bounds(), image.Black, image.Point{})
+	for _, l := range c.texts {
+		c.drawText(l)
+	}
+}
+
+// pngFace returns a face of f at the given size in points. Hinting is
+// disabled, so that the width of text is proportional to the size, like in
+// the layout.
+func pngFace(f *opentype.Font, size float64, dpi float64) (font.Face, error) {
+	return opentype.NewFace(f, &opentype.FaceOptions{
+		Size: size, DPI: dpi, Hinting: font.HintingNone,
+	})
+}
+
+// pixel converts a coordinate of the layout to pixels.
+func (c *pngCanvas) pixel(v float64) float64 {
+	return v * c.scale
+}
+
+// fill adds the polygon with the given corners to the lines. All polygons
+// have to be in the same orientation, or overlapping parts cancel out.
+func (c *pngCanvas) fill(points ...[2]float64) {
+	c.lines.MoveTo(float32(points[0][0]), float32(points[0][1]))
+	for _, p := range points[1:] {
+		c.lines.LineTo(float32(p[0]), float32(p[1]))
+	}
+	c.lines.ClosePath()
+}
+
+func (c *pngCanvas) rect(x float64, y float64, w float64, h float64) {
+	c.line(x, y, x+w, y)
+	c.line(x+w, y, x+w, y+h)
+	c.line(x, y+h, x+w, y+h)
+	c.line(x, y, x, y+h)
+}
+
+func (c *pngCanvas) line(x1 float64, y1 float64, x2 float64, y2 float64) {
+	x1, y1, x2, y2 = c.pixel(x1), c.pixel(y1), c.pixel(x2), c.pixel(y2)
+	half := c.lineWidth / 2
+
+	if x1 == x2 || y1 == y2 {
+		// Snap the edges to whole pixels to keep the line sharp, and
+		// extend it by half its width to close the corners of boxes.
+		left := math.Round(math.Min(x1, x2) - half)
+		top := math.Round(math.Min(y1, y2) - half)
+		right := math.Round(math.Max(x1, x2) - half + c.lineWidth)
+		bottom := math.Round(math.Max(y1, y2) - half + c.lineWidth)
+		c.fill(
+			[2]float64{left, top}, [2]float64{right, top},
+			[2]float64{right, bottom}, [2]float64{left, bottom},
+		)
+		return
+	}
+
+	// The corners are offset by half the width, perpendicular to the line.
+	length := math.Hypot(x2-x1, y2-y1)
+	nx := (y2 - y1) / length * half
+	ny := -(x2 - x1) / length * half
+	c.fill(
+		[2]float64{x1 + nx, y1 + ny}, [2]float64{x2 + nx, y2 + ny},
+		[2]float64{x2 - nx, y2 - ny}, [2]float64{x1 - nx, y1 - ny},
+	)
+}
+
+func (c *pngCanvas) text(
+	s string, x float64, y float64, anchor string, bold bool,
+) {
+	c.texts = append(c.texts, layout.Label{
+		Text: s, X: x, Y: y, Anchor: anchor, Bold: bold,
+	})
+}
+
+func (c *pngCanvas) drawText(l layout.Label) {
+	face := c.regular
+	if l.Bold {
+		face = c.bold
+	}
+	d := &font.Drawer{Dst: c.img, Src: image.Black, Face: face}
+	x := c.pixel(l.X)
+	switch l.Anchor {
+	case "middle":
+		x -= float64(d.MeasureString(l.Text)) / 64 / 2
+	case "end":
+		x -= float64(d.MeasureString(l.Text)) / 64
+	}
+	d.Dot = fixed.Point26_6{
+		X: fixed.Int26_6(math.Round(x * 64)),
+		Y: fixed.Int26_6(math.Round(c.pixel(l.Y) * 64)),
+	}
+	d.DrawString(l.Text)
+}
+
+func (c *pngCanvas) beginGroup(b *layout.Box) {}
+
+func (c *pngCanvas) endGroup() {}
@@ -0,0 +1,99 @@
+package render
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"testing"
+)
+
+func renderPNG(
+	t *testing.T, source string, dpi float64, scale float64,
+	transparent bool,
+) image.Image {
+	t.Helper()
+	var buf bytes.Buffer
+	checkOk(t, PNG(&buf, parseForRender(t, source), dpi, scale, transparent))
+	img, err := png.Decode(&buf)
+	checkOk(t, err)
+	return img
+}
+
+// gray returns the brightness of the pixel at x, y from 0 to 0xffff and its
+// opacity.
+func gray(img image.Image, x int, y int) (uint32, uint32) {
+	r, g, b, a := img.At(x, y).RGBA()
+	return (r + g + b) / 3, a
+}
+
+func TestPNGScalesWithDpiAndScale(t *testing.T) {
+	source := `name("a") instruction("b")`
+	small := renderPNG(t, source, 72, 1, false).Bounds()
+	large := renderPNG(t, source, 144, 1, false).Bounds()
+	scaled := renderPNG(t, source, 72, 2, false).Bounds()
+
+	if large.Dx() < 2*small.Dx()-1 || large.Dx() > 2*small.Dx() {
+		t.Errorf(
+			"Expected width of about %d, but got %d", 2*small.Dx(), large.Dx(),
+		)
+	}
+	if scaled != large {
+		t.Errorf("Expected size %v, but got %v", large, scaled)
+	}
+}
+
+func TestPNGFillsTheBackgroundUnlessTransparent(t *testing.T) {
+	source := `name("a") instruction("b")`
+	brightness, alpha := gray(renderPNG(t, source, 96, 1, false), 0, 0)
+	if brightness != 0xffff || alpha != 0xffff {
+		t.Errorf("Expected a white corner, but got %x, %x", brightness, alpha)
+	}
+	_, alpha = gray(renderPNG(t, source, 96, 1, true), 0, 0)
+	if alpha != 0 {
+		t.Errorf("Expected a transparent corner, but got alpha %x", alpha)
+	}
+}
+
+// partialPixels counts the pixels of img that are neither fully transparent
+// nor fully opaque.
+func partialPixels(img *image.RGBA) int {
+	partial := 0
+	for i := 3; i < len(img.Pix); i += 4 {
+		if img.Pix[i] != 0 && img.Pix[i] != 0xff {
+			partial++
+		}
+	}
+	return partial
+}
+
+func TestPNGAlignsStraightLinesToPixels(t *testing.T) {
+	c := newPNGCanvas(40, 40, 1.3)
+	c.rect(0.2, 0.7, 20.3, 10.1)
+	c.line(5.5, 0.7, 5.5, 10.8)
+	c.finish()
+
+	if n := partialPixels(c.img); n != 0 {
+		t.Errorf("Expected only sharp pixels, but got %d blurred ones", n)
+	}
+	if _, alpha := gray(c.img, int(c.pixel(5.5)), int(c.pixel(5))); alpha != 0xffff {
+		t.Errorf("Expected the vertical line to be drawn")
+	}
+}
+
+func TestPNGAntiAliasesDiagonals(t *testing.T) {
+	c := newPNGCanvas(40, 40, 1.3)
+	c.line(0, 0, 20, 7)
+	c.finish()
+
+	if partialPixels(c.img) == 0 {
+		t.Errorf("Expected anti-aliased pixels along the diagonal")
+	}
+}
+
+func TestPNGRejectsNonPositiveDpi(t *testing.T) {
+	var buf bytes.Buffer
+	err := PNG(&buf, parseForRender(t, `name("a")`), 0, 1, false)
+	if err == nil {
+		t.Errorf("Expected an error for dpi 0")
+	}
+}
@@ -0,0 +1,250 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/JSchrtke/structogen/layout"
+	"github.com/JSchrtke/structogen/syntax"
+)
+
+// mmPerPoint converts points, the unit of the layout, to millimeters,
+// the unit of struktex.
+const mmPerPoint = 25.4 / 72
+
+// latexEscapes are the replacements for the characters that are special
+// in LaTeX, or that are missing from its default font encoding.
+var latexEscapes = strings.NewReplacer(
+	`\`, `\textbackslash{}`,
+	`{`, `\{`,
+	`}`, `\}`,
+	`$`, `\$`,
+	`&`, `\&`,
+	`#`, `\#`,
+	`%`, `\%`,
+	`_`, `\_`,
+	`^`, `\textasciicircum{}`,
+	`~`, `\textasciitilde{}`,
+	`<`, `\textless{}`,
+	`>`, `\textgreater{}`,
+	`|`, `\textbar{}`,
+	`"`, `\textquotedbl{}`,
+)
+
+// escapeLaTeX escapes the special characters of LaTeX in s. Lines are
+// joined with sep, as line breaks are only allowed in some arguments of
+// struktex macros.
+func escapeLaTeX(s string, sep string) string {
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		lines[i] = latexEscapes.Replace(strings.TrimSpace(l))
+	}
+	return strings.Join(lines, sep)
+}
+
+// struktexWriter writes the macros of the struktex package with indentation.
+type struktexWriter struct {
+	b     strings.Builder
+	depth int
+}
+
+// Struktex writes s as a struktogramm environment of the LaTeX package
+// struktex to w. If standalone is set, the environment is wrapped in a
+// document that can be compiled on its own, otherwise it can be included
+// with \input. The size of the diagram is estimated from the layout that the
+// other output formats share.
+//
+// struktex has no try blocks, so they are drawn like loops, with a section
+// for the try and each catch and finally. The branches of a parallel can
+// only contain simple statements.
+func Struktex(w io.Writer, s *syntax.Structogram, standalone bool) error {
+	d := layout.Compute(s, layout.DefaultStyle())
+
+	sw := &struktexWriter{}
+	if standalone {
+		sw.line(`\documentclass{article}`)
+		sw.line(`\usepackage[T1]{fontenc}`)
+		sw.line(`\usepackage[utf8]{inputenc}`)
+		sw.line(`\usepackage{struktex}`)
+		sw.line(`\pagestyle{empty}`)
+		sw.line(`\begin{document}`)
+	}
+	sw.line(fmt.Sprintf(
+		`\begin{struktogramm}(%d,%d)[%s]`,
+		int(math.Ceil(d.Width*mmPerPoint)), int(math.Ceil(d.Height*mmPerPoint)),
+		escapeLaTeX(s.Name, " "),
+	))
+	sw.depth++
+	if err := sw.nodes(s.Nodes); err != nil {
+		return err
+	}
+	sw.depth--
+	sw.line(`\end{struktogramm}`)
+	if standalone {
+		sw.line(`\end{document}`)
+	}
+
+	_, err := io.WriteString(w, sw.b.String())
+	return err
+}
+
+func (sw *struktexWriter) line(text string) {
+	sw.b.WriteString(strings.Repeat("    ", sw.depth) + text + "\n")
+}
+
+// body writes nodes with one more level of indentation. struktex needs at
+// least one statement in every body.
+func (sw *struktexWriter) body(nodes []syntax.Node) error {
+	sw.depth++
+	defer func() { sw.depth-- }()
+	if len(nodes) == 0 {
+		sw.line(`\assign{}`)
+		return nil
+	}
+	return sw.nodes(nodes)
+}
+
+// nodes writes nodes, merging the else nodes into their if and the catch
+// and finally nodes into their try.
+func (sw *struktexWriter) nodes(nodes []syntax.Node) error {
+	for i := 0; i < len(nodes); i++ {
+		n := nodes[i]
+		end := i + 1
+		switch n.NodeType {
+		case "if":
+			if end < len(nodes) && nodes[end].NodeType == "else" {
+				end++
+			}
+		case "try":
+			for end < len(nodes) && (nodes[end].NodeType == "catch" ||
+				nodes[end].NodeType == "finally") {
+				end++
+			}
+		}
+		if err := sw.node(n, nodes[i+1:end]); err != nil {
+			return err
+		}
+		i = end - 1
+	}
+	return nil
+}
+
+// jumpText returns the text of a jump, which starts with its keyword, like
+// in the other output formats.
+func jumpText(n syntax.Node) string {
+	if n.Value == "" {
+		return n.NodeType
+	}
+	return n.NodeType + " " + n.Value
+}
+
+func (sw *struktexWriter) node(n syntax.Node, merged []syntax.Node) error {
+	statement := escapeLaTeX(n.Value, `\\ `)
+	header := escapeLaTeX(n.Value, " ")
+	switch n.NodeType {
+	case "instruction":
+		sw.line(`\assign{` + statement + `}`)
+	case "call":
+		sw.line(`\sub{` + statement + `}`)
+	case "break", "continue", "return", "exit":
+		sw.line(`\exit{` + escapeLaTeX(jumpText(n), " ") + `}`)
+	case "if":
+		sw.line(`\ifthenelse{3}{3}{` + header + `}{true}{false}`)
+		if err := sw.body(n.Nodes); err != nil {
+			return err
+		}
+		sw.line(`\change`)
+		var elseNodes []syntax.Node
+		if len(merged) > 0 {
+			elseNodes = merged[0].Nodes
+		}
+		if err := sw.body(elseNodes); err != nil {
+			return err
+		}
+		sw.line(`\ifend`)
+	case "while", "for":
+		return sw.block(`\while{`+header+`}`, n.Nodes, `\whileend`)
+	case "dowhile":
+		return sw.block(`\until{`+header+`}`, n.Nodes, `\untilend`)
+	case "switch":
+		for i, c := range n.Nodes {
+			label := escapeLaTeX(c.Value, " ")
+			if c.NodeType == "default" {
+				label = "default"
+			}
+			switch {
+			case i == 0:
+				sw.line(fmt.Sprintf(
+					`\case{4}{%d}{%s}{%s}`, len(n.Nodes), header, label,
+				))
+			case c.NodeType == "default":
+				sw.line(`\switch[r]{default}`)
+			default:
+				sw.line(`\switch{` + label + `}`)
+			}
+			if err := sw.body(c.Nodes); err != nil {
+				return err
+			}
+		}
+		sw.line(`\caseend`)
+	case "parallel":
+		return sw.parallel(n)
+	case "try":
+		if err := sw.block(`\while{try}`, n.Nodes, `\whileend`); err != nil {
+			return err
+		}
+		for _, h := range merged {
+			label := h.NodeType
+			if h.Value != "" {
+				label += " " + escapeLaTeX(h.Value, " ")
+			}
+			if err := sw.block(`\while{`+label+`}`, h.Nodes, `\whileend`); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// block writes a compound statement with a body between two macros.
+func (sw *struktexWriter) block(
+	begin string, nodes []syntax.Node, end string,
+) error {
+	sw.line(begin)
+	if err := sw.body(nodes); err != nil {
+		return err
+	}
+	sw.line(end)
+	return nil
+}
+
+// parallel writes a parallel as the tasks of an inparallel, where each task
+// is the text of the simple statements of a branch.
+func (sw *struktexWriter) parallel(n syntax.Node) error {
+	tasks := fmt.Sprintf(`\inparallel{%d}`, len(n.Nodes))
+	for _, br := range n.Nodes {
+		var texts []string
+		for _, c := range br.Nodes {
+			switch c.NodeType {
+			case "instruction", "call":
+				texts = append(texts, escapeLaTeX(c.Value, `\\ `))
+			case "break", "continue", "return", "exit":
+				texts = append(texts, escapeLaTeX(jumpText(c), " "))
+			default:
+				return syntax.Diagnostic{
+					Start: c.Start,
+					End:   c.End,
+					Message: fmt.Sprintf(
+						"struktex can not draw '%s' inside of a parallel branch",
+						c.NodeType,
+					),
+				}
+			}
+		}
+		tasks += "{" + strings.Join(texts, `\\ `) + "}"
+	}
+	sw.line(tasks)
+	return nil
+}
@@ -0,0 +1,118 @@
+package render
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestStruktexEscapesSpecialCharacters(t *testing.T) {
+	escaped := escapeLaTeX(`a_b & {c} $5 50% #1 ~x^2 \n <>|"`, " ")
+	expected := `a\_b \& \{c\} \$5 50\% \#1 \textasciitilde{}x\textasciicircum{}2 ` +
+		`\textbackslash{}n \textless{}\textgreater{}\textbar{}\textquotedbl{}`
+	if escaped != expected {
+		t.Errorf("Expected %s, but got %s", expected, escaped)
+	}
+}
+
+func TestStruktexWritesEnvironment(t *testing.T) {
+	s := parseForRender(
+		t,
+		`name("sum_up")
+		instruction("s = 0\ni = 0")
+		while("i < n") {
+			if("a[i] > 0") {call("add(a[i])")}
+			instruction("i++")
+		}
+		dowhile("x") {break("")}
+		switch("k") {
+			case("1") {instruction("a")}
+			case("2") {instruction("b")}
+			default {return("c")}
+		}`,
+	)
+	var buf bytes.Buffer
+	checkOk(t, Struktex(&buf, s, false))
+	out := buf.String()
+
+	if !strings.HasPrefix(out, `\begin{struktogramm}(`) ||
+		!strings.HasSuffix(out, "\\end{struktogramm}\n") {
+		t.Errorf("Expected only the environment, but got:\n%s", out)
+	}
+	checkContains(t, out, `)[sum\_up]`)
+	checkContains(t, out, `
+    \assign{s = 0\\ i = 0}
+    \while{i \textless{} n}
+        \ifthenelse{3}{3}{a[i] \textgreater{} 0}{true}{false}
+            \sub{add(a[i])}
+        \change
+            \assign{}
+        \ifend
+        \assign{i++}
+    \whileend
+    \until{x}
+        \exit{break}
+    \untilend
+    \case{4}{3}{k}{1}
+        \assign{a}
+    \switch{2}
+        \assign{b}
+    \switch[r]{default}
+        \exit{return c}
+    \caseend
+`)
+}
+
+func TestStruktexWritesStandaloneDocument(t *testing.T) {
+	s := parseForRender(
+		t,
+		`name("a")
+		try {call("b")} catch("E e") {call("c")}
+		parallel {branch {call("d") instruction("e")} branch {exit("1")}}`,
+	)
+	var buf bytes.Buffer
+	checkOk(t, Struktex(&buf, s, true))
+	out := buf.String()
+
+	checkContains(t, out, "\\documentclass{article}\n")
+	checkContains(t, out, "\\usepackage{struktex}\n")
+	checkContains(t, out, "\\end{struktogramm}\n\\end{document}\n")
+	checkContains(t, out, `
+    \while{try}
+        \sub{b}
+    \whileend
+    \while{catch E e}
+        \sub{c}
+    \whileend
+    \inparallel{2}{d\\ e}{exit 1}
+`)
+}
+
+func TestStruktexLabelsADefaultInFirstPlace(t *testing.T) {
+	s := parseForRender(
+		t,
+		`name("a") switch("k") {default {call("b")}}`,
+	)
+	var buf bytes.Buffer
+	if err := Struktex(&buf, s, false); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `\case{4}{1}{k}{default}`) {
+		t.Errorf(
+			"Expected the first case to be labeled default, but got:\n%s",
+			buf.String(),
+		)
+	}
+}
+
+func TestStruktexRejectsCompoundStatementsInParallel(t *testing.T) {
+	s := parseForRender(
+		t,
+		`name("a") parallel {branch {call("b")} branch {while("c") {call("d")}}}`,
+	)
+	err := Struktex(&bytes.Buffer{}, s, false)
+	expected := "1:48, struktex can not draw 'while' inside of a parallel branch"
+	if err == nil || err.Error() != expected {
+		t.Errorf("Expected error %s, but got %v", expected, err)
+	}
+}
@@ -0,0 +1,144 @@
+package render
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/JSchrtke/structogen/layout"
+	"github.com/JSchrtke/structogen/syntax"
+)
+
+// svgCanvas implements canvas by writing svg elements into a buffer. The
+// coordinates are the points of the layout, the size of the svg element
+// scales them to the size of the diagram.
+type svgCanvas struct {
+	buf   bytes.Buffer
+	style layout.Style
+	// link returns the url that a call links to, or an empty string if it
+	// is not linked.
+	link func(call string) string
+	// linked tells for each open group whether it is a link.
+	linked []bool
+}
+
+// svgFont selects Go Regular, the font that the layout is measured in.
+// Viewers that lack the Go fonts fall back to another sans-serif font.
+const svgFont = `font-family="Go, sans-serif"`
+
+// svgLineWidth is the width of the lines in points. At 96 dpi it is a single
+// pixel.
+const svgLineWidth = 0.75
+
+// SVG writes s as a Nassi-Shneiderman diagram in svg format to w. The diagram
+// has the same layout as the pdf output, its size is given in points.
+func SVG(w io.Writer, s *syntax.Structogram) error {
+	return LinkedSVG(w, s, nil)
+}
+
+// LinkedSVG writes s like SVG, but makes every call a link to the url that
+// link returns for the text of the call, unless it returns an empty string.
+func LinkedSVG(
+	w io.Writer, s *syntax.Structogram, link func(call string) string,
+) error {
+	style := layout.DefaultStyle()
+	d := layout.Compute(s, style)
+	c := &svgCanvas{style: style, link: link}
+
+	fmt.Fprintf(&c.buf, `<?xml version="1.0" encoding="UTF-8"?>`+"\n")
+	fmt.Fprintf(
+		&c.buf,
+		`<svg xmlns="http://www.w3.org/2000/svg" width="%spt" height="%spt" `+
+			`viewBox="0 0 %s %s">`+"\n",
+		num(d.Width), num(d.Height), num(d.Width), num(d.Height),
+	)
+	fmt.Fprintf(
+		&c.buf,
+		`<g %s font-size="%s" fill="none" stroke="black" stroke-width="%s">`+
+			"\n",
+		svgFont, num(style.FontSize), num(svgLineWidth),
+	)
+	fmt.Fprintf(
+		&c.buf,
+		`<rect x="0" y="0" width="%s" height="%s" fill="white" `+
+			`stroke="none"/>`+"\n",
+		num(d.Width), num(d.Height),
+	)
+	drawDiagram(c, d)
+	fmt.Fprintf(&c.buf, "</g>\n</svg>\n")
+
+	_, err := w.Write(c.buf.Bytes())
+	return err
+}
+
+// num formats a coordinate without unnecessary trailing zeros.
+func num(f float64) string {
+	return strconv.FormatFloat(math.Round(f*100)/100, 'f', -1, 64)
+}
+
+func (c *svgCanvas) rect(x float64, y float64, w float64, h float64) {
+	fmt.Fprintf(
+		&c.buf, `<rect x="%s" y="%s" width="%s" height="%s"/>`+"\n",
+		num(x), num(y), num(w), num(h),
+	)
+}
+
+func (c *svgCanvas) line(x1 float64, y1 float64, x2 float64, y2 float64) {
+	fmt.Fprintf(
+		&c.buf, `<line x1="%s" y1="%s" x2="%s" y2="%s"/>`+"\n",
+		num(x1), num(y1), num(x2), num(y2),
+	)
+}
+
+func (c *svgCanvas) text(
+	s string, x float64, y float64, anchor string, bold bool,
+) {
+	weight := "normal"
+	if bold {
+		weight = "bold"
+	}
+	fmt.Fprintf(
+		&c.buf,
+		`<text x="%s" y="%s" text-anchor="%s" font-weight="%s" `+
+			`fill="black" stroke="none" xml:space="preserve"`,
+		num(x), num(y), anchor, weight,
+	)
+	// The length makes texts in a fallback font as wide as they were
+	// measured, so they fit into their boxes. Bold texts are left alone, as
+	// they were measured in the regular font.
+	if !bold && strings.TrimSpace(s) != "" {
+		fmt.Fprintf(
+			&c.buf, ` textLength="%s" lengthAdjust="spacingAndGlyphs"`,
+			num(c.style.Measurer.Width(s, c.style.FontSize)),
+		)
+	}
+	fmt.Fprintf(&c.buf, ">")
+	_ = xml.EscapeText(&c.buf, []byte(s))
+	fmt.Fprintf(&c.buf, "</text>\n")
+}
+
+func (c *svgCanvas) beginGroup(b *layout.Box) {
+	fmt.Fprintf(&c.buf, `<g class="%s">`+"\n", b.NodeType)
+	var href string
+	if b.NodeType == "call" && c.link != nil {
+		href = c.link(b.Value)
+	}
+	if href != "" {
+		fmt.Fprintf(&c.buf, `<a href="`)
+		_ = xml.EscapeText(&c.buf, []byte(href))
+		fmt.Fprintf(&c.buf, `">`+"\n")
+	}
+	c.linked = append(c.linked, href != "")
+}
+
+func (c *svgCanvas) endGroup() {
+	if c.linked[len(c.linked)-1] {
+		fmt.Fprintf(&c.buf, "</a>\n")
+	}
+	c.linked = c.linked[:len(c.linked)-1]
+	fmt.Fprintf(&c.buf, "</g>\n")
+}
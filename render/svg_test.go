@@ -0,0 +1,126 @@
+package render
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/JSchrtke/structogen/layout"
+	"github.com/JSchrtke/structogen/syntax"
+)
+
+func checkOk(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Errorf("Did not expect any errors, but got %s", err.Error())
+	}
+}
+
+func parseForRender(t *testing.T, s string) *syntax.Structogram {
+	t.Helper()
+	structogram, err := syntax.Parse(strings.NewReader(s))
+	checkOk(t, err)
+	return structogram
+}
+
+func checkContains(t *testing.T, s string, substr string) {
+	t.Helper()
+	if !strings.Contains(s, substr) {
+		t.Errorf("Expected output to contain %s", substr)
+	}
+}
+
+func TestSvgIsWellFormedXml(t *testing.T) {
+	s := parseForRender(
+		t, `name("a & b") instruction("c < d") if("e > f") {call("g")}`,
+	)
+	var buf bytes.Buffer
+	checkOk(t, SVG(&buf, s))
+
+	decoder := xml.NewDecoder(&buf)
+	for {
+		_, err := decoder.Token()
+		if err != nil {
+			if err.Error() != "EOF" {
+				t.Errorf("Expected well-formed xml, but got %s", err.Error())
+			}
+			break
+		}
+	}
+}
+
+func TestSvgContainsNodeGroups(t *testing.T) {
+	s := parseForRender(
+		t,
+		`name("a")
+		 instruction("b")
+		 while("c") {call("d")}
+		 switch("e") {case("f") {instruction("g")} default {instruction("h")}}
+		 parallel {branch {call("i")} branch {call("j")}}
+		 try {call("k")} catch("l") {call("m")}
+		 return("n")`,
+	)
+	var buf bytes.Buffer
+	checkOk(t, SVG(&buf, s))
+	out := buf.String()
+
+	checkContains(t, out, `<svg xmlns="http://www.w3.org/2000/svg"`)
+	checkContains(t, out, `<g class="instruction">`)
+	checkContains(t, out, `<g class="while">`)
+	checkContains(t, out, `<g class="call">`)
+	checkContains(t, out, `<g class="switch">`)
+	checkContains(t, out, `<g class="parallel">`)
+	checkContains(t, out, `<g class="return">`)
+	checkContains(t, out, `<g class="try">`)
+	checkContains(t, out, `>catch l</text>`)
+	checkContains(t, out, `>f</text>`)
+	checkContains(t, out, `>default</text>`)
+}
+
+func TestSvgEscapesText(t *testing.T) {
+	s := parseForRender(t, `name("a") instruction("b < c && d")`)
+	var buf bytes.Buffer
+	checkOk(t, SVG(&buf, s))
+	checkContains(t, buf.String(), ">b &lt; c &amp;&amp; d</text>")
+}
+
+func TestSvgHasTheLayoutOfThePdfInPoints(t *testing.T) {
+	s := parseForRender(t, `name("a") instruction("bb")`)
+	var buf bytes.Buffer
+	checkOk(t, SVG(&buf, s))
+	out := buf.String()
+
+	style := layout.DefaultStyle()
+	d := layout.Compute(s, style)
+	checkContains(t, out, fmt.Sprintf(
+		`width="%spt" height="%spt" viewBox="0 0 %s %s"`,
+		num(d.Width), num(d.Height), num(d.Width), num(d.Height),
+	))
+	checkContains(t, out, fmt.Sprintf(
+		`textLength="%s" lengthAdjust="spacingAndGlyphs">bb</text>`,
+		num(style.Measurer.Width("bb", style.FontSize)),
+	))
+}
+
+func TestSvgLinksCalls(t *testing.T) {
+	s := parseForRender(
+		t, `name("a") call("b()") while("c") {call("d & e")} call("f()")`,
+	)
+	var buf bytes.Buffer
+	checkOk(t, LinkedSVG(&buf, s, func(call string) string {
+		if call == "f()" {
+			return ""
+		}
+		return "#" + call
+	}))
+	out := buf.String()
+
+	checkContains(t, out, "<g class=\"call\">\n<a href=\"#b()\">\n")
+	checkContains(t, out, `<a href="#d &amp; e">`)
+	checkContains(t, out, "</a>\n</g>\n</g>\n")
+	if strings.Count(out, "<a ") != 2 || strings.Count(out, "</a>") != 2 {
+		t.Errorf("Expected two links, but got:\n%s", out)
+	}
+}
@@ -0,0 +1,236 @@
+package render
+
+import (
+	"io"
+	"math"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/JSchrtke/structogen/layout"
+	"github.com/JSchrtke/structogen/syntax"
+)
+
+// Directions in which the border in a cell of a textCanvas continues.
+const (
+	up = 1 << iota
+	down
+	left
+	right
+)
+
+// boxChars maps the directions of a border cell to box-drawing characters.
+var boxChars = map[int]rune{
+	left | right:             '─',
+	left:                     '─',
+	right:                    '─',
+	up | down:                '│',
+	up:                       '│',
+	down:                     '│',
+	down | right:             '┌',
+	down | left:              '┐',
+	up | right:               '└',
+	up | left:                '┘',
+	up | down | right:        '├',
+	up | down | left:         '┤',
+	down | left | right:      '┬',
+	up | left | right:        '┴',
+	up | down | left | right: '┼',
+}
+
+// textCanvas implements canvas on a grid of characters, where a unit is the
+// size of a cell. Borders are collected as the directions in which they
+// continue from each cell, so crossing borders are joined.
+type textCanvas struct {
+	borders [][]int
+	chars   [][]rune
+	ascii   bool
+	// jump is set while a jump is drawn, whose arrow is too small for the
+	// grid and is drawn as a single character instead.
+	jump bool
+}
+
+// textLayoutStyle returns a layout style in which a unit is the size of a
+// character. Half a character of padding on each side leaves room for the
+// borders. The margin of half a character puts the borders of the frame
+// into the middle of cells. The bands of a parallel are two characters high,
+// so that their diagonals can be drawn.
+func textLayoutStyle(width int) layout.Style {
+	return layout.Style{
+		FontSize:     1,
+		LineHeight:   1,
+		Padding:      0.5,
+		Margin:       0.5,
+		LoopIndent:   2,
+		CallInset:    1,
+		ArrowWidth:   2,
+		MinWidth:     3,
+		MaxTextWidth: float64(width),
+		BandHeight:   2,
+		Measurer: layout.MeasurerFunc(func(s string, fontSize float64) float64 {
+			return float64(utf8.RuneCountInString(s))
+		}),
+	}
+}
+
+// Text writes s as a Nassi-Shneiderman diagram drawn with box-drawing
+// characters to w, for terminals. Text is wrapped to lines of at most width
+// characters. If ascii is set, only ASCII characters are used for the
+// borders.
+func Text(w io.Writer, s *syntax.Structogram, width int, ascii bool) error {
+	d := layout.Compute(s, textLayoutStyle(width))
+	rows, cols := cell(d.Height)+1, cell(d.Width)+1
+	c := &textCanvas{ascii: ascii}
+	for i := 0; i < rows; i++ {
+		c.borders = append(c.borders, make([]int, cols))
+		c.chars = append(c.chars, make([]rune, cols))
+	}
+	drawDiagram(c, d)
+
+	var b strings.Builder
+	// The first row is the top margin.
+	for row := 1; row < rows; row++ {
+		line := make([]rune, cols)
+		for col := range line {
+			line[col] = c.char(row, col)
+		}
+		b.WriteString(strings.TrimRight(string(line), " ") + "\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// cell returns the index of the cell that the coordinate v falls into.
+func cell(v float64) int {
+	return int(math.Floor(v))
+}
+
+func (c *textCanvas) inside(row int, col int) bool {
+	return row >= 0 && row < len(c.chars) && col >= 0 && col < len(c.chars[row])
+}
+
+func (c *textCanvas) char(row int, col int) rune {
+	if ch := c.chars[row][col]; ch != 0 {
+		return ch
+	}
+	dirs := c.borders[row][col]
+	if dirs == 0 {
+		return ' '
+	}
+	if !c.ascii {
+		return boxChars[dirs]
+	}
+	switch dirs {
+	case left, right, left | right:
+		return '-'
+	case up, down, up | down:
+		return '|'
+	}
+	return '+'
+}
+
+func (c *textCanvas) rect(x float64, y float64, w float64, h float64) {
+	c.line(x, y, x+w, y)
+	c.line(x, y+h, x+w, y+h)
+	c.line(x, y, x, y+h)
+	c.line(x+w, y, x+w, y+h)
+}
+
+func (c *textCanvas) line(x1 float64, y1 float64, x2 float64, y2 float64) {
+	r1, c1, r2, c2 := cell(y1), cell(x1), cell(y2), cell(x2)
+	switch {
+	case r1 == r2:
+		if c1 > c2 {
+			c1, c2 = c2, c1
+		}
+		for col := c1; col <= c2; col++ {
+			if col > c1 {
+				c.border(r1, col, left)
+			}
+			if col < c2 {
+				c.border(r1, col, right)
+			}
+		}
+	case c1 == c2:
+		if r1 > r2 {
+			r1, r2 = r2, r1
+		}
+		for row := r1; row <= r2; row++ {
+			if row > r1 {
+				c.border(row, c1, up)
+			}
+			if row < r2 {
+				c.border(row, c1, down)
+			}
+		}
+	case c.jump:
+		// The first line of the arrow ends in its tip.
+		if c2 < c1 && c.inside(r2, c2+1) {
+			c.chars[r2][c2+1] = '<'
+		}
+	default:
+		c.diagonal(r1, c1, r2, c2)
+	}
+}
+
+func (c *textCanvas) border(row int, col int, dir int) {
+	if c.inside(row, col) {
+		c.borders[row][col] |= dir
+	}
+}
+
+// diagonal draws a diagonal line with one character in each row between
+// its end points, which are on borders.
+func (c *textCanvas) diagonal(r1 int, c1 int, r2 int, c2 int) {
+	if r1 > r2 {
+		r1, c1, r2, c2 = r2, c2, r1, c1
+	}
+	ch := '\\'
+	if c2 < c1 {
+		ch = '/'
+	}
+	for row := r1 + 1; row < r2; row++ {
+		col := c1 + int(math.Round(
+			float64(c2-c1)*float64(row-r1)/float64(r2-r1),
+		))
+		if c.inside(row, col) && c.borders[row][col] == 0 {
+			c.chars[row][col] = ch
+		}
+	}
+}
+
+func (c *textCanvas) text(
+	s string, x float64, y float64, anchor string, bold bool,
+) {
+	// Text is padded by half a cell, which is rounded up to the cell next
+	// to the border.
+	row := cell(y)
+	n := utf8.RuneCountInString(s)
+	var col int
+	switch anchor {
+	case "start":
+		col = cell(x + 0.5)
+	case "middle":
+		col = cell(x - float64(n)/2 + 0.5)
+	case "end":
+		col = cell(x+0.5) - n
+	}
+	for _, r := range s {
+		if c.inside(row, col) {
+			c.chars[row][col] = r
+		}
+		col++
+	}
+}
+
+func (c *textCanvas) beginGroup(b *layout.Box) {
+	switch b.NodeType {
+	case "break", "continue", "return", "exit":
+		c.jump = true
+	default:
+		c.jump = false
+	}
+}
+
+func (c *textCanvas) endGroup() {
+	c.jump = false
+}
@@ -0,0 +1,76 @@
+package render
+
+import (
+	"bytes"
+	"testing"
+)
+
+func renderText(t *testing.T, source string, width int, ascii bool) string {
+	t.Helper()
+	var buf bytes.Buffer
+	checkOk(t, Text(&buf, parseForRender(t, source), width, ascii))
+	return buf.String()
+}
+
+func checkText(t *testing.T, actual string, expected string) {
+	t.Helper()
+	if actual != expected {
+		t.Errorf("Expected:\n%s\nbut got:\n%s", expected, actual)
+	}
+}
+
+func TestTextDrawsIfWithDiagonals(t *testing.T) {
+	checkText(t, renderText(t, `name("abs")
+		if("x < 0") {instruction("x = -x")} else {call("keep()")}
+		return("x")`, 40, false), ` abs
+┌───────────────┐
+│ \   x < 0  /  │
+│true\    /false│
+├──────┬┬──────┬┤
+│x = -x││keep()││
+├──────┴┴──────┴┤
+│< return x     │
+└───────────────┘
+`)
+}
+
+func TestTextDrawsTheBandsOfParallel(t *testing.T) {
+	checkText(t, renderText(t, `name("p")
+		parallel {branch {call("a")} branch {instruction("b")}}`, 40, false), ` p
+┌───────┐
+│\     /│
+├┬──┬┬──┤
+││a ││b │
+├┴──┴┴──┤
+│/     \│
+└───────┘
+`)
+}
+
+func TestTextWrapsLongValues(t *testing.T) {
+	checkText(t, renderText(t, `name("a")
+		while("i < n") {instruction("one two three four")}`, 9, true), ` a
++---------+
+|i < n    |
+| +-------+
+| |one two|
+| |three  |
+| |four   |
++-+-------+
+`)
+}
+
+func TestTextUsesOnlyAsciiInAsciiMode(t *testing.T) {
+	source := `name("a")
+		switch("k") {case("1") {break("")} default {call("f")}}
+		parallel {branch {instruction("b")} branch {instruction("c")}}
+		try {instruction("d")} finally {instruction("e")}
+		dowhile("x") {continue("")}`
+	out := renderText(t, source, 40, true)
+	for _, r := range out {
+		if r > 127 {
+			t.Fatalf("Expected only ascii characters, but got %q in:\n%s", r, out)
+		}
+	}
+	checkContains(t, renderText(t, source, 40, false), "┼")
+}
@@ -0,0 +1,151 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/JSchrtke/structogen/layout"
+	"github.com/JSchrtke/structogen/syntax"
+)
+
+// tikzStyles are the TikZ styles that the output uses, along with their
+// defaults. Styles that are already defined, e.g. in the preamble of the
+// document, are kept.
+var tikzStyles = []struct{ name, value string }{
+	{"structogram", "line width=0.5pt, font=\\fontsize{10}{14}\\selectfont"},
+	{"structogram box", "draw"},
+	{"structogram line", "draw"},
+	{"structogram text", "inner sep=0pt"},
+	{"structogram title", "inner sep=0pt, font=\\bfseries"},
+}
+
+// tikzFit defines \structogramfit{width}{text}, which sets text and scales
+// it horizontally to the width in points if it is wider.
+const tikzFit = `\providecommand{\structogramfit}[2]{\sbox0{#2}` +
+	`\ifdim\wd0>#1pt\relax` +
+	`\pgfmathsetmacro{\structogramscale}{#1/\wd0}` +
+	`\scalebox{\structogramscale}[1]{\usebox0}` +
+	`\else\usebox0\fi}`
+
+// tikzNodeTypes are the node types that get a scope with a style of their
+// own.
+var tikzNodeTypes = []string{
+	"instruction", "call", "if", "while", "for", "dowhile", "switch", "case",
+	"default", "parallel", "try", "break", "continue", "return", "exit",
+}
+
+// tikzCanvas implements canvas by writing TikZ commands. The picture uses
+// points as its unit and flips the y axis, so the coordinates of the layout
+// can be used unchanged.
+type tikzCanvas struct {
+	buf     bytes.Buffer
+	measure func(s string) float64
+}
+
+// TikZ writes s as a tikzpicture to w, with the coordinates of the layout of
+// the pdf output. LaTeX sets the texts in the font of the document, whose
+// widths differ from the measured ones, so texts that are wider than they
+// were measured are squeezed to fit into their boxes. If standalone is set,
+// the picture is wrapped in a document that can be compiled on its own.
+//
+// Every element is drawn with a style that can be redefined in the preamble:
+// structogram for the whole picture, structogram box, structogram line,
+// structogram text and structogram title for the elements, and scopes with
+// styles like structogram if for each statement.
+func TikZ(w io.Writer, s *syntax.Structogram, standalone bool) error {
+	style := layout.DefaultStyle()
+	d := layout.Compute(s, style)
+	c := &tikzCanvas{measure: func(s string) float64 {
+		return style.Measurer.Width(s, style.FontSize)
+	}}
+
+	if standalone {
+		fmt.Fprintf(&c.buf, "\\documentclass[tikz]{standalone}\n")
+		fmt.Fprintf(&c.buf, "\\usepackage[T1]{fontenc}\n")
+		fmt.Fprintf(&c.buf, "\\usepackage[utf8]{inputenc}\n")
+		fmt.Fprintf(&c.buf, "\\begin{document}\n")
+	}
+	for _, st := range tikzStyles {
+		fmt.Fprintf(
+			&c.buf, "\\pgfkeysifdefined{/tikz/%s/.@cmd}{}"+
+				"{\\tikzset{%s/.style={%s}}}\n",
+			st.name, st.name, st.value,
+		)
+	}
+	for _, nodeType := range tikzNodeTypes {
+		fmt.Fprintf(
+			&c.buf, "\\pgfkeysifdefined{/tikz/structogram %s/.@cmd}{}"+
+				"{\\tikzset{structogram %s/.style={}}}\n",
+			nodeType, nodeType,
+		)
+	}
+	fmt.Fprintf(&c.buf, "%s\n", tikzFit)
+	fmt.Fprintf(&c.buf, "\\begin{tikzpicture}[x=1pt, y=-1pt, structogram]\n")
+	// The invisible frame keeps the margins of the layout.
+	fmt.Fprintf(
+		&c.buf, "\\path (0,0) rectangle (%s,%s);\n", num(d.Width), num(d.Height),
+	)
+	drawDiagram(c, d)
+	fmt.Fprintf(&c.buf, "\\end{tikzpicture}\n")
+	if standalone {
+		fmt.Fprintf(&c.buf, "\\end{document}\n")
+	}
+
+	_, err := w.Write(c.buf.Bytes())
+	return err
+}
+
+func (c *tikzCanvas) rect(x float64, y float64, w float64, h float64) {
+	fmt.Fprintf(
+		&c.buf, "\\path[structogram box] (%s,%s) rectangle (%s,%s);\n",
+		num(x), num(y), num(x+w), num(y+h),
+	)
+}
+
+func (c *tikzCanvas) line(x1 float64, y1 float64, x2 float64, y2 float64) {
+	fmt.Fprintf(
+		&c.buf, "\\path[structogram line] (%s,%s) -- (%s,%s);\n",
+		num(x1), num(y1), num(x2), num(y2),
+	)
+}
+
+func (c *tikzCanvas) text(
+	s string, x float64, y float64, anchor string, bold bool,
+) {
+	style := "structogram text"
+	if bold {
+		style = "structogram title"
+	}
+	// LaTeX drops leading spaces, so the indentation of a line is turned
+	// into an offset.
+	trimmed := strings.TrimLeft(s, " ")
+	if anchor == "start" {
+		x += c.measure(s[:len(s)-len(trimmed)])
+	}
+	tikzAnchor := map[string]string{
+		"start":  "base west",
+		"middle": "base",
+		"end":    "base east",
+	}[anchor]
+	text := escapeLaTeX(trimmed, " ")
+	// The title is measured in the regular font and has no box to fit into.
+	if !bold && text != "" {
+		text = fmt.Sprintf(
+			"\\structogramfit{%s}{%s}", num(c.measure(trimmed)), text,
+		)
+	}
+	fmt.Fprintf(
+		&c.buf, "\\node[%s, anchor=%s] at (%s,%s) {%s};\n",
+		style, tikzAnchor, num(x), num(y), text,
+	)
+}
+
+func (c *tikzCanvas) beginGroup(b *layout.Box) {
+	fmt.Fprintf(&c.buf, "\\begin{scope}[structogram %s]\n", b.NodeType)
+}
+
+func (c *tikzCanvas) endGroup() {
+	fmt.Fprintf(&c.buf, "\\end{scope}\n")
+}
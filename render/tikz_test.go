@@ -0,0 +1,72 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/JSchrtke/structogen/layout"
+)
+
+func TestTikZUsesTheCoordinatesOfTheLayout(t *testing.T) {
+	s := parseForRender(
+		t, `name("a") if("b") {instruction("c")} else {call("d")}`,
+	)
+	var buf bytes.Buffer
+	checkOk(t, TikZ(&buf, s, false))
+	out := buf.String()
+
+	d := layout.Compute(s, layout.DefaultStyle())
+	ifBox := d.Body[0]
+	split := ifBox.Branches[1].X
+	bottom := ifBox.Y + ifBox.HeaderH
+
+	checkContains(t, out, "\\begin{tikzpicture}[x=1pt, y=-1pt, structogram]\n")
+	checkContains(t, out, "\\begin{scope}[structogram if]\n")
+	checkContains(t, out, fmt.Sprintf(
+		"\\path[structogram box] (%s,%s) rectangle (%s,%s);\n",
+		num(ifBox.X), num(ifBox.Y),
+		num(ifBox.X+ifBox.W), num(ifBox.Y+ifBox.H),
+	))
+	checkContains(t, out, fmt.Sprintf(
+		"\\path[structogram line] (%s,%s) -- (%s,%s);\n",
+		num(ifBox.X), num(ifBox.Y), num(split), num(bottom),
+	))
+	checkContains(t, out, "\\node[structogram title, anchor=base west]")
+	checkContains(t, out, "\\begin{scope}[structogram call]\n")
+	if strings.Contains(out, "\\documentclass") {
+		t.Errorf("Did not expect a document, but got:\n%s", out)
+	}
+}
+
+func TestTikZKeepsStylesOfThePreamble(t *testing.T) {
+	s := parseForRender(t, `name("a") instruction("  x_1 < 2")`)
+	var buf bytes.Buffer
+	checkOk(t, TikZ(&buf, s, true))
+	out := buf.String()
+
+	checkContains(t, out, "\\documentclass[tikz]{standalone}\n")
+	checkContains(
+		t, out,
+		"\\pgfkeysifdefined{/tikz/structogram box/.@cmd}{}"+
+			"{\\tikzset{structogram box/.style={draw}}}\n",
+	)
+	checkContains(t, out, "{x\\_1 \\textless{} 2}};\n")
+	checkContains(t, out, "\\end{tikzpicture}\n\\end{document}\n")
+}
+
+func TestTikZFitsTextsIntoTheirMeasuredWidth(t *testing.T) {
+	s := parseForRender(t, `name("a") instruction("b_c")`)
+	var buf bytes.Buffer
+	checkOk(t, TikZ(&buf, s, false))
+	out := buf.String()
+
+	style := layout.DefaultStyle()
+	checkContains(t, out, "\\providecommand{\\structogramfit}[2]")
+	checkContains(t, out, fmt.Sprintf(
+		"{\\structogramfit{%s}{b\\_c}};\n",
+		num(style.Measurer.Width("b_c", style.FontSize)),
+	))
+	checkContains(t, out, "anchor=base west] at (4,14) {a};\n")
+}
@@ -0,0 +1,61 @@
+package syntax
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Position is a location in a .str source. Line and column are 1-based.
+type Position struct {
+	Line   int
+	Column int
+}
+
+// Diagnostic is a syntax error. It spans from Start up to, but not
+// including, End.
+type Diagnostic struct {
+	Start   Position
+	End     Position
+	Message string
+}
+
+func (d Diagnostic) Error() string {
+	return fmt.Sprintf("%d:%d, %s", d.Start.Line, d.Start.Column, d.Message)
+}
+
+// Diagnostics is the list of all syntax errors of a source, sorted by their
+// position. It is the error returned by ParseTokens.
+type Diagnostics []Diagnostic
+
+func (d Diagnostics) Error() string {
+	switch len(d) {
+	case 0:
+		return "no errors"
+	case 1:
+		return d[0].Error()
+	}
+	return fmt.Sprintf("%s (and %d more errors)", d[0].Error(), len(d)-1)
+}
+
+func (d Diagnostics) sort() {
+	sort.SliceStable(d, func(i, j int) bool {
+		a, b := d[i].Start, d[j].Start
+		return a.Line < b.Line || a.Line == b.Line && a.Column < b.Column
+	})
+}
+
+// newDiagnostic returns a diagnostic that spans the token t.
+func newDiagnostic(t Token, message string) Diagnostic {
+	return Diagnostic{
+		Start:   Position{Line: t.Line, Column: t.Column},
+		End:     Position{Line: t.EndLine, Column: t.EndColumn},
+		Message: message,
+	}
+}
+
+func newTokenTypeError(expected string, actual Token) Diagnostic {
+	return newDiagnostic(
+		actual,
+		fmt.Sprintf("expected '%s', but got '%s'", expected, actual.Type),
+	)
+}
@@ -0,0 +1,231 @@
+package syntax
+
+import (
+	"strings"
+)
+
+// Format prints s as .str source in its canonical formatting: an indentation
+// of four spaces, one statement per line, an else on the line of the closing
+// brace of its if, and strings in double quotes. Values with line breaks are
+// printed as multi-line strings. Imports come first, each on its own line.
+// If s was parsed from source, its comments are kept, and so are blank lines
+// between statements, with multiple blank lines reduced to one. A comment
+// on the line of a closing brace stays there, so a line comment moves the
+// else to the next line.
+func Format(s *Structogram) string {
+	p := printer{comments: s.Comments, first: true}
+	for _, imp := range s.Imports {
+		if imp.Start.Line > 0 {
+			p.commentsBefore(imp.Start, 0)
+		}
+		p.item(imp.Start.Line, imp.End.Line, 0, "import("+quote(imp.Path)+")")
+		p.first = true
+	}
+	// The imports are separated from the name by a blank line.
+	p.blank = len(s.Imports) > 0
+	if s.NameStart.Line > 0 {
+		p.commentsBefore(s.NameStart, 0)
+	}
+	p.item(s.NameStart.Line, s.NameStart.Line, 0, "name("+quote(s.Name)+")")
+	p.blank = true
+	p.nodes(s.Nodes, 0)
+	p.commentsBefore(Position{}, 0)
+	p.b.WriteString("\n")
+	return p.b.String()
+}
+
+// FormatAll prints the structograms of a file like Format, separated by
+// blank lines.
+func FormatAll(structograms []*Structogram) string {
+	var formatted []string
+	for _, s := range structograms {
+		formatted = append(formatted, Format(s))
+	}
+	return strings.Join(formatted, "\n")
+}
+
+// printer prints nodes and the comments between them. A printed item does
+// not end with a line break, so trailing comments can be appended to it.
+type printer struct {
+	b        strings.Builder
+	comments []Comment
+	// line is the source line the last printed item ends on, or 0 if it is
+	// unknown.
+	line int
+	// first is set if the next item is the first one in its body, which is
+	// never preceded by a blank line.
+	first bool
+	// blank is set if the next item has to be preceded by a blank line.
+	blank bool
+}
+
+func indent(depth int) string {
+	return strings.Repeat("    ", depth)
+}
+
+// item prints text on a new line. It is separated from the previous item by
+// a blank line if their source lines are.
+func (p *printer) item(start int, end int, depth int, text string) {
+	if p.b.Len() > 0 {
+		p.b.WriteString("\n")
+		gap := start > 0 && p.line > 0 && start > p.line+1
+		if p.blank || !p.first && gap {
+			p.b.WriteString("\n")
+		}
+	}
+	p.first, p.blank = false, false
+	p.b.WriteString(indent(depth) + text)
+	p.line = end
+}
+
+// commentsBefore prints the comments that start before pos, or all of the
+// remaining ones if pos is unknown. A comment on the line the last item
+// ends on is appended to it.
+func (p *printer) commentsBefore(pos Position, depth int) {
+	for len(p.comments) > 0 {
+		c := p.comments[0]
+		if pos.Line > 0 && (c.Line > pos.Line ||
+			c.Line == pos.Line && c.Column >= pos.Column) {
+			return
+		}
+		p.comments = p.comments[1:]
+
+		end := c.Line + strings.Count(c.Text, "\n")
+		if p.b.Len() > 0 && c.Line == p.line {
+			p.b.WriteString(" " + c.Text)
+			p.line = end
+		} else {
+			p.item(c.Line, end, depth, c.Text)
+		}
+	}
+}
+
+// trailingComments appends the comments that follow the last printed item
+// on its line and start before pos. It reports whether one of them is a line
+// comment, after which the line can not continue.
+func (p *printer) trailingComments(pos Position) bool {
+	lineComment := false
+	for len(p.comments) > 0 {
+		c := p.comments[0]
+		if c.Line != p.line || c.Line > pos.Line ||
+			c.Line == pos.Line && c.Column >= pos.Column {
+			return lineComment
+		}
+		p.comments = p.comments[1:]
+		p.b.WriteString(" " + c.Text)
+		p.line = c.Line + strings.Count(c.Text, "\n")
+		lineComment = strings.HasPrefix(c.Text, "//")
+	}
+	return lineComment
+}
+
+func (p *printer) nodes(nodes []Node, depth int) {
+	for i, n := range nodes {
+		if i > 0 && continues(nodes[i-1], n) {
+			// An else, catch or finally gets appended to the closing brace
+			// of the block it continues, unless a line comment trails the
+			// brace.
+			if p.trailingComments(n.Start) {
+				p.b.WriteString("\n" + indent(depth) + header(n, depth))
+			} else {
+				p.b.WriteString(" " + header(n, depth))
+			}
+			p.line = n.Start.Line
+			p.body(n, depth)
+			continue
+		}
+		p.commentsBefore(n.Start, depth)
+		p.node(n, depth)
+	}
+}
+
+// continues reports whether n belongs to the compound statement that
+// previous is a part of.
+func continues(previous Node, n Node) bool {
+	switch n.NodeType {
+	case "else":
+		return previous.NodeType == "if"
+	case "catch", "finally":
+		return previous.NodeType == "try" || previous.NodeType == "catch"
+	}
+	return false
+}
+
+func (p *printer) node(n Node, depth int) {
+	start := n.Start.Line
+	switch n.NodeType {
+	case "instruction", "call", "break", "continue", "return", "exit":
+		text := n.NodeType + "(" + literal(n.Value, depth) + ")"
+		p.item(start, n.End.Line, depth, text)
+		return
+	}
+	p.item(start, start, depth, header(n, depth))
+	p.body(n, depth)
+}
+
+// header returns the first line of a compound statement, up to and
+// including the opening brace.
+func header(n Node, depth int) string {
+	switch n.NodeType {
+	case "default", "else", "parallel", "branch", "try", "finally":
+		return n.NodeType + " {"
+	}
+	return n.NodeType + " (" + literal(n.Value, depth) + ") {"
+}
+
+// body prints the nodes of n, followed by the closing brace.
+func (p *printer) body(n Node, depth int) {
+	p.first = true
+	p.nodes(n.Nodes, depth+1)
+	p.commentsBefore(n.End, depth+1)
+	p.b.WriteString("\n" + indent(depth) + "}")
+	p.line = n.End.Line
+	p.first = false
+}
+
+// quote encloses s in double quotes, escaping everything that can not
+// appear in a string literal as is.
+func quote(s string) string {
+	r := strings.NewReplacer(
+		"\\", `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`,
+	)
+	return `"` + r.Replace(s) + `"`
+}
+
+// literal returns s as a string literal for a statement at the given depth.
+// Values with line breaks become multi-line strings, whose lines are
+// indented one level deeper than the statement, unless the removal of the
+// common indentation would change them.
+func literal(s string, depth int) string {
+	if !strings.Contains(s, "\n") {
+		return quote(s)
+	}
+	// A quotation mark only has to be escaped if it could be part of the
+	// closing quotation marks.
+	var b strings.Builder
+	b.WriteString(`"""` + "\n")
+	for _, l := range strings.Split(s, "\n") {
+		if l != "" {
+			b.WriteString(indent(depth + 1))
+		}
+		runes := []rune(l)
+		for i, r := range runes {
+			switch {
+			case r == '\\':
+				b.WriteString(`\\`)
+			case r == '"' && (i == len(runes)-1 || runes[i+1] == '"'):
+				b.WriteString(`\"`)
+			default:
+				b.WriteRune(r)
+			}
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString(indent(depth) + `"""`)
+
+	tokens := Tokenize(b.String())
+	if len(tokens) != 2 || tokens[0].Type != "string" || tokens[0].Value != s {
+		return quote(s)
+	}
+	return b.String()
+}
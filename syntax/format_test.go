@@ -0,0 +1,311 @@
+package syntax
+
+import (
+	"strings"
+	"testing"
+)
+
+func checkFormat(t *testing.T, source string, expected string) {
+	t.Helper()
+	s, err := Parse(strings.NewReader(source))
+	checkOk(t, err)
+	formatted := Format(s)
+	if formatted != expected {
+		t.Errorf("Expected:\n%s\nbut got:\n%s", expected, formatted)
+	}
+
+	// Formatting has to be idempotent.
+	s, err = Parse(strings.NewReader(formatted))
+	checkOk(t, err)
+	if again := Format(s); again != formatted {
+		t.Errorf("Formatting again changed:\n%s\nto:\n%s", formatted, again)
+	}
+}
+
+func TestFormatPutsEveryStatementOnItsOwnLine(t *testing.T) {
+	checkFormat(
+		t,
+		`name('a') switch("x"){case('1'){instruction("b") call("c")}`+
+			`default{instruction("d")}}`,
+		`name("a")
+
+switch ("x") {
+    case ("1") {
+        instruction("b")
+        call("c")
+    }
+    default {
+        instruction("d")
+    }
+}
+`,
+	)
+}
+
+func TestFormatAttachesElseToClosingBrace(t *testing.T) {
+	checkFormat(
+		t,
+		"name(\"a\")\nif(\"b\") {\n  call(\"c\")\n}\n\nelse\n{\n  call(\"d\")\n}\n",
+		`name("a")
+
+if ("b") {
+    call("c")
+} else {
+    call("d")
+}
+`,
+	)
+}
+
+func TestFormatKeepsCommentsOnTheClosingBraceOfIf(t *testing.T) {
+	checkFormat(t, `name("a")
+if ("b") {
+    instruction("c")
+} // done
+else {
+    instruction("d")
+}`, `name("a")
+
+if ("b") {
+    instruction("c")
+} // done
+else {
+    instruction("d")
+}
+`)
+	checkFormat(t, `name("a")
+if ("b") { instruction("c") } /* done */ else { instruction("d") }`, `name("a")
+
+if ("b") {
+    instruction("c")
+} /* done */ else {
+    instruction("d")
+}
+`)
+}
+
+func TestFormatKeepsSingleBlankLines(t *testing.T) {
+	checkFormat(
+		t,
+		`name("a")
+instruction("b")
+
+
+
+instruction("c")
+instruction("d")
+while ("e") {
+
+    instruction("f")
+
+    instruction("g")
+
+}
+`,
+		`name("a")
+
+instruction("b")
+
+instruction("c")
+instruction("d")
+while ("e") {
+    instruction("f")
+
+    instruction("g")
+}
+`,
+	)
+}
+
+func TestFormatKeepsComments(t *testing.T) {
+	checkFormat(
+		t,
+		`// file comment
+name("a") // name comment
+/* block
+   comment */
+instruction("b") /* inline */ instruction("c")
+if ("d") { // header comment
+  instruction("e")
+  // last in body
+}
+else {
+  instruction("f")
+}
+// trailing comment
+`,
+		`// file comment
+name("a") // name comment
+
+/* block
+   comment */
+instruction("b") /* inline */
+instruction("c")
+if ("d") { // header comment
+    instruction("e")
+    // last in body
+} else {
+    instruction("f")
+}
+// trailing comment
+`,
+	)
+}
+
+func TestFormatUsesDoubleQuotesAndEscapes(t *testing.T) {
+	checkFormat(
+		t,
+		`name('a "b"') instruction('c\'s \\ \t')`,
+		`name("a \"b\"")
+
+instruction("c's \\ \t")
+`,
+	)
+}
+
+func TestFormatPrintsLineBreaksAsMultilineStrings(t *testing.T) {
+	checkFormat(
+		t,
+		"name(\"a\")\nwhile(\"b\") { instruction(\"x = 1\\n  y = \\\"\\\"\\\"\") }",
+		`name("a")
+
+while ("b") {
+    instruction("""
+        x = 1
+          y = \"\"\"
+    """)
+}
+`,
+	)
+}
+
+func TestFormatKeepsValuesThatCanNotBeMultilineStrings(t *testing.T) {
+	// The common indentation of a multi-line string is removed, so it can
+	// not express lines that all start with whitespace.
+	checkFormat(
+		t,
+		`name("a") instruction("  x\n  y")`,
+		`name("a")
+
+instruction("  x\n  y")
+`,
+	)
+}
+
+func TestFormatWithoutPositions(t *testing.T) {
+	s := &Structogram{
+		Name: "a",
+		Nodes: []Node{
+			{NodeType: "instruction", Value: "b"},
+			{NodeType: "if", Value: "c", Nodes: []Node{
+				{NodeType: "call", Value: "d"},
+			}},
+			{NodeType: "else", Nodes: []Node{
+				{NodeType: "call", Value: "e"},
+			}},
+		},
+	}
+	expected := `name("a")
+
+instruction("b")
+if ("c") {
+    call("d")
+} else {
+    call("e")
+}
+`
+	if formatted := Format(s); formatted != expected {
+		t.Errorf("Expected:\n%s\nbut got:\n%s", expected, formatted)
+	}
+}
+
+func TestFormatJumps(t *testing.T) {
+	checkFormat(
+		t,
+		`name("a") while("b") {break('') continue("")} return("c") exit("1")`,
+		`name("a")
+
+while ("b") {
+    break("")
+    continue("")
+}
+return("c")
+exit("1")
+`,
+	)
+}
+
+func TestFormatParallel(t *testing.T) {
+	checkFormat(
+		t,
+		`name("a") parallel{branch{call("b")}branch{call("c")}}`,
+		`name("a")
+
+parallel {
+    branch {
+        call("b")
+    }
+    branch {
+        call("c")
+    }
+}
+`,
+	)
+}
+
+func TestFormatAttachesCatchAndFinallyToClosingBrace(t *testing.T) {
+	checkFormat(
+		t,
+		`name("a") try{call("b")}
+		catch('E e'){call("c")}
+		finally{call("d")}`,
+		`name("a")
+
+try {
+    call("b")
+} catch ("E e") {
+    call("c")
+} finally {
+    call("d")
+}
+`,
+	)
+}
+
+func TestFormatAllSeparatesStructograms(t *testing.T) {
+	source := `name("a") instruction("b") // end of a
+// documents c
+name("c")
+call("a()")`
+	structograms, err := ParseAll(strings.NewReader(source))
+	checkOk(t, err)
+	expected := `name("a")
+
+instruction("b") // end of a
+
+// documents c
+name("c")
+
+call("a()")
+`
+	if formatted := FormatAll(structograms); formatted != expected {
+		t.Errorf("Expected:\n%s\nbut got:\n%s", expected, formatted)
+	}
+}
+
+func TestFormatPutsImportsInFrontOfTheName(t *testing.T) {
+	checkFormat(
+		t,
+		`// shared subprograms
+import('common/sorting.str') import("search.str")
+name("a") call("sort(list)")`,
+		`// shared subprograms
+import("common/sorting.str")
+import("search.str")
+
+name("a")
+
+call("sort(list)")
+`,
+	)
+}
@@ -0,0 +1,659 @@
+// Package syntax implements the tokenizer and parser for the .str format,
+// which describes structograms (Nassi-Shneiderman diagrams).
+package syntax
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// Structogram is the root of a parsed .str file. The comments of the file
+// are not part of the tree, they are kept in Comments in source order.
+// NameStart is the position of the name keyword. Imports are the import
+// statements in front of the name.
+type Structogram struct {
+	Name      string
+	Nodes     []Node
+	Imports   []Import  `json:",omitempty"`
+	Comments  []Comment `json:",omitempty"`
+	NameStart Position  `json:"-"`
+}
+
+// Import is an import statement, which makes the structograms of the file
+// at Path available to calls. Start and End span the statement.
+type Import struct {
+	Path  string
+	Start Position `json:"-"`
+	End   Position `json:"-"`
+}
+
+// Comment is a line comment or a block comment, including its delimiters.
+type Comment struct {
+	Text   string
+	Line   int
+	Column int
+}
+
+// Node is a single statement of a structogram. Compound statements, like if
+// or while, contain their body in Nodes. An else node directly follows the
+// if node it belongs to, and so do the catch nodes and the finally node that
+// belong to a try node. The children of a parallel node are branch nodes,
+// which contain the statements that run concurrently. The jump statements
+// break, continue, return and exit leave the surrounding loop, case,
+// subprogram or program.
+//
+// Start and End span the source of the node, from its keyword up to and
+// including its closing parenthesis or brace.
+type Node struct {
+	NodeType string
+	Value    string
+	Nodes    []Node
+	Start    Position `json:"-"`
+	End      Position `json:"-"`
+}
+
+type Parser struct {
+	tokenIndex     int
+	tokens         []Token
+	isInSwitchBody bool
+	isInCaseBody   bool
+	isInLoopBody   bool
+	diagnostics    Diagnostics
+}
+
+// Token is a single lexical element of a structogram source. Line and
+// column are 1-based and point to the first rune of the token, EndLine and
+// EndColumn point just behind its last rune.
+type Token struct {
+	Type      string
+	Value     string
+	Line      int
+	Column    int
+	EndLine   int
+	EndColumn int
+}
+
+// ToJSON returns the tree of s as indented json.
+func (s *Structogram) ToJSON() (string, error) {
+	j, err := json.MarshalIndent(s, "", "    ")
+	if err != nil {
+		return "", err
+	}
+	return string(j), nil
+}
+
+// Parse reads the .str source from r and parses it into a structogram.
+func Parse(r io.Reader) (*Structogram, error) {
+	source, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return ParseTokens(Tokenize(string(source)))
+}
+
+// ParseAll reads the .str source from r and parses all structograms in it.
+func ParseAll(r io.Reader) ([]*Structogram, error) {
+	source, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return ParseAllTokens(Tokenize(string(source)))
+}
+
+// ParseTokens parses the tokens returned by Tokenize into a structogram. The
+// parser recovers from syntax errors by skipping to the next statement or
+// the end of the current body, so all of them are reported at once. In that
+// case, the error is of type Diagnostics and the returned structogram
+// contains everything that could be parsed. A second name is reported as an
+// error, files with several structograms are parsed by ParseAllTokens.
+func ParseTokens(tokens []Token) (*Structogram, error) {
+	structograms, err := ParseAllTokens(tokens)
+	if len(structograms) > 1 {
+		diagnostics, _ := err.(Diagnostics)
+		p := Parser{diagnostics: diagnostics}
+		p.report(Diagnostic{
+			Start:   structograms[1].NameStart,
+			Message: "expected a single structogram, but found another name",
+		})
+		p.diagnostics.sort()
+		err = p.diagnostics
+	}
+	return structograms[0], err
+}
+
+// ParseAllTokens parses the tokens returned by Tokenize into the
+// structograms of a file. Every structogram starts with a name and ends
+// before the next name, so there is always at least one. Errors are
+// reported like by ParseTokens. Comments between two structograms belong
+// to the second one, unless they are on the line where the first one ends,
+// so a comment in front of a name documents its structogram.
+func ParseAllTokens(tokens []Token) ([]*Structogram, error) {
+	// we do not need whitespace for anything, so they just get discarded.
+	// Comments are not part of the tree either, but get kept separately.
+	var cleanTokens []Token
+	var comments []Comment
+	for _, t := range tokens {
+		switch t.Type {
+		case "whitespace":
+		case "comment":
+			comments = append(comments, Comment{
+				Text:   t.Value,
+				Line:   t.Line,
+				Column: t.Column,
+			})
+		default:
+			cleanTokens = append(cleanTokens, t)
+		}
+	}
+
+	p := Parser{
+		tokenIndex: 0,
+		tokens:     cleanTokens,
+	}
+	var structograms []*Structogram
+	for len(structograms) == 0 || p.next().Type != "EOF" {
+		structograms = append(structograms, p.parseStructogram())
+	}
+
+	for _, c := range comments {
+		i := len(structograms) - 1
+		for i > 0 && c.Line <= lastLine(structograms[i-1]) {
+			i--
+		}
+		structograms[i].Comments = append(structograms[i].Comments, c)
+	}
+	if len(p.diagnostics) > 0 {
+		p.diagnostics.sort()
+		return structograms, p.diagnostics
+	}
+	return structograms, nil
+}
+
+// lastLine returns the line on which the statements of s end.
+func lastLine(s *Structogram) int {
+	if len(s.Nodes) == 0 {
+		return s.NameStart.Line
+	}
+	return s.Nodes[len(s.Nodes)-1].End.Line
+}
+
+// parseStructogram parses the imports and the name of a structogram and
+// its statements up to the imports or the name of the next one, or the end
+// of the file.
+func (p *Parser) parseStructogram() *Structogram {
+	parsed := &Structogram{}
+	for p.next().Type == "import" {
+		var imp Import
+		imp.Start = p.readNext().position()
+		path, err := p.parseParentheses()
+		if err != nil {
+			p.report(err)
+			p.synchronize()
+			continue
+		}
+		imp.Path = path
+		imp.End = p.end()
+		parsed.Imports = append(parsed.Imports, imp)
+	}
+	if p.next().Type != "name" {
+		p.report(newTokenTypeError("name", p.next()))
+	} else {
+		parsed.NameStart = p.readNext().position()
+		name, err := p.parseParentheses()
+		if err != nil {
+			p.report(err)
+			p.synchronize()
+		}
+		parsed.Name = name
+	}
+
+	// parseUntil only fails if the delimiter is missing, which can not
+	// happen for EOF.
+	parsed.Nodes, _ = p.parseUntil("EOF")
+	return parsed
+}
+
+func (p *Parser) next() Token {
+	return p.tokens[p.tokenIndex]
+}
+
+// readNext returns the next token and advances past it, unless it is the
+// EOF token, which is never advanced past.
+func (p *Parser) readNext() Token {
+	t := p.next()
+	if t.Type != "EOF" {
+		p.tokenIndex++
+	}
+	return t
+}
+
+// report adds err to the diagnostics. Only the first error on each line is
+// kept, as any further errors on the same line are most likely caused by it.
+func (p *Parser) report(err error) {
+	d, ok := err.(Diagnostic)
+	if !ok {
+		d = Diagnostic{Start: p.next().position(), Message: err.Error()}
+	}
+	for _, reported := range p.diagnostics {
+		if reported.Start.Line == d.Start.Line {
+			return
+		}
+	}
+	p.diagnostics = append(p.diagnostics, d)
+}
+
+// synchronize skips tokens until the start of the next statement, the end
+// of the current body, the imports or the name of the next structogram or
+// the end of the file, so parsing can continue after a syntax error.
+func (p *Parser) synchronize() {
+	for !isKeyword(p.next().Type) &&
+		p.next().Type != "closeBrace" &&
+		p.next().Type != "name" &&
+		p.next().Type != "import" &&
+		p.next().Type != "EOF" {
+		p.readNext()
+	}
+}
+
+func (t Token) position() Position {
+	return Position{Line: t.Line, Column: t.Column}
+}
+
+// end returns the position just behind the last token that was read.
+func (p *Parser) end() Position {
+	if p.tokenIndex == 0 {
+		return p.next().position()
+	}
+	t := p.tokens[p.tokenIndex-1]
+	return Position{Line: t.EndLine, Column: t.EndColumn}
+}
+
+func (p *Parser) parseParentheses() (string, error) {
+	if p.next().Type != "openParentheses" {
+		return "", newTokenTypeError("openParentheses", p.next())
+	}
+	p.readNext()
+
+	if p.next().Type != "string" {
+		return "", newTokenTypeError("string", p.next())
+	}
+	content := p.readNext().Value
+
+	if p.next().Type != "closeParentheses" {
+		return "", newTokenTypeError("closeParentheses", p.next())
+	}
+	p.readNext()
+	return content, nil
+}
+
+// parseUntil parses statements until the delimiter is found. Errors in the
+// statements are reported and recovered from, the returned error is only
+// set if the delimiter is missing. A name ends the statements of the
+// structogram, so it is not read, and it ends any body that is still open,
+// as a missing delimiter. So does an import at the top level, as it belongs
+// to the next structogram.
+func (p *Parser) parseUntil(delimiter string) ([]Node, error) {
+	var nodes []Node
+
+	for p.next().Type != delimiter {
+		if (p.next().Type == "name" || p.next().Type == "import") &&
+			delimiter == "EOF" {
+			return nodes, nil
+		}
+		if p.next().Type == "EOF" || p.next().Type == "name" {
+			return nodes, newTokenTypeError(delimiter, p.next())
+		}
+		start := p.tokenIndex
+		statement, err := p.parseStatement()
+		nodes = append(nodes, statement...)
+		if err != nil {
+			p.report(err)
+			// Skip the token that caused the error, if the statement did not
+			// get past it, so the parser can not get stuck on it.
+			if p.tokenIndex == start {
+				p.readNext()
+			}
+			p.synchronize()
+		}
+	}
+	p.readNext()
+
+	return nodes, nil
+}
+
+// parseStatement parses a single statement. It usually returns one node, but
+// an if followed by an else returns both of them. If the statement contains
+// an error, the nodes that could be parsed are returned along with it.
+func (p *Parser) parseStatement() ([]Node, error) {
+	var err error
+
+	switch p.next().Type {
+	case "instruction", "call", "break", "continue", "return", "exit":
+		var n Node
+		keyword := p.readNext()
+		n.Start = keyword.position()
+		n.NodeType = keyword.Type
+
+		n.Value, err = p.parseParentheses()
+		if err != nil {
+			return nil, err
+		}
+		n.End = p.end()
+		return []Node{n}, p.checkJump(keyword)
+	case "if":
+		ifNode, err := p.parseConditional()
+		nodes := nodesOf(ifNode)
+
+		// Even if the condition is malformed, the else still belongs to
+		// this if.
+		if ifNode != nil && p.next().Type == "else" {
+			elseNode, elseErr := p.parseElse()
+			nodes = append(nodes, elseNode)
+			if err == nil {
+				err = elseErr
+			} else if elseErr != nil {
+				p.report(elseErr)
+			}
+		}
+		return nodes, err
+	case "else", "catch", "finally":
+		return nil, newTokenTypeError("statement", p.next())
+	case "try":
+		var tryNode Node
+		keyword := p.readNext()
+		tryNode.Start = keyword.position()
+		tryNode.NodeType = keyword.Type
+		tryNode.Nodes, err = p.parseBraces()
+		tryNode.End = p.end()
+		nodes := []Node{tryNode}
+
+		handlers, handlerErr := p.parseHandlers()
+		nodes = append(nodes, handlers...)
+		if err == nil {
+			err = handlerErr
+		} else if handlerErr != nil {
+			p.report(handlerErr)
+		}
+		if err == nil && len(handlers) == 0 {
+			err = newDiagnostic(keyword, "'try' needs a catch or a finally")
+		}
+		return nodes, err
+	case "while", "dowhile", "for":
+		wasInLoopBody := p.isInLoopBody
+		p.isInLoopBody = true
+		conditionalNode, err := p.parseConditional()
+		p.isInLoopBody = wasInLoopBody
+		return nodesOf(conditionalNode), err
+	case "switch":
+		switchNode := Node{}
+		switchNode.Start = p.next().position()
+		switchNode.NodeType = p.readNext().Type
+		switchNode.Value, err = p.parseParentheses()
+		if err != nil {
+			return nil, err
+		}
+		switchNode.Nodes, err = p.parseSwitchBody()
+		switchNode.End = p.end()
+		return []Node{switchNode}, err
+	case "default":
+		if p.isInSwitchBody {
+			return nil, newTokenTypeError("keyword", p.next())
+		}
+		var defaultNode Node
+		defaultNode.Start = p.next().position()
+		defaultNode.NodeType = p.readNext().Type
+		defaultNode.Value = ""
+
+		defaultNode.Nodes, err = p.parseBraces()
+		defaultNode.End = p.end()
+		return []Node{defaultNode}, err
+	case "case":
+		if p.isInCaseBody {
+			return nil, newTokenTypeError("keyword", p.next())
+		}
+		caseNode, err := p.parseConditional()
+		return nodesOf(caseNode), err
+	case "parallel":
+		var parallelNode Node
+		keyword := p.readNext()
+		parallelNode.Start = keyword.position()
+		parallelNode.NodeType = keyword.Type
+		parallelNode.Nodes, err = p.parseParallelBody()
+		parallelNode.End = p.end()
+		if err == nil && len(parallelNode.Nodes) < 2 {
+			err = newDiagnostic(
+				keyword, "'parallel' needs at least two branches",
+			)
+		}
+		return []Node{parallelNode}, err
+	case "branch":
+		return nil, newDiagnostic(
+			p.next(), "'branch' is only allowed inside of a parallel",
+		)
+	case "import":
+		return nil, newDiagnostic(
+			p.next(), "'import' is only allowed in front of a name",
+		)
+	}
+	return nil, newTokenTypeError("keyword", p.next())
+}
+
+// checkJump reports an error if the jump statement starting with keyword
+// has nothing to jump out of. A break leaves a loop or a case, a continue
+// can only be used in a loop. Return and exit can be used anywhere.
+func (p *Parser) checkJump(keyword Token) error {
+	switch keyword.Type {
+	case "break":
+		if !p.isInLoopBody && !p.isInSwitchBody {
+			return newDiagnostic(
+				keyword, "'break' is only allowed inside of a loop or a case",
+			)
+		}
+	case "continue":
+		if !p.isInLoopBody {
+			return newDiagnostic(
+				keyword, "'continue' is only allowed inside of a loop",
+			)
+		}
+	}
+	return nil
+}
+
+// nodesOf returns n as a slice, which is empty if n is nil.
+func nodesOf(n *Node) []Node {
+	if n == nil {
+		return nil
+	}
+	return []Node{*n}
+}
+
+// parseBraces parses a body enclosed by braces. A body has to start with a
+// keyword, but if it does not, the rest of the body is still parsed, unless
+// the file ends right away.
+func (p *Parser) parseBraces() ([]Node, error) {
+	if p.next().Type != "openBrace" {
+		return nil, newTokenTypeError("openBrace", p.next())
+	}
+	p.readNext()
+	if !isKeyword(p.next().Type) {
+		err := newTokenTypeError("keyword", p.next())
+		if p.next().Type == "EOF" || p.next().Type == "name" {
+			return nil, err
+		}
+		p.report(err)
+	}
+	return p.parseUntil("closeBrace")
+}
+
+func (p *Parser) parseSwitchBody() ([]Node, error) {
+	// Switches can be nested inside of case bodies, so the state of the
+	// surrounding switch has to be restored afterwards.
+	wasInSwitchBody, wasInCaseBody := p.isInSwitchBody, p.isInCaseBody
+	defer func() {
+		p.isInSwitchBody, p.isInCaseBody = wasInSwitchBody, wasInCaseBody
+	}()
+
+	p.isInSwitchBody = true
+	var switchBody []Node
+	if p.next().Type != "openBrace" {
+		return switchBody, newTokenTypeError("openBrace", p.next())
+	}
+	p.readNext()
+	for p.next().Type == "case" {
+		p.isInCaseBody = true
+		caseNode, err := p.parseConditional()
+		switchBody = append(switchBody, nodesOf(caseNode)...)
+		if err != nil {
+			return switchBody, err
+		}
+	}
+	p.isInCaseBody = false
+	if p.next().Type != "default" {
+		return switchBody, newTokenTypeError("default", p.next())
+	}
+	var defaultNode Node
+	defaultNode.Start = p.next().position()
+	defaultNode.NodeType = p.readNext().Type
+	defaultNode.Value = ""
+	defaultBody, err := p.parseBraces()
+	defaultNode.Nodes = defaultBody
+	defaultNode.End = p.end()
+	switchBody = append(switchBody, defaultNode)
+	if err != nil {
+		return switchBody, err
+	}
+	if p.next().Type != "closeBrace" {
+		return switchBody, newTokenTypeError("closeBrace", p.next())
+	}
+	p.readNext()
+	return switchBody, nil
+}
+
+// parseParallelBody parses the branches of a parallel block. Jumps can not
+// leave a branch, so its body does not count as being inside of a
+// surrounding loop or switch.
+func (p *Parser) parseParallelBody() ([]Node, error) {
+	wasInSwitchBody, wasInCaseBody := p.isInSwitchBody, p.isInCaseBody
+	wasInLoopBody := p.isInLoopBody
+	defer func() {
+		p.isInSwitchBody, p.isInCaseBody = wasInSwitchBody, wasInCaseBody
+		p.isInLoopBody = wasInLoopBody
+	}()
+	p.isInSwitchBody, p.isInCaseBody, p.isInLoopBody = false, false, false
+
+	var branches []Node
+	if p.next().Type != "openBrace" {
+		return branches, newTokenTypeError("openBrace", p.next())
+	}
+	p.readNext()
+	for p.next().Type == "branch" {
+		var branchNode Node
+		branchNode.Start = p.next().position()
+		branchNode.NodeType = p.readNext().Type
+		branchBody, err := p.parseBraces()
+		branchNode.Nodes = branchBody
+		branchNode.End = p.end()
+		branches = append(branches, branchNode)
+		if err != nil {
+			return branches, err
+		}
+	}
+	if p.next().Type != "closeBrace" {
+		return branches, newTokenTypeError("branch", p.next())
+	}
+	p.readNext()
+	return branches, nil
+}
+
+// parseConditional parses a keyword with a value in parentheses, followed
+// by a body. If the value is malformed, the body is still parsed if it can
+// be found, so its statements do not end up in the surrounding body. The
+// returned node is nil if there is no body.
+func (p *Parser) parseConditional() (*Node, error) {
+	node := &Node{}
+
+	node.Start = p.next().position()
+	node.NodeType = p.readNext().Value
+
+	v, err := p.parseParentheses()
+	node.Value = v
+	if err != nil {
+		if !p.skipToBody() {
+			return nil, err
+		}
+		body, bodyErr := p.parseBraces()
+		if bodyErr != nil {
+			p.report(bodyErr)
+		}
+		node.Nodes = body
+		node.End = p.end()
+		return node, err
+	}
+
+	body, err := p.parseBraces()
+	node.Nodes = body
+	node.End = p.end()
+	return node, err
+}
+
+// skipToBody skips to the next open brace, if there is one before the next
+// statement.
+func (p *Parser) skipToBody() bool {
+	start := p.tokenIndex
+	p.synchronize()
+	end := p.tokenIndex
+	for i := start; i < end; i++ {
+		if p.tokens[i].Type == "openBrace" {
+			p.tokenIndex = i
+			return true
+		}
+	}
+	p.tokenIndex = start
+	return false
+}
+
+// parseHandlers parses the catch blocks and the finally block following a
+// try, all of which are optional.
+func (p *Parser) parseHandlers() ([]Node, error) {
+	var handlers []Node
+	for p.next().Type == "catch" {
+		catchNode, err := p.parseConditional()
+		handlers = append(handlers, nodesOf(catchNode)...)
+		if err != nil {
+			return handlers, err
+		}
+	}
+	if p.next().Type != "finally" {
+		return handlers, nil
+	}
+	var finallyNode Node
+	finallyNode.Start = p.next().position()
+	finallyNode.NodeType = p.readNext().Type
+	finallyBody, err := p.parseBraces()
+	finallyNode.Nodes = finallyBody
+	finallyNode.End = p.end()
+	return append(handlers, finallyNode), err
+}
+
+func (p *Parser) parseElse() (Node, error) {
+	var elseNode Node
+
+	elseNode.Start = p.next().position()
+	elseNode.NodeType = p.readNext().Type
+
+	elseBody, err := p.parseBraces()
+	elseNode.Nodes = elseBody
+	elseNode.End = p.end()
+	return elseNode, err
+}
+
+// isKeyword reports whether a statement can start with a token of type s.
+func isKeyword(s string) bool {
+	switch s {
+	case "instruction", "call", "if", "while", "dowhile", "for", "switch",
+		"case", "default", "break", "continue", "return", "exit", "parallel",
+		"branch", "try":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,1055 @@
+package syntax
+
+import (
+	"strings"
+	"testing"
+)
+
+func checkOk(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Errorf("Did not expect any errors, but got %s", err.Error())
+	}
+}
+
+func checkErrorMsg(t *testing.T, err error, expectedMsg string) {
+	t.Helper()
+	if err == nil {
+		t.Errorf("Expected error but was nil")
+	}
+	if err.Error() != expectedMsg {
+		t.Errorf(
+			"Expected error with msg %s, but got %s",
+			expectedMsg,
+			err.Error(),
+		)
+	}
+}
+
+func checkNodeCount(t *testing.T, n []Node, count int) {
+	t.Helper()
+	if len(n) != count {
+		t.Errorf("Wrong node count, expected %d, but got %d", count, len(n))
+	}
+}
+
+func checkNode(t *testing.T, n Node, nodeType string, value string) {
+	t.Helper()
+	if n.NodeType != nodeType {
+		t.Errorf(
+			"Wrong node type, expected %s, but got %s", nodeType, n.NodeType,
+		)
+	}
+	if n.Value != value {
+		t.Errorf("Wrong node value, expected %s, but got %s", value, n.Value)
+	}
+}
+
+func TestEmptyStructogramNameCausesError(t *testing.T) {
+	tokens := Tokenize("name()")
+	_, err := ParseTokens(tokens)
+	checkErrorMsg(t, err, "1:6, expected 'string', but got 'closeParentheses'")
+}
+
+func TestStructogramsHaveNames(t *testing.T) {
+	tokens := Tokenize(`name("test name")`)
+	structogram, err := ParseTokens(tokens)
+	checkOk(t, err)
+
+	if structogram.Name != "test name" {
+		t.Errorf(
+			"Diagram has wrong name, expected: %s, but was: %s",
+			"test name", structogram.Name,
+		)
+	}
+}
+
+func TestNamesCanNotBeNested(t *testing.T) {
+	tokens := Tokenize("name(name())")
+	_, err := ParseTokens(tokens)
+	checkErrorMsg(t, err, "1:6, expected 'string', but got 'name'")
+}
+
+func TestNameHasToBeFirstToken(t *testing.T) {
+	tokens := Tokenize(`instruction("something")name("a name")`)
+	_, err := ParseTokens(tokens)
+	checkErrorMsg(t, err, "1:1, expected 'name', but got 'instruction'")
+}
+
+func TestNameValueHasToBeEnclosedByParentheses(t *testing.T) {
+	tokens := Tokenize(`name"a name"`)
+	_, err := ParseTokens(tokens)
+	checkErrorMsg(t, err, "1:5, expected 'openParentheses', but got 'string'")
+
+	tokens = Tokenize(`name("a"(`)
+	_, err = ParseTokens(tokens)
+	checkErrorMsg(
+		t, err, "1:9, expected 'closeParentheses', but got 'openParentheses'",
+	)
+}
+
+func TestInstructionValueHasToBeEnclosedByParentheses(t *testing.T) {
+	tokens := Tokenize(`name("some name")instruction"something")`)
+	_, err := ParseTokens(tokens)
+	checkErrorMsg(t, err, "1:29, expected 'openParentheses', but got 'string'")
+
+	tokens = Tokenize(`name("a")instruction("b"(`)
+	_, err = ParseTokens(tokens)
+	checkErrorMsg(
+		t, err, "1:25, expected 'closeParentheses', but got 'openParentheses'",
+	)
+}
+
+func TestInstructionsCanNotBeEmpty(t *testing.T) {
+	tokens := Tokenize(`name("test structogram")instruction()`)
+	_, err := ParseTokens(tokens)
+	checkErrorMsg(t, err, "1:37, expected 'string', but got 'closeParentheses'")
+}
+
+func TestInstuctionsCanNotBeNested(t *testing.T) {
+	tokens := Tokenize(`name("a")instruction(instruction())`)
+	_, err := ParseTokens(tokens)
+	checkErrorMsg(t, err, "1:22, expected 'string', but got 'instruction'")
+}
+
+func TestStructogramCanHaveInstructions(t *testing.T) {
+	tokens := Tokenize(`name("a")instruction("something")`)
+	structogram, err := ParseTokens(tokens)
+	checkOk(t, err)
+	checkNodeCount(t, structogram.Nodes, 1)
+	checkNode(t, structogram.Nodes[0], "instruction", "something")
+}
+
+func TestStructogramsCanHaveMultipleInstructions(t *testing.T) {
+	tokens := Tokenize(`name("a")instruction("b")instruction("c")`)
+	structogram, err := ParseTokens(tokens)
+	checkOk(t, err)
+	checkNodeCount(t, structogram.Nodes, 2)
+	checkNode(t, structogram.Nodes[0], "instruction", "b")
+	checkNode(t, structogram.Nodes[1], "instruction", "c")
+}
+
+func TestParserCanHandleInvalidTokens(t *testing.T) {
+	tokens := Tokenize(`name("a")asd`)
+	_, err := ParseTokens(tokens)
+	checkErrorMsg(t, err, "1:10, expected 'keyword', but got 'invalid'")
+}
+
+func TestParserIgnoresWhitespaceTokens(t *testing.T) {
+	tokens := Tokenize(`name("a")` + "\n " + `instruction("b")`)
+	_, err := ParseTokens(tokens)
+	checkOk(t, err)
+}
+
+func TestIfTokenValuesAreEnclosedByParentheses(t *testing.T) {
+	tokens := Tokenize(`name("a")if"b")`)
+	_, err := ParseTokens(tokens)
+	checkErrorMsg(t, err, "1:12, expected 'openParentheses', but got 'string'")
+
+	tokens = Tokenize(`name("a")if("b"`)
+	_, err = ParseTokens(tokens)
+	checkErrorMsg(t, err, "1:16, expected 'closeParentheses', but got 'EOF'")
+}
+
+func TestIfTokenValueCanNotBeEmpty(t *testing.T) {
+	tokens := Tokenize(`name("a")if()`)
+	_, err := ParseTokens(tokens)
+	checkErrorMsg(t, err, "1:13, expected 'string', but got 'closeParentheses'")
+}
+
+func TestIfTokenHasToHaveBody(t *testing.T) {
+	tokens := Tokenize(`name("a")if ("b")`)
+	_, err := ParseTokens(tokens)
+	checkErrorMsg(t, err, "1:18, expected 'openBrace', but got 'EOF'")
+
+	// The only valid tokens inside of an if-body are keywords or whitespace.
+	// Whitespace should get entirely ignored, and anything that is not a
+	// keyword, so either a string or EOF should cause an error.
+	// The only exception are openParentheses, which are legal if they
+	// are preceeded by a keyword
+	tokens = Tokenize(`name("a")if("b"){`)
+	_, err = ParseTokens(tokens)
+	checkErrorMsg(t, err, "1:18, expected 'keyword', but got 'EOF'")
+
+	tokens = Tokenize(`name("a")if("b"){"c"`)
+	_, err = ParseTokens(tokens)
+	checkErrorMsg(t, err, "1:18, expected 'keyword', but got 'string'")
+
+	tokens = Tokenize(`name("a")if("b"){name}`)
+	_, err = ParseTokens(tokens)
+	checkErrorMsg(t, err, "1:18, expected 'keyword', but got 'name'")
+
+	tokens = Tokenize(`name("a") if("b") {instruction("c")`)
+	_, err = ParseTokens(tokens)
+	checkErrorMsg(t, err, "1:36, expected 'closeBrace', but got 'EOF'")
+}
+
+func TestIfTokenCanHaveWhitespaceBetweenConditionAndBody(t *testing.T) {
+	tokens := Tokenize(`name("a")if("b")` + "\n " + `{instruction("c")}`)
+	_, err := ParseTokens(tokens)
+	checkOk(t, err)
+}
+
+func TestInstructionTokenInsideIfBodyBehavesTheSameAsOutside(t *testing.T) {
+	tokens := Tokenize(`name("a") if("b") {instruction}`)
+	_, err := ParseTokens(tokens)
+	checkErrorMsg(
+		t, err, "1:31, expected 'openParentheses', but got 'closeBrace'",
+	)
+
+	tokens = Tokenize(`name("a") if("b") {instruction(}`)
+	_, err = ParseTokens(tokens)
+	checkErrorMsg(t, err, "1:32, expected 'string', but got 'closeBrace'")
+
+	tokens = Tokenize(`name("a") if("b") {instruction("c"}`)
+	_, err = ParseTokens(tokens)
+	checkErrorMsg(
+		t, err, "1:35, expected 'closeParentheses', but got 'closeBrace'",
+	)
+}
+
+func TestCanParseMultipleInstructionsInsideIfBody(t *testing.T) {
+	tokens := Tokenize(
+		`name("a") if("b") {instruction("c") instruction("d")}`,
+	)
+	structogram, err := ParseTokens(tokens)
+	checkOk(t, err)
+
+	checkNodeCount(t, structogram.Nodes, 1)
+	ifNode := structogram.Nodes[0]
+	checkNode(t, ifNode, "if", "b")
+
+	ifBody := ifNode.Nodes
+	checkNodeCount(t, ifBody, 2)
+	instructionNode := ifBody[0]
+	checkNode(t, instructionNode, "instruction", "c")
+
+	instructionNode = ifBody[1]
+	checkNode(t, instructionNode, "instruction", "d")
+}
+
+func TestCanParseNestedIfs(t *testing.T) {
+	tokens := Tokenize(`name("a") if("b") {if("c"){instruction("d")}}`)
+	structogram, err := ParseTokens(tokens)
+	checkOk(t, err)
+
+	checkNodeCount(t, structogram.Nodes, 1)
+	ifNode := structogram.Nodes[0]
+	checkNode(t, ifNode, "if", "b")
+
+	ifBody := ifNode.Nodes
+	checkNodeCount(t, ifBody, 1)
+
+	nestedIf := ifBody[0]
+	checkNode(t, nestedIf, "if", "c")
+
+	nestedIfBody := nestedIf.Nodes
+	checkNodeCount(t, nestedIfBody, 1)
+	checkNode(t, nestedIfBody[0], "instruction", "d")
+}
+
+func TestElseWithoutIfCausesError(t *testing.T) {
+	tokens := Tokenize(`name("a") else {instruction("b")}`)
+	_, err := ParseTokens(tokens)
+	checkErrorMsg(t, err, "1:11, expected 'statement', but got 'else'")
+}
+
+func TestCanParseElse(t *testing.T) {
+	tokens := Tokenize(
+		`name("a") if("b") {instruction("c")} else {instruction("d")}`,
+	)
+	structogram, err := ParseTokens(tokens)
+	checkOk(t, err)
+
+	checkNodeCount(t, structogram.Nodes, 2)
+
+	elseNode := structogram.Nodes[1]
+	checkNode(t, elseNode, "else", "")
+	checkNodeCount(t, elseNode.Nodes, 1)
+
+	elseBody := elseNode.Nodes
+	checkNodeCount(t, elseBody, 1)
+	checkNode(t, elseBody[0], "instruction", "d")
+}
+
+func TestCanParseCall(t *testing.T) {
+	tokens := Tokenize(`name("a") call`)
+	_, err := ParseTokens(tokens)
+	checkErrorMsg(t, err, "1:15, expected 'openParentheses', but got 'EOF'")
+
+	tokens = Tokenize(`name("a") call(`)
+	_, err = ParseTokens(tokens)
+	checkErrorMsg(t, err, "1:16, expected 'string', but got 'EOF'")
+
+	tokens = Tokenize(`name("a") call("b"`)
+	_, err = ParseTokens(tokens)
+	checkErrorMsg(t, err, "1:19, expected 'closeParentheses', but got 'EOF'")
+
+	tokens = Tokenize(`name("a") call("b")`)
+	structogram, err := ParseTokens(tokens)
+	checkOk(t, err)
+
+	checkNodeCount(t, structogram.Nodes, 1)
+	checkNode(t, structogram.Nodes[0], "call", "b")
+}
+
+func TestCanParseCallInsideIfBody(t *testing.T) {
+	tokens := Tokenize(`name("a") if("b") {call("c")}`)
+	structogram, err := ParseTokens(tokens)
+	checkOk(t, err)
+
+	checkNodeCount(t, structogram.Nodes, 1)
+	ifNode := structogram.Nodes[0]
+	checkNode(t, ifNode, "if", "b")
+
+	checkNodeCount(t, ifNode.Nodes, 1)
+	checkNode(t, ifNode.Nodes[0], "call", "c")
+}
+
+func TestWhileHasToHaveCondition(t *testing.T) {
+	tokens := Tokenize(`name("a") while`)
+	_, err := ParseTokens(tokens)
+	checkErrorMsg(t, err, "1:16, expected 'openParentheses', but got 'EOF'")
+
+	tokens = Tokenize(`name("a") while(`)
+	_, err = ParseTokens(tokens)
+	checkErrorMsg(t, err, "1:17, expected 'string', but got 'EOF'")
+
+	tokens = Tokenize(`name("a") while("a"`)
+	_, err = ParseTokens(tokens)
+	checkErrorMsg(t, err, "1:20, expected 'closeParentheses', but got 'EOF'")
+}
+
+func TestWhileTokenHasToHaveBody(t *testing.T) {
+	tokens := Tokenize(`name("a")while("b")`)
+	_, err := ParseTokens(tokens)
+	checkErrorMsg(t, err, "1:20, expected 'openBrace', but got 'EOF'")
+
+	tokens = Tokenize(`name("a")while("b"){`)
+	_, err = ParseTokens(tokens)
+	checkErrorMsg(t, err, "1:21, expected 'keyword', but got 'EOF'")
+
+	tokens = Tokenize(`name("a")while("b"){"c"`)
+	_, err = ParseTokens(tokens)
+	checkErrorMsg(t, err, "1:21, expected 'keyword', but got 'string'")
+
+	tokens = Tokenize(`name("a")while("b"){name}`)
+	_, err = ParseTokens(tokens)
+	checkErrorMsg(t, err, "1:21, expected 'keyword', but got 'name'")
+
+	tokens = Tokenize(`name("a") while("b") {instruction("c")`)
+	_, err = ParseTokens(tokens)
+	checkErrorMsg(t, err, "1:39, expected 'closeBrace', but got 'EOF'")
+}
+
+func TestCanParseWhileBody(t *testing.T) {
+	tokens := Tokenize(
+		`name("a")
+		 while("b") {
+			 instruction("c")
+			 call("d")
+			 if("e") {
+				 instruction("f")
+			 }
+
+			 while("g") {
+				 instruction("h")
+			 }
+		 }`,
+	)
+	structogram, err := ParseTokens(tokens)
+	checkOk(t, err)
+	checkNodeCount(t, structogram.Nodes, 1)
+
+	whileNode := structogram.Nodes[0]
+	checkNode(t, whileNode, "while", "b")
+	whileBody := whileNode.Nodes
+	checkNodeCount(t, whileBody, 4)
+
+	instructionNode := whileBody[0]
+	checkNode(t, instructionNode, "instruction", "c")
+	checkNodeCount(t, instructionNode.Nodes, 0)
+
+	callNode := whileBody[1]
+	checkNode(t, callNode, "call", "d")
+	checkNodeCount(t, callNode.Nodes, 0)
+
+	ifNode := whileBody[2]
+	checkNode(t, ifNode, "if", "e")
+	ifBody := ifNode.Nodes
+	checkNodeCount(t, ifBody, 1)
+	instructionNode = ifBody[0]
+	checkNode(t, instructionNode, "instruction", "f")
+	checkNodeCount(t, instructionNode.Nodes, 0)
+
+	nestedWhileNode := whileBody[3]
+	checkNode(t, nestedWhileNode, "while", "g")
+	nestedWhileBody := nestedWhileNode.Nodes
+	checkNodeCount(t, nestedWhileBody, 1)
+	instructionNode = nestedWhileBody[0]
+	checkNode(t, instructionNode, "instruction", "h")
+	checkNodeCount(t, instructionNode.Nodes, 0)
+}
+
+func TestDoWhileHasToHaveCondition(t *testing.T) {
+	tokens := Tokenize(`name("a") dowhile`)
+	_, err := ParseTokens(tokens)
+	checkErrorMsg(t, err, "1:18, expected 'openParentheses', but got 'EOF'")
+
+	tokens = Tokenize(`name("a") dowhile(`)
+	_, err = ParseTokens(tokens)
+	checkErrorMsg(t, err, "1:19, expected 'string', but got 'EOF'")
+
+	tokens = Tokenize(`name("a") dowhile("a"`)
+	_, err = ParseTokens(tokens)
+	checkErrorMsg(t, err, "1:22, expected 'closeParentheses', but got 'EOF'")
+}
+
+func TestDoWhileTokenHasToHaveBody(t *testing.T) {
+	tokens := Tokenize(`name("a")dowhile("b")`)
+	_, err := ParseTokens(tokens)
+	checkErrorMsg(t, err, "1:22, expected 'openBrace', but got 'EOF'")
+
+	tokens = Tokenize(`name("a")dowhile("b"){`)
+	_, err = ParseTokens(tokens)
+	checkErrorMsg(t, err, "1:23, expected 'keyword', but got 'EOF'")
+
+	tokens = Tokenize(`name("a")dowhile("b"){"c"`)
+	_, err = ParseTokens(tokens)
+	checkErrorMsg(t, err, "1:23, expected 'keyword', but got 'string'")
+
+	tokens = Tokenize(`name("a")dowhile("b"){name}`)
+	_, err = ParseTokens(tokens)
+	checkErrorMsg(t, err, "1:23, expected 'keyword', but got 'name'")
+
+	tokens = Tokenize(`name("a") dowhile("b") {instruction("c")`)
+	_, err = ParseTokens(tokens)
+	checkErrorMsg(t, err, "1:41, expected 'closeBrace', but got 'EOF'")
+}
+
+func TestCanParseDoWhileBody(t *testing.T) {
+	tokens := Tokenize(
+		`name("a")
+		 dowhile("b") {
+			 instruction("c")
+			 call("d")
+			 if("e") {
+				 instruction("f")
+			 }
+
+			 dowhile("g") {
+				 instruction("h")
+			 }
+		 }`,
+	)
+	structogram, err := ParseTokens(tokens)
+	checkOk(t, err)
+	checkNodeCount(t, structogram.Nodes, 1)
+
+	doWhileNode := structogram.Nodes[0]
+	checkNode(t, doWhileNode, "dowhile", "b")
+	doWhileBody := doWhileNode.Nodes
+	checkNodeCount(t, doWhileBody, 4)
+
+	instructionNode := doWhileBody[0]
+	checkNode(t, instructionNode, "instruction", "c")
+	checkNodeCount(t, instructionNode.Nodes, 0)
+
+	callNode := doWhileBody[1]
+	checkNode(t, callNode, "call", "d")
+	checkNodeCount(t, callNode.Nodes, 0)
+
+	ifNode := doWhileBody[2]
+	checkNode(t, ifNode, "if", "e")
+	ifBody := ifNode.Nodes
+	checkNodeCount(t, ifBody, 1)
+	instructionNode = ifBody[0]
+	checkNode(t, instructionNode, "instruction", "f")
+	checkNodeCount(t, instructionNode.Nodes, 0)
+
+	nestedDoWhileNode := doWhileBody[3]
+	checkNode(t, nestedDoWhileNode, "dowhile", "g")
+	nestedDoWhileBody := nestedDoWhileNode.Nodes
+	checkNodeCount(t, nestedDoWhileBody, 1)
+	instructionNode = nestedDoWhileBody[0]
+	checkNode(t, instructionNode, "instruction", "h")
+	checkNodeCount(t, instructionNode.Nodes, 0)
+}
+
+func TestSwitchHasToHaveCondition(t *testing.T) {
+	tokens := Tokenize(`name("a") switch`)
+	_, err := ParseTokens(tokens)
+	checkErrorMsg(t, err, "1:17, expected 'openParentheses', but got 'EOF'")
+
+	tokens = Tokenize(`name("a") switch(`)
+	_, err = ParseTokens(tokens)
+	checkErrorMsg(t, err, "1:18, expected 'string', but got 'EOF'")
+
+	tokens = Tokenize(`name("a") switch("b"`)
+	_, err = ParseTokens(tokens)
+	checkErrorMsg(t, err, "1:21, expected 'closeParentheses', but got 'EOF'")
+}
+
+func TestCanParseDefault(t *testing.T) {
+	tokens := Tokenize(`name("a") switch("b") {default}`)
+	_, err := ParseTokens(tokens)
+	checkErrorMsg(t, err, "1:31, expected 'openBrace', but got 'closeBrace'")
+
+	tokens = Tokenize(`name("a") switch("b") {default {`)
+	_, err = ParseTokens(tokens)
+	checkErrorMsg(t, err, "1:33, expected 'keyword', but got 'EOF'")
+
+	tokens = Tokenize(`name("a") switch("b"){default {instruction("b")}`)
+	_, err = ParseTokens(tokens)
+	checkErrorMsg(t, err, "1:49, expected 'closeBrace', but got 'EOF'")
+
+	tokens = Tokenize(`name("a") switch("b"){default {instruction("b")}}`)
+	structogram, err := ParseTokens(tokens)
+	checkOk(t, err)
+	_ = structogram
+
+	checkNodeCount(t, structogram.Nodes, 1)
+	switchNode := structogram.Nodes[0]
+	checkNode(t, switchNode, "switch", "b")
+	switchBody := switchNode.Nodes
+	checkNodeCount(t, switchBody, 1)
+	defaultNode := switchBody[0]
+	checkNode(t, defaultNode, "default", "")
+	defaultBody := defaultNode.Nodes
+	checkNodeCount(t, defaultBody, 1)
+	checkNode(t, defaultNode.Nodes[0], "instruction", "b")
+}
+
+func TestSwitchBodyHasToHaveDefaultCase(t *testing.T) {
+	tokens := Tokenize(`name("a") switch("b") {case("c"){instruction("d")} }`)
+	_, err := ParseTokens(tokens)
+	checkErrorMsg(t, err, "1:52, expected 'default', but got 'closeBrace'")
+}
+
+func TestCanParseCase(t *testing.T) {
+	tokens := Tokenize(`name("a") case`)
+	_, err := ParseTokens(tokens)
+	checkErrorMsg(t, err, "1:15, expected 'openParentheses', but got 'EOF'")
+
+	tokens = Tokenize(`name("a") case(`)
+	_, err = ParseTokens(tokens)
+	checkErrorMsg(t, err, "1:16, expected 'string', but got 'EOF'")
+
+	tokens = Tokenize(`name("a") case("b"`)
+	_, err = ParseTokens(tokens)
+	checkErrorMsg(t, err, "1:19, expected 'closeParentheses', but got 'EOF'")
+
+	tokens = Tokenize(`name("a") case("b")`)
+	_, err = ParseTokens(tokens)
+	checkErrorMsg(t, err, "1:20, expected 'openBrace', but got 'EOF'")
+
+	tokens = Tokenize(`name("a") case("b") {`)
+	_, err = ParseTokens(tokens)
+	checkErrorMsg(t, err, "1:22, expected 'keyword', but got 'EOF'")
+
+	tokens = Tokenize(`name("a") case("b") { instruction("c")`)
+	_, err = ParseTokens(tokens)
+	checkErrorMsg(t, err, "1:39, expected 'closeBrace', but got 'EOF'")
+
+	tokens = Tokenize(`name("a") case("b") {instruction("c")}`)
+	structogram, err := ParseTokens(tokens)
+	checkOk(t, err)
+	checkNodeCount(t, structogram.Nodes, 1)
+	caseNode := structogram.Nodes[0]
+	checkNode(t, caseNode, "case", "b")
+	caseBody := caseNode.Nodes
+	checkNodeCount(t, caseBody, 1)
+	checkNode(t, caseBody[0], "instruction", "c")
+}
+
+func TestMissingClosingBraceAfterCaseInsideSwitchBody(t *testing.T) {
+	tokens := Tokenize(`name("a") switch("b") { case("c") { instruction("d") default {instruction("e")}}`)
+	_, err := ParseTokens(tokens)
+	checkErrorMsg(t, err, "1:54, expected 'keyword', but got 'default'")
+}
+
+func TestParseReadsSourceFromReader(t *testing.T) {
+	structogram, err := Parse(strings.NewReader(`name("a") call("b")`))
+	checkOk(t, err)
+	if structogram.Name != "a" {
+		t.Errorf("Wrong name, expected a, but got %s", structogram.Name)
+	}
+	checkNodeCount(t, structogram.Nodes, 1)
+	checkNode(t, structogram.Nodes[0], "call", "b")
+}
+
+func TestParserSkipsComments(t *testing.T) {
+	tokens := Tokenize(`// leading
+		name("a") // after name
+		/* before */ instruction("b")
+		if("c") {
+			// inside body
+			call("d")
+			/* at the end
+			   of the body */
+		}`)
+	structogram, err := ParseTokens(tokens)
+	checkOk(t, err)
+	checkNodeCount(t, structogram.Nodes, 2)
+	checkNode(t, structogram.Nodes[0], "instruction", "b")
+	checkNode(t, structogram.Nodes[1], "if", "c")
+	checkNodeCount(t, structogram.Nodes[1].Nodes, 1)
+	checkNode(t, structogram.Nodes[1].Nodes[0], "call", "d")
+}
+
+func TestParserKeepsCommentsInSourceOrder(t *testing.T) {
+	tokens := Tokenize("name(\"a\") // first\n/* second */ instruction(\"b\")")
+	structogram, err := ParseTokens(tokens)
+	checkOk(t, err)
+
+	comments := structogram.Comments
+	if len(comments) != 2 {
+		t.Fatalf("Expected 2 comments, but got %d", len(comments))
+	}
+	if comments[0] != (Comment{Text: "// first", Line: 1, Column: 11}) {
+		t.Errorf("Wrong first comment, got %+v", comments[0])
+	}
+	if comments[1] != (Comment{Text: "/* second */", Line: 2, Column: 1}) {
+		t.Errorf("Wrong second comment, got %+v", comments[1])
+	}
+}
+
+func TestCommentsCanNotBeUsedAsStatementValues(t *testing.T) {
+	tokens := Tokenize(`name("a") instruction(/* b */)`)
+	_, err := ParseTokens(tokens)
+	checkErrorMsg(t, err, "1:30, expected 'string', but got 'closeParentheses'")
+}
+
+func TestLineNumbersAreCorrectAfterMultilineStrings(t *testing.T) {
+	tokens := Tokenize("name(\"a\")\ninstruction(\"\"\"\n    b\n    c\n\"\"\")\nif")
+	structogram, err := ParseTokens(tokens)
+	checkErrorMsg(t, err, "6:3, expected 'openParentheses', but got 'EOF'")
+	checkNodeCount(t, structogram.Nodes, 1)
+	checkNode(t, structogram.Nodes[0], "instruction", "b\nc")
+}
+
+func checkDiagnostics(t *testing.T, err error, expectedMsgs ...string) {
+	t.Helper()
+	diagnostics, ok := err.(Diagnostics)
+	if !ok {
+		t.Fatalf("Expected diagnostics, but got %v", err)
+	}
+	if len(diagnostics) != len(expectedMsgs) {
+		t.Errorf(
+			"Expected %d diagnostics, but got %d: %v",
+			len(expectedMsgs), len(diagnostics), diagnostics,
+		)
+		return
+	}
+	for i, d := range diagnostics {
+		if d.Error() != expectedMsgs[i] {
+			t.Errorf(
+				"Expected diagnostic %s, but got %s", expectedMsgs[i], d.Error(),
+			)
+		}
+	}
+}
+
+func TestParserReportsAllErrorsAtOnce(t *testing.T) {
+	tokens := Tokenize(`name("a")
+		instruction()
+		call("b")
+		while "c" {
+			instruction("d")
+		}
+		if("e") {
+			call(f)
+		}`)
+	structogram, err := ParseTokens(tokens)
+	checkDiagnostics(
+		t, err,
+		"2:15, expected 'string', but got 'closeParentheses'",
+		"4:9, expected 'openParentheses', but got 'string'",
+		"8:9, expected 'string', but got 'invalid'",
+	)
+
+	checkNodeCount(t, structogram.Nodes, 3)
+	checkNode(t, structogram.Nodes[0], "call", "b")
+	checkNode(t, structogram.Nodes[1], "while", "")
+	checkNodeCount(t, structogram.Nodes[1].Nodes, 1)
+	checkNode(t, structogram.Nodes[1].Nodes[0], "instruction", "d")
+	checkNode(t, structogram.Nodes[2], "if", "e")
+}
+
+func TestDiagnosticsSummarizeFurtherErrors(t *testing.T) {
+	tokens := Tokenize("name(\"a\")\ninstruction()\ncall()")
+	_, err := ParseTokens(tokens)
+	checkErrorMsg(
+		t, err,
+		"2:13, expected 'string', but got 'closeParentheses' "+
+			"(and 1 more errors)",
+	)
+}
+
+func TestOnlyFirstErrorOfALineIsReported(t *testing.T) {
+	tokens := Tokenize(`name("a") instruction() call() if() {}`)
+	_, err := ParseTokens(tokens)
+	checkDiagnostics(
+		t, err, "1:23, expected 'string', but got 'closeParentheses'",
+	)
+}
+
+func TestDiagnosticsSpanTheOffendingToken(t *testing.T) {
+	tokens := Tokenize("name(\"a\")\ninstruction(\n    \"\"\"b\n    c\"\"\" (")
+	_, err := ParseTokens(tokens)
+	diagnostics := err.(Diagnostics)
+	if len(diagnostics) != 1 {
+		t.Fatalf("Expected 1 diagnostic, but got %d", len(diagnostics))
+	}
+	expected := Diagnostic{
+		Start:   Position{Line: 4, Column: 10},
+		End:     Position{Line: 4, Column: 11},
+		Message: "expected 'closeParentheses', but got 'openParentheses'",
+	}
+	if diagnostics[0] != expected {
+		t.Errorf("Expected %+v, but got %+v", expected, diagnostics[0])
+	}
+
+	tokens = Tokenize("name(\"a\") instruction(\"\"\"b\nc\"\"\"")
+	_, err = ParseTokens(tokens)
+	diagnostics = err.(Diagnostics)
+	if diagnostics[0].Start != diagnostics[0].End {
+		t.Errorf("Expected EOF to have an empty span, got %+v", diagnostics[0])
+	}
+}
+
+func TestBodyOfMalformedConditionIsStillParsed(t *testing.T) {
+	tokens := Tokenize(`name("a")
+		if(b) {
+			instruction("c")
+		} else {
+			instruction("d")
+		}
+		instruction("e")`)
+	structogram, err := ParseTokens(tokens)
+	checkDiagnostics(t, err, "2:6, expected 'string', but got 'invalid'")
+	checkNodeCount(t, structogram.Nodes, 3)
+	checkNode(t, structogram.Nodes[0], "if", "")
+	checkNode(t, structogram.Nodes[0].Nodes[0], "instruction", "c")
+	checkNode(t, structogram.Nodes[1], "else", "")
+	checkNode(t, structogram.Nodes[2], "instruction", "e")
+}
+
+func TestParserDoesNotGetStuckOnUnexpectedTokens(t *testing.T) {
+	tokens := Tokenize("name(\"a\") \"b\"\n}\n{\n)\ninstruction(\"c\")")
+	structogram, err := ParseTokens(tokens)
+	checkDiagnostics(
+		t, err,
+		"1:11, expected 'keyword', but got 'string'",
+		"2:1, expected 'keyword', but got 'closeBrace'",
+	)
+	checkNodeCount(t, structogram.Nodes, 1)
+	checkNode(t, structogram.Nodes[0], "instruction", "c")
+}
+
+func TestBodiesCanStartWithAnyStatement(t *testing.T) {
+	tokens := Tokenize(`name("a")
+		if("b") {
+			while("c") {
+				for("d") {
+					dowhile("e") {
+						instruction("f")
+					}
+				}
+			}
+		}`)
+	_, err := ParseTokens(tokens)
+	checkOk(t, err)
+}
+
+func TestMissingClosingBracesAreReportedOnce(t *testing.T) {
+	tokens := Tokenize("name(\"a\")\nwhile(\"b\") {\nif(\"c\") {\ncall(\"d\")")
+	structogram, err := ParseTokens(tokens)
+	checkDiagnostics(t, err, "4:10, expected 'closeBrace', but got 'EOF'")
+	checkNodeCount(t, structogram.Nodes, 1)
+	checkNode(t, structogram.Nodes[0].Nodes[0].Nodes[0], "call", "d")
+}
+
+func checkSpan(t *testing.T, n Node, start Position, end Position) {
+	t.Helper()
+	if n.Start != start || n.End != end {
+		t.Errorf(
+			"Wrong span of %s, expected %v to %v, but got %v to %v",
+			n.NodeType, start, end, n.Start, n.End,
+		)
+	}
+}
+
+func TestNodesSpanTheirSource(t *testing.T) {
+	tokens := Tokenize(`name("a")
+instruction("b")
+if ("c") {
+    call("d")
+} else {
+    switch ("e") {
+        case ("f") {
+            instruction("g")
+        }
+        default {
+            instruction("h")
+        }
+    }
+}`)
+	structogram, err := ParseTokens(tokens)
+	checkOk(t, err)
+
+	nodes := structogram.Nodes
+	checkSpan(t, nodes[0], Position{2, 1}, Position{2, 17})
+	checkSpan(t, nodes[1], Position{3, 1}, Position{5, 2})
+	checkSpan(t, nodes[1].Nodes[0], Position{4, 5}, Position{4, 14})
+	checkSpan(t, nodes[2], Position{5, 3}, Position{14, 2})
+
+	switchNode := nodes[2].Nodes[0]
+	checkSpan(t, switchNode, Position{6, 5}, Position{13, 6})
+	checkSpan(t, switchNode.Nodes[0], Position{7, 9}, Position{9, 10})
+	checkSpan(t, switchNode.Nodes[1], Position{10, 9}, Position{12, 10})
+}
+
+func TestNodeSpansAreNotPartOfJson(t *testing.T) {
+	structogram, err := ParseTokens(Tokenize(`name("a") call("b")`))
+	checkOk(t, err)
+	j, err := structogram.ToJSON()
+	checkOk(t, err)
+	if strings.Contains(j, "Start") || strings.Contains(j, "Line") {
+		t.Errorf("Did not expect positions in json, got %s", j)
+	}
+}
+
+func TestCanParseJumps(t *testing.T) {
+	tokens := Tokenize(`name("a")
+		while("b") {
+			break("c")
+			continue("")
+		}
+		return("d")
+		exit("1")`)
+	structogram, err := ParseTokens(tokens)
+	checkOk(t, err)
+	checkNodeCount(t, structogram.Nodes, 3)
+	loopBody := structogram.Nodes[0].Nodes
+	checkNodeCount(t, loopBody, 2)
+	checkNode(t, loopBody[0], "break", "c")
+	checkNode(t, loopBody[1], "continue", "")
+	checkNode(t, structogram.Nodes[1], "return", "d")
+	checkNode(t, structogram.Nodes[2], "exit", "1")
+
+	tokens = Tokenize(`name("a") return`)
+	_, err = ParseTokens(tokens)
+	checkErrorMsg(t, err, "1:17, expected 'openParentheses', but got 'EOF'")
+}
+
+func TestBreakHasToBeInsideOfLoopOrCase(t *testing.T) {
+	tokens := Tokenize(`name("a") if("b") { break("") }`)
+	structogram, err := ParseTokens(tokens)
+	checkErrorMsg(t, err, "1:21, 'break' is only allowed inside of a loop or a case")
+	// The break is still part of the tree.
+	checkNodeCount(t, structogram.Nodes[0].Nodes, 1)
+
+	tokens = Tokenize(`name("a")
+		for("b") { if("c") { break("") } }
+		dowhile("d") { break("") }
+		switch("e") {
+			case("f") { if("g") { break("") } }
+			default { break("") }
+		}`)
+	_, err = ParseTokens(tokens)
+	checkOk(t, err)
+
+	// The loop or switch has to be around the break, not before it.
+	tokens = Tokenize(`name("a") while("b") { call("c") } break("")`)
+	_, err = ParseTokens(tokens)
+	checkErrorMsg(t, err, "1:36, 'break' is only allowed inside of a loop or a case")
+}
+
+func TestContinueHasToBeInsideOfLoop(t *testing.T) {
+	tokens := Tokenize(`name("a")
+		switch("b") { case("c") { continue("") } default { call("d") } }`)
+	_, err := ParseTokens(tokens)
+	checkErrorMsg(t, err, "2:29, 'continue' is only allowed inside of a loop")
+
+	tokens = Tokenize(`name("a")
+		while("b") {
+			switch("c") { case("d") { continue("") } default { call("e") } }
+		}`)
+	_, err = ParseTokens(tokens)
+	checkOk(t, err)
+}
+
+func TestCanParseParallel(t *testing.T) {
+	tokens := Tokenize(`name("a") parallel {
+		branch { instruction("b") call("c") }
+		branch { instruction("d") }
+	}`)
+	structogram, err := ParseTokens(tokens)
+	checkOk(t, err)
+	checkNodeCount(t, structogram.Nodes, 1)
+	parallelNode := structogram.Nodes[0]
+	checkNode(t, parallelNode, "parallel", "")
+	checkNodeCount(t, parallelNode.Nodes, 2)
+	checkNode(t, parallelNode.Nodes[0], "branch", "")
+	checkNodeCount(t, parallelNode.Nodes[0].Nodes, 2)
+	checkNode(t, parallelNode.Nodes[1].Nodes[0], "instruction", "d")
+
+	tokens = Tokenize(`name("a") parallel`)
+	_, err = ParseTokens(tokens)
+	checkErrorMsg(t, err, "1:19, expected 'openBrace', but got 'EOF'")
+
+	tokens = Tokenize(`name("a") parallel { branch { call("b") } call("c") }`)
+	_, err = ParseTokens(tokens)
+	checkErrorMsg(t, err, "1:43, expected 'branch', but got 'call'")
+}
+
+func TestParallelNeedsAtLeastTwoBranches(t *testing.T) {
+	tokens := Tokenize(`name("a") parallel { branch { call("b") } }`)
+	_, err := ParseTokens(tokens)
+	checkErrorMsg(t, err, "1:11, 'parallel' needs at least two branches")
+
+	tokens = Tokenize(`name("a") parallel {}`)
+	_, err = ParseTokens(tokens)
+	checkErrorMsg(t, err, "1:11, 'parallel' needs at least two branches")
+}
+
+func TestBranchHasToBeInsideOfParallel(t *testing.T) {
+	tokens := Tokenize(`name("a") branch { call("b") }`)
+	_, err := ParseTokens(tokens)
+	checkErrorMsg(t, err, "1:11, 'branch' is only allowed inside of a parallel")
+}
+
+func TestJumpsCanNotLeaveBranches(t *testing.T) {
+	tokens := Tokenize(`name("a") while("b") {
+		parallel {
+			branch { break("") }
+			branch { while("c") { break("") } }
+		}
+	}`)
+	_, err := ParseTokens(tokens)
+	checkErrorMsg(t, err, "3:13, 'break' is only allowed inside of a loop or a case")
+}
+
+func TestCanParseTryCatchFinally(t *testing.T) {
+	tokens := Tokenize(`name("a")
+		try { call("b") }
+		catch("IOException e") { instruction("c") }
+		catch("") { instruction("d") }
+		finally { instruction("e") }
+		instruction("f")`)
+	structogram, err := ParseTokens(tokens)
+	checkOk(t, err)
+	// Like an else, the catch and finally nodes follow their try node.
+	checkNodeCount(t, structogram.Nodes, 5)
+	checkNode(t, structogram.Nodes[0], "try", "")
+	checkNode(t, structogram.Nodes[0].Nodes[0], "call", "b")
+	checkNode(t, structogram.Nodes[1], "catch", "IOException e")
+	checkNode(t, structogram.Nodes[1].Nodes[0], "instruction", "c")
+	checkNode(t, structogram.Nodes[2], "catch", "")
+	checkNode(t, structogram.Nodes[3], "finally", "")
+	checkNode(t, structogram.Nodes[3].Nodes[0], "instruction", "e")
+	checkNode(t, structogram.Nodes[4], "instruction", "f")
+
+	tokens = Tokenize(`name("a") try { call("b") } finally { call("c") }`)
+	structogram, err = ParseTokens(tokens)
+	checkOk(t, err)
+	checkNodeCount(t, structogram.Nodes, 2)
+
+	tokens = Tokenize(`name("a") try { call("b") } catch { call("c") }`)
+	_, err = ParseTokens(tokens)
+	checkErrorMsg(t, err, "1:35, expected 'openParentheses', but got 'openBrace'")
+}
+
+func TestTryNeedsCatchOrFinally(t *testing.T) {
+	tokens := Tokenize(`name("a") try { call("b") } call("c")`)
+	_, err := ParseTokens(tokens)
+	checkErrorMsg(t, err, "1:11, 'try' needs a catch or a finally")
+}
+
+func TestCatchAndFinallyNeedTry(t *testing.T) {
+	tokens := Tokenize(`name("a") catch("b") { call("c") }`)
+	_, err := ParseTokens(tokens)
+	checkErrorMsg(t, err, "1:11, expected 'statement', but got 'catch'")
+
+	tokens = Tokenize(`name("a") finally { call("c") }`)
+	_, err = ParseTokens(tokens)
+	checkErrorMsg(t, err, "1:11, expected 'statement', but got 'finally'")
+
+	// Nothing can follow the finally.
+	tokens = Tokenize(`name("a")
+		try { call("b") } finally { call("c") } catch("d") { call("e") }`)
+	_, err = ParseTokens(tokens)
+	checkErrorMsg(t, err, "2:43, expected 'statement', but got 'catch'")
+}
+
+func TestParseAllSplitsStructogramsAtNames(t *testing.T) {
+	structograms, err := ParseAllTokens(Tokenize(`name("a") instruction("b")
+		name("c") call("a()") if("d") {return("")}`))
+	checkOk(t, err)
+	if len(structograms) != 2 {
+		t.Fatalf("Expected 2 structograms, but got %d", len(structograms))
+	}
+	if structograms[0].Name != "a" || structograms[1].Name != "c" {
+		t.Errorf(
+			"Wrong names %s and %s",
+			structograms[0].Name, structograms[1].Name,
+		)
+	}
+	checkNodeCount(t, structograms[0].Nodes, 1)
+	checkNodeCount(t, structograms[1].Nodes, 2)
+	if structograms[1].NameStart != (Position{Line: 2, Column: 3}) {
+		t.Errorf("Wrong name position %v", structograms[1].NameStart)
+	}
+}
+
+func TestNameEndsUnclosedBodies(t *testing.T) {
+	structograms, err := ParseAllTokens(Tokenize(`name("a")
+while("b") {
+    instruction("c")
+name("d")
+instruction("e")`))
+	checkErrorMsg(t, err, "4:1, expected 'closeBrace', but got 'name'")
+	if len(structograms) != 2 || len(structograms[1].Nodes) != 1 {
+		t.Errorf("Expected the second structogram to be parsed")
+	}
+}
+
+func TestParseTokensOnlyAcceptsASingleStructogram(t *testing.T) {
+	_, err := ParseTokens(Tokenize("name(\"a\")\nname(\"b\")"))
+	checkErrorMsg(
+		t, err, "2:1, expected a single structogram, but found another name",
+	)
+}
+
+func TestImportsBelongToTheFollowingStructogram(t *testing.T) {
+	structograms, err := ParseAllTokens(Tokenize(`import("a.str")
+name("b") instruction("c")
+import("d/e.str") import("f.str")
+name("g")`))
+	checkOk(t, err)
+	if len(structograms) != 2 {
+		t.Fatalf("Expected 2 structograms, but got %d", len(structograms))
+	}
+	first, second := structograms[0].Imports, structograms[1].Imports
+	if len(first) != 1 || first[0].Path != "a.str" {
+		t.Errorf("Wrong imports of the first structogram %v", first)
+	}
+	if len(second) != 2 || second[0].Path != "d/e.str" ||
+		second[1].Path != "f.str" {
+		t.Errorf("Wrong imports of the second structogram %v", second)
+	}
+	if second[0].Start != (Position{Line: 3, Column: 1}) ||
+		second[0].End != (Position{Line: 3, Column: 18}) {
+		t.Errorf(
+			"Wrong span of the import %v to %v", second[0].Start, second[0].End,
+		)
+	}
+}
+
+func TestImportsAreNotAllowedInBodies(t *testing.T) {
+	_, err := ParseTokens(
+		Tokenize(`name("a") while("b") {instruction("x") import("c.str")}`),
+	)
+	checkErrorMsg(t, err, "1:40, 'import' is only allowed in front of a name")
+}
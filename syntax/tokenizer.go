@@ -0,0 +1,334 @@
+package syntax
+
+import (
+	"strings"
+)
+
+type Tokenizer struct {
+	input               []rune
+	runeIndex           int
+	nextRuneIdx         int
+	currentLineNumber   int
+	currentColumnNumber int
+	tokens              []Token
+	runes               []rune
+}
+
+func (t *Tokenizer) isEof() bool {
+	return t.nextRuneIdx > len(t.input)-1
+}
+
+func (t *Tokenizer) readNext() rune {
+	t.runeIndex = t.nextRuneIdx
+	t.nextRuneIdx++
+	r := t.input[t.runeIndex]
+	return r
+}
+
+func (t *Tokenizer) next() rune {
+	return t.input[t.nextRuneIdx]
+}
+
+func (t *Tokenizer) isNextWhitespace() bool {
+	return string(t.next()) == " " ||
+		string(t.next()) == "\t" ||
+		string(t.next()) == "\n"
+}
+
+func (t *Tokenizer) emitToken(tokenType string) {
+	v := string(t.runes)
+	if tokenType == "EOF" {
+		v = "EOF"
+	}
+	tok := Token{
+		Type:   tokenType,
+		Value:  v,
+		Line:   t.currentLineNumber,
+		Column: t.currentColumnNumber,
+	}
+	if tokenType != "EOF" {
+		t.advance(v)
+	}
+	tok.EndLine = t.currentLineNumber
+	tok.EndColumn = t.currentColumnNumber
+	t.tokens = append(t.tokens, tok)
+	t.runes = nil
+}
+
+// advance moves the current position past s, which may span multiple lines.
+func (t *Tokenizer) advance(s string) {
+	for _, r := range s {
+		if r == '\n' {
+			t.currentLineNumber++
+			t.currentColumnNumber = 1
+		} else {
+			t.currentColumnNumber++
+		}
+	}
+}
+
+// readString reads the rest of a string literal, whose opening quotation
+// mark is the only rune read so far. Three quotation marks open a multi-line
+// string, which may contain line breaks and ends with three quotation marks
+// as well. The common indentation of its lines is removed. An unterminated
+// string or an unknown escape sequence results in an invalid token.
+func (t *Tokenizer) readString() {
+	quot := t.runes[0]
+	multiline := t.hasAhead(quot, 2)
+	if multiline {
+		t.runes = append(t.runes, t.readNext(), t.readNext())
+	}
+
+	var value []rune
+	for {
+		if t.isEof() || !multiline && t.next() == '\n' {
+			t.emitToken("invalid")
+			return
+		}
+		r := t.readNext()
+		t.runes = append(t.runes, r)
+		if r == quot {
+			if !multiline {
+				break
+			}
+			if t.hasAhead(quot, 2) {
+				t.runes = append(t.runes, t.readNext(), t.readNext())
+				break
+			}
+		}
+		if r == '\\' {
+			escaped, ok := t.readEscape()
+			if !ok {
+				t.emitToken("invalid")
+				return
+			}
+			r = escaped
+		}
+		value = append(value, r)
+	}
+
+	str := string(value)
+	if multiline {
+		str = trimIndent(str)
+	}
+	stringToken := Token{
+		Type:   "string",
+		Value:  str,
+		Line:   t.currentLineNumber,
+		Column: t.currentColumnNumber,
+	}
+	// While we don't want the quotation marks and escape sequences in the
+	// value of the string, we do have to make sure the position is still
+	// correct.
+	t.advance(string(t.runes))
+	stringToken.EndLine = t.currentLineNumber
+	stringToken.EndColumn = t.currentColumnNumber
+	t.tokens = append(t.tokens, stringToken)
+	t.runes = nil
+}
+
+// hasAhead reports whether the next n runes are all r.
+func (t *Tokenizer) hasAhead(r rune, n int) bool {
+	if t.nextRuneIdx+n > len(t.input) {
+		return false
+	}
+	for _, next := range t.input[t.nextRuneIdx : t.nextRuneIdx+n] {
+		if next != r {
+			return false
+		}
+	}
+	return true
+}
+
+// readEscape reads the escape sequence following a backslash and returns the
+// rune it stands for.
+func (t *Tokenizer) readEscape() (rune, bool) {
+	if t.isEof() {
+		return 0, false
+	}
+	r := t.readNext()
+	t.runes = append(t.runes, r)
+	switch r {
+	case '"', '\'', '\\':
+		return r, true
+	case 'n':
+		return '\n', true
+	case 't':
+		return '\t', true
+	case 'u':
+		var code rune
+		for i := 0; i < 4; i++ {
+			if t.isEof() {
+				return 0, false
+			}
+			digit := t.readNext()
+			t.runes = append(t.runes, digit)
+			v, ok := hexValue(digit)
+			if !ok {
+				return 0, false
+			}
+			code = code*16 + v
+		}
+		return code, true
+	}
+	return 0, false
+}
+
+func hexValue(r rune) (rune, bool) {
+	switch {
+	case r >= '0' && r <= '9':
+		return r - '0', true
+	case r >= 'a' && r <= 'f':
+		return r - 'a' + 10, true
+	case r >= 'A' && r <= 'F':
+		return r - 'A' + 10, true
+	}
+	return 0, false
+}
+
+// trimIndent removes the line breaks directly after the opening and before
+// the closing quotation marks of a multi-line string, as well as the
+// indentation that all of its non-blank lines have in common.
+func trimIndent(s string) string {
+	lines := strings.Split(s, "\n")
+	if len(lines) > 1 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	if len(lines) > 1 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	indent := -1
+	for _, l := range lines {
+		if strings.TrimSpace(l) == "" {
+			continue
+		}
+		n := len(l) - len(strings.TrimLeft(l, " \t"))
+		if indent == -1 || n < indent {
+			indent = n
+		}
+	}
+	for i, l := range lines {
+		if strings.TrimSpace(l) == "" {
+			lines[i] = ""
+		} else {
+			lines[i] = l[indent:]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func isDelimiter(r rune) bool {
+	switch r {
+	case '(', ')', '{', '}', '"', '\'', '\n':
+		return true
+	}
+	return false
+}
+
+// Tokenize splits the .str source s into tokens. Anything that is not part
+// of the syntax becomes an invalid token. The last token is always EOF.
+func Tokenize(s string) []Token {
+	t := Tokenizer{
+		runeIndex:           0,
+		nextRuneIdx:         0,
+		currentLineNumber:   1,
+		currentColumnNumber: 1,
+	}
+	t.nextRuneIdx = 0
+	t.input = []rune(s)
+	for !t.isEof() {
+		r := t.readNext()
+		t.runes = append(t.runes, r)
+		switch string(t.runes) {
+		case "name":
+			t.emitToken("name")
+		case "import":
+			t.emitToken("import")
+		case "(":
+			t.emitToken("openParentheses")
+		case ")":
+			t.emitToken("closeParentheses")
+		case "if":
+			t.emitToken("if")
+		case "{":
+			t.emitToken("openBrace")
+		case "}":
+			t.emitToken("closeBrace")
+		case "instruction":
+			t.emitToken("instruction")
+		case "else":
+			t.emitToken("else")
+		case "call":
+			t.emitToken("call")
+		case "while":
+			t.emitToken("while")
+		case "dowhile":
+			t.emitToken("dowhile")
+		case "for":
+			t.emitToken("for")
+		case "switch":
+			t.emitToken("switch")
+		case "default":
+			t.emitToken("default")
+		case "case":
+			t.emitToken("case")
+		case "break":
+			t.emitToken("break")
+		case "continue":
+			t.emitToken("continue")
+		case "return":
+			t.emitToken("return")
+		case "exit":
+			t.emitToken("exit")
+		case "parallel":
+			t.emitToken("parallel")
+		case "branch":
+			t.emitToken("branch")
+		case "try":
+			t.emitToken("try")
+		case "catch":
+			t.emitToken("catch")
+		case "finally":
+			t.emitToken("finally")
+		case "//":
+			for !t.isEof() && string(t.next()) != "\n" {
+				t.runes = append(t.runes, t.readNext())
+			}
+			t.emitToken("comment")
+		case "/*":
+			terminated := false
+			for !t.isEof() {
+				t.runes = append(t.runes, t.readNext())
+				// The opening "/*" must not be part of the closing "*/".
+				n := len(t.runes)
+				if n >= 4 && t.runes[n-2] == '*' && t.runes[n-1] == '/' {
+					terminated = true
+					break
+				}
+			}
+			if terminated {
+				t.emitToken("comment")
+			} else {
+				t.emitToken("invalid")
+			}
+		case `"`, "'":
+			t.readString()
+		case " ", "\t", "\n":
+			for !t.isEof() && t.isNextWhitespace() {
+				t.runes = append(t.runes, t.readNext())
+			}
+			t.emitToken("whitespace")
+		}
+		// Runes that are not part of any token end at the next rune that
+		// starts a token on its own, so the parser can recover after them.
+		if len(t.runes) != 0 && !t.isEof() && isDelimiter(t.next()) {
+			t.emitToken("invalid")
+		}
+	}
+	if len(t.runes) != 0 {
+		t.emitToken("invalid")
+	}
+	t.emitToken("EOF")
+	return t.tokens
+}
@@ -1,4 +1,4 @@
-package main
+package syntax
 
 import (
 	"fmt"
@@ -16,44 +16,44 @@ func checkTokenCount(t *testing.T, tokens []Token, count int) {
 
 func checkTokenType(t *testing.T, token Token, typeString string) {
 	t.Helper()
-	if token.tokenType != typeString {
+	if token.Type != typeString {
 		t.Errorf(fmt.Sprintf(
 			"Expected token of type: '%s', but got type: '%s'",
 			typeString,
-			token.tokenType,
+			token.Type,
 		))
 	}
 }
 
 func checkTokenValue(t *testing.T, token Token, value string) {
 	t.Helper()
-	if token.value != value {
+	if token.Value != value {
 		t.Errorf(fmt.Sprintf(
 			"Expected token with value: %s, but got value: %s",
 			value,
-			token.value,
+			token.Value,
 		))
 	}
 }
 
 func checkTokenLineNumber(t *testing.T, token Token, lineNumber int) {
 	t.Helper()
-	if token.line != lineNumber {
+	if token.Line != lineNumber {
 		t.Errorf(fmt.Sprintf(
 			"Expected token with line number: %d, but got line number: %d",
 			lineNumber,
-			token.line,
+			token.Line,
 		))
 	}
 }
 
 func checkTokenColumnNumber(t *testing.T, token Token, columnNumber int) {
 	t.Helper()
-	if token.column != columnNumber {
+	if token.Column != columnNumber {
 		t.Errorf(fmt.Sprintf(
 			"Expected token with column number: %d, but got column number: %d",
 			columnNumber,
-			token.column,
+			token.Column,
 		))
 	}
 }
@@ -70,84 +70,91 @@ func checkToken(
 }
 
 func TestCanTokenizeName(t *testing.T) {
-	tokens := makeTokens("name")
+	tokens := Tokenize("name")
 	checkTokenCount(t, tokens, 2)
 	checkToken(t, tokens[0], "name", "name", 1, 1)
 	checkToken(t, tokens[1], "EOF", "EOF", 1, 5)
 }
 
+func TestCanTokenizeImport(t *testing.T) {
+	tokens := Tokenize("import")
+	checkTokenCount(t, tokens, 2)
+	checkToken(t, tokens[0], "import", "import", 1, 1)
+	checkToken(t, tokens[1], "EOF", "EOF", 1, 7)
+}
+
 func TestCanTokenizeOpenParentheses(t *testing.T) {
-	tokens := makeTokens("(")
+	tokens := Tokenize("(")
 	checkTokenCount(t, tokens, 2)
 	checkToken(t, tokens[0], "openParentheses", "(", 1, 1)
 	checkToken(t, tokens[1], "EOF", "EOF", 1, 2)
 }
 
 func TestCanTokenizeCloseParentheses(t *testing.T) {
-	tokens := makeTokens(")")
+	tokens := Tokenize(")")
 	checkTokenCount(t, tokens, 2)
 	checkToken(t, tokens[0], "closeParentheses", ")", 1, 1)
 	checkToken(t, tokens[1], "EOF", "EOF", 1, 2)
 }
 
 func TestCanTokenizeString(t *testing.T) {
-	tokens := makeTokens(`"a test string"`)
+	tokens := Tokenize(`"a test string"`)
 	checkTokenCount(t, tokens, 2)
 	checkToken(t, tokens[0], "string", "a test string", 1, 1)
 	checkToken(t, tokens[1], "EOF", "EOF", 1, 16)
 }
 
 func TestCanTokenizeInstruction(t *testing.T) {
-	tokens := makeTokens("instruction")
+	tokens := Tokenize("instruction")
 	checkTokenCount(t, tokens, 2)
 	checkToken(t, tokens[0], "instruction", "instruction", 1, 1)
 	checkToken(t, tokens[1], "EOF", "EOF", 1, 12)
 }
 
 func TestCanTokenizeSpace(t *testing.T) {
-	tokens := makeTokens(" ")
+	tokens := Tokenize(" ")
 	checkTokenCount(t, tokens, 2)
 	checkToken(t, tokens[0], "whitespace", " ", 1, 1)
 	checkToken(t, tokens[1], "EOF", "EOF", 1, 2)
 }
 
 func TestCanTokenizeMultipleSpaces(t *testing.T) {
-	tokens := makeTokens("  ")
+	tokens := Tokenize("  ")
 	checkTokenCount(t, tokens, 2)
 	checkToken(t, tokens[0], "whitespace", "  ", 1, 1)
 	checkToken(t, tokens[1], "EOF", "EOF", 1, 3)
 }
 
 func TestCanTokenizeTabs(t *testing.T) {
-	tokens := makeTokens("\t")
+	tokens := Tokenize("\t")
 	checkTokenCount(t, tokens, 2)
 	checkToken(t, tokens[0], "whitespace", "\t", 1, 1)
 	checkToken(t, tokens[1], "EOF", "EOF", 1, 2)
 }
 
 func TestCanTokenizeMultipleTabs(t *testing.T) {
-	tokens := makeTokens("\t\t")
+	tokens := Tokenize("\t\t")
 	checkTokenCount(t, tokens, 2)
 	checkToken(t, tokens[0], "whitespace", "\t\t", 1, 1)
 	checkToken(t, tokens[1], "EOF", "EOF", 1, 3)
 }
 
 func TestCanTokenizeNewlines(t *testing.T) {
-	tokens := makeTokens("\n")
+	tokens := Tokenize("\n")
 	checkTokenCount(t, tokens, 2)
 	checkToken(t, tokens[0], "whitespace", "\n", 1, 1)
 	checkToken(t, tokens[1], "EOF", "EOF", 2, 1)
 }
 
 func TestCanTokenizeMultipleNewlines(t *testing.T) {
-	tokens := makeTokens("\n\n")
+	tokens := Tokenize("\n\n")
 	checkTokenCount(t, tokens, 2)
 	checkToken(t, tokens[0], "whitespace", "\n\n", 1, 1)
 	checkToken(t, tokens[1], "EOF", "EOF", 3, 1)
 }
 
 func TestTokenizingNewlineAdvancesLineNumber(t *testing.T) {
-	tokens := makeTokens("name\ninstruction\n\ninstruction")
+	tokens := Tokenize("name\ninstruction\n\ninstruction")
 	checkTokenCount(t, tokens, 6)
 	checkToken(t, tokens[0], "name", "name", 1, 1)
 	checkToken(t, tokens[1], "whitespace", "\n", 1, 5)
@@ -158,7 +165,7 @@ func TestTokenizingNewlineAdvancesLineNumber(t *testing.T) {
 }
 
 func TestDifferentWhitespacesAreOneToken(t *testing.T) {
-	tokens := makeTokens("\t \nname")
+	tokens := Tokenize("\t \nname")
 	checkTokenCount(t, tokens, 3)
 	checkToken(t, tokens[0], "whitespace", "\t \n", 1, 1)
 	checkToken(t, tokens[1], "name", "name", 2, 1)
@@ -166,7 +173,7 @@ func TestDifferentWhitespacesAreOneToken(t *testing.T) {
 }
 
 func TestCanTokenizeMultipleTokens(t *testing.T) {
-	tokens := makeTokens(`name("a name") instruction("do this")`)
+	tokens := Tokenize(`name("a name") instruction("do this")`)
 	checkTokenCount(t, tokens, 10)
 
 	checkToken(t, tokens[0], "name", "name", 1, 1)
@@ -182,19 +189,19 @@ func TestCanTokenizeMultipleTokens(t *testing.T) {
 }
 
 func TestInvalidTokenAdvancesColumnByLengthOfInvalidString(t *testing.T) {
-	tokens := makeTokens("invalid")
+	tokens := Tokenize("invalid")
 	checkTokenCount(t, tokens, 2)
 	checkToken(t, tokens[0], "invalid", "invalid", 1, 1)
 	checkToken(t, tokens[1], "EOF", "EOF", 1, 8)
 }
 
 func TestCanTokenizeInvalidStrings(t *testing.T) {
-	tokens := makeTokens("some invalid string")
+	tokens := Tokenize("some invalid string")
 	checkTokenCount(t, tokens, 2)
 	checkToken(t, tokens[0], "invalid", "some invalid string", 1, 1)
 	checkToken(t, tokens[1], "EOF", "EOF", 1, 20)
 
-	tokens = makeTokens(`name("some name")invalid`)
+	tokens = Tokenize(`name("some name")invalid`)
 	checkTokenCount(t, tokens, 6)
 	checkToken(t, tokens[0], "name", "name", 1, 1)
 	checkToken(t, tokens[1], "openParentheses", "(", 1, 5)
@@ -205,13 +212,13 @@ func TestCanTokenizeInvalidStrings(t *testing.T) {
 }
 
 func TestCanTokenizeEof(t *testing.T) {
-	tokens := makeTokens("")
+	tokens := Tokenize("")
 	checkTokenCount(t, tokens, 1)
 	checkToken(t, tokens[0], "EOF", "EOF", 1, 1)
 }
 
 func TestStringTokensCanBeDelimitedByBothQuotationMarkTypes(t *testing.T) {
-	tokens := makeTokens(`"'a'"'"b"'`)
+	tokens := Tokenize(`"'a'"'"b"'`)
 	checkTokenCount(t, tokens, 3)
 	checkToken(t, tokens[0], "string", "'a'", 1, 1)
 	checkToken(t, tokens[1], "string", `"b"`, 1, 6)
@@ -219,57 +226,224 @@ func TestStringTokensCanBeDelimitedByBothQuotationMarkTypes(t *testing.T) {
 }
 
 func TestCanTokenizeIf(t *testing.T) {
-	tokens := makeTokens("if")
+	tokens := Tokenize("if")
 	checkTokenCount(t, tokens, 2)
 	checkToken(t, tokens[0], "if", "if", 1, 1)
 	checkToken(t, tokens[1], "EOF", "EOF", 1, 3)
 }
 
 func TestCanTokenizeOpenBrace(t *testing.T) {
-	tokens := makeTokens("{")
+	tokens := Tokenize("{")
 	checkTokenCount(t, tokens, 2)
 	checkToken(t, tokens[0], "openBrace", "{", 1, 1)
 	checkToken(t, tokens[1], "EOF", "EOF", 1, 2)
 }
 
 func TestCanTokenizeCloseBrace(t *testing.T) {
-	tokens := makeTokens("}")
+	tokens := Tokenize("}")
 	checkTokenCount(t, tokens, 2)
 	checkToken(t, tokens[0], "closeBrace", "}", 1, 1)
 	checkToken(t, tokens[1], "EOF", "EOF", 1, 2)
 }
 
 func TestCanTokenizeElse(t *testing.T) {
-	tokens := makeTokens("else")
+	tokens := Tokenize("else")
 	checkTokenCount(t, tokens, 2)
 	checkToken(t, tokens[0], "else", "else", 1, 1)
 	checkToken(t, tokens[1], "EOF", "EOF", 1, 5)
 }
 
 func TestCanTokenizeCall(t *testing.T) {
-	tokens := makeTokens("call")
+	tokens := Tokenize("call")
 	checkTokenCount(t, tokens, 2)
 	checkToken(t, tokens[0], "call", "call", 1, 1)
 	checkToken(t, tokens[1], "EOF", "EOF", 1, 5)
 }
 
 func TestCanTokenizeWhile(t *testing.T) {
-	tokens := makeTokens("while")
+	tokens := Tokenize("while")
 	checkTokenCount(t, tokens, 2)
 	checkToken(t, tokens[0], "while", "while", 1, 1)
 	checkToken(t, tokens[1], "EOF", "EOF", 1, 6)
 }
 
 func TestCanTokenizeDoWhile(t *testing.T) {
-	tokens := makeTokens("dowhile")
+	tokens := Tokenize("dowhile")
 	checkTokenCount(t, tokens, 2)
 	checkToken(t, tokens[0], "dowhile", "dowhile", 1, 1)
 	checkToken(t, tokens[1], "EOF", "EOF", 1, 8)
 }
 
 func TestCanTokenizeSwitch(t *testing.T) {
-	tokens := makeTokens("switch")
+	tokens := Tokenize("switch")
 	checkTokenCount(t, tokens, 2)
 	checkToken(t, tokens[0], "switch", "switch", 1, 1)
 	checkToken(t, tokens[1], "EOF", "EOF", 1, 7)
 }
+
+func TestCanTokenizeLineComment(t *testing.T) {
+	tokens := Tokenize("// a comment\nname")
+	checkTokenCount(t, tokens, 4)
+	checkToken(t, tokens[0], "comment", "// a comment", 1, 1)
+	checkToken(t, tokens[1], "whitespace", "\n", 1, 13)
+	checkToken(t, tokens[2], "name", "name", 2, 1)
+	checkToken(t, tokens[3], "EOF", "EOF", 2, 5)
+}
+
+func TestLineCommentCanEndAtEof(t *testing.T) {
+	tokens := Tokenize(`name("a") // comment`)
+	checkTokenCount(t, tokens, 7)
+	checkToken(t, tokens[5], "comment", "// comment", 1, 11)
+	checkToken(t, tokens[6], "EOF", "EOF", 1, 21)
+}
+
+func TestCanTokenizeBlockComment(t *testing.T) {
+	tokens := Tokenize("/* a */name")
+	checkTokenCount(t, tokens, 3)
+	checkToken(t, tokens[0], "comment", "/* a */", 1, 1)
+	checkToken(t, tokens[1], "name", "name", 1, 8)
+	checkToken(t, tokens[2], "EOF", "EOF", 1, 12)
+}
+
+func TestBlockCommentsCanSpanMultipleLines(t *testing.T) {
+	tokens := Tokenize("/* a\n * b\n */ name")
+	checkTokenCount(t, tokens, 4)
+	checkToken(t, tokens[0], "comment", "/* a\n * b\n */", 1, 1)
+	checkToken(t, tokens[1], "whitespace", " ", 3, 4)
+	checkToken(t, tokens[2], "name", "name", 3, 5)
+	checkToken(t, tokens[3], "EOF", "EOF", 3, 9)
+}
+
+func TestBlockCommentOpeningIsNotPartOfClosing(t *testing.T) {
+	tokens := Tokenize("/*/ a */")
+	checkTokenCount(t, tokens, 2)
+	checkToken(t, tokens[0], "comment", "/*/ a */", 1, 1)
+}
+
+func TestUnterminatedBlockCommentIsInvalid(t *testing.T) {
+	tokens := Tokenize("/* a\nb")
+	checkTokenCount(t, tokens, 2)
+	checkToken(t, tokens[0], "invalid", "/* a\nb", 1, 1)
+	checkToken(t, tokens[1], "EOF", "EOF", 2, 2)
+}
+
+func TestCommentDelimitersInsideStringsAreNotComments(t *testing.T) {
+	tokens := Tokenize(`"a // b /* c */"`)
+	checkTokenCount(t, tokens, 2)
+	checkToken(t, tokens[0], "string", "a // b /* c */", 1, 1)
+}
+
+func TestCanTokenizeEscapeSequences(t *testing.T) {
+	tokens := Tokenize(`"name == \"Bob\"" 'it\'s' "a\\b" "\n\t"`)
+	checkTokenCount(t, tokens, 8)
+	checkToken(t, tokens[0], "string", `name == "Bob"`, 1, 1)
+	checkToken(t, tokens[2], "string", "it's", 1, 19)
+	checkToken(t, tokens[4], "string", `a\b`, 1, 27)
+	checkToken(t, tokens[6], "string", "\n\t", 1, 34)
+	checkToken(t, tokens[7], "EOF", "EOF", 1, 40)
+}
+
+func TestCanTokenizeUnicodeEscapeSequences(t *testing.T) {
+	tokens := Tokenize(`"\u00e4\u00DF"`)
+	checkTokenCount(t, tokens, 2)
+	checkToken(t, tokens[0], "string", "äß", 1, 1)
+	checkToken(t, tokens[1], "EOF", "EOF", 1, 15)
+}
+
+func TestUnknownEscapeSequencesAreInvalid(t *testing.T) {
+	tokens := Tokenize(`"a\qb"`)
+	checkTokenCount(t, tokens, 4)
+	checkToken(t, tokens[0], "invalid", `"a\q`, 1, 1)
+
+	tokens = Tokenize(`"\u00g0"`)
+	checkToken(t, tokens[0], "invalid", `"\u00g`, 1, 1)
+}
+
+func TestUnterminatedStringsAreInvalid(t *testing.T) {
+	tokens := Tokenize(`"abc`)
+	checkTokenCount(t, tokens, 2)
+	checkToken(t, tokens[0], "invalid", `"abc`, 1, 1)
+	checkToken(t, tokens[1], "EOF", "EOF", 1, 5)
+
+	tokens = Tokenize(`"abc\`)
+	checkToken(t, tokens[0], "invalid", `"abc\`, 1, 1)
+}
+
+func TestStringsCanNotContainLineBreaks(t *testing.T) {
+	tokens := Tokenize("\"a\nb\"")
+	checkToken(t, tokens[0], "invalid", `"a`, 1, 1)
+	checkToken(t, tokens[1], "whitespace", "\n", 1, 3)
+}
+
+func TestNonAsciiRunesAdvanceColumnByOne(t *testing.T) {
+	tokens := Tokenize(`"äö"name`)
+	checkTokenCount(t, tokens, 3)
+	checkToken(t, tokens[0], "string", "äö", 1, 1)
+	checkToken(t, tokens[1], "name", "name", 1, 5)
+}
+
+func TestCanTokenizeMultilineStrings(t *testing.T) {
+	tokens := Tokenize("\"\"\"\n    a = 1\n      b = \"2\"\n    \"\"\" name")
+	checkTokenCount(t, tokens, 4)
+	checkToken(t, tokens[0], "string", "a = 1\n  b = \"2\"", 1, 1)
+	checkToken(t, tokens[1], "whitespace", " ", 4, 8)
+	checkToken(t, tokens[2], "name", "name", 4, 9)
+}
+
+func TestMultilineStringsCanBeSingleQuoted(t *testing.T) {
+	tokens := Tokenize(`'''it's "a"'''`)
+	checkTokenCount(t, tokens, 2)
+	checkToken(t, tokens[0], "string", `it's "a"`, 1, 1)
+	checkToken(t, tokens[1], "EOF", "EOF", 1, 15)
+}
+
+func TestUnterminatedMultilineStringsAreInvalid(t *testing.T) {
+	tokens := Tokenize("\"\"\"a\n\"\"")
+	checkTokenCount(t, tokens, 2)
+	checkToken(t, tokens[0], "invalid", "\"\"\"a\n\"\"", 1, 1)
+	checkToken(t, tokens[1], "EOF", "EOF", 2, 3)
+}
+
+func TestEmptyStringIsNotMultiline(t *testing.T) {
+	tokens := Tokenize(`""name`)
+	checkTokenCount(t, tokens, 3)
+	checkToken(t, tokens[0], "string", "", 1, 1)
+	checkToken(t, tokens[1], "name", "name", 1, 3)
+}
+
+func TestInvalidTokensEndBeforeDelimiters(t *testing.T) {
+	tokens := Tokenize("if(a b){c\nd}")
+	checkTokenCount(t, tokens, 10)
+	checkToken(t, tokens[2], "invalid", "a b", 1, 4)
+	checkToken(t, tokens[3], "closeParentheses", ")", 1, 7)
+	checkToken(t, tokens[5], "invalid", "c", 1, 9)
+	checkToken(t, tokens[6], "whitespace", "\n", 1, 10)
+	checkToken(t, tokens[7], "invalid", "d", 2, 1)
+	checkToken(t, tokens[8], "closeBrace", "}", 2, 2)
+}
+
+func TestCanTokenizeJumps(t *testing.T) {
+	tokens := Tokenize("break continue return exit")
+	checkTokenCount(t, tokens, 8)
+	checkTokenType(t, tokens[0], "break")
+	checkTokenType(t, tokens[2], "continue")
+	checkTokenType(t, tokens[4], "return")
+	checkTokenType(t, tokens[6], "exit")
+}
+
+func TestCanTokenizeParallel(t *testing.T) {
+	tokens := Tokenize("parallel{branch{")
+	checkTokenCount(t, tokens, 5)
+	checkTokenType(t, tokens[0], "parallel")
+	checkTokenType(t, tokens[1], "openBrace")
+	checkTokenType(t, tokens[2], "branch")
+	checkTokenType(t, tokens[3], "openBrace")
+}
+
+func TestCanTokenizeExceptionHandling(t *testing.T) {
+	tokens := Tokenize("try catch finally")
+	checkTokenCount(t, tokens, 6)
+	checkTokenType(t, tokens[0], "try")
+	checkTokenType(t, tokens[2], "catch")
+	checkTokenType(t, tokens[4], "finally")
+}